@@ -0,0 +1,181 @@
+package achievement
+
+import (
+	"strings"
+
+	"github.com/automuteus/automuteus/amongus"
+	"github.com/automuteus/utils/pkg/game"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// Progress is what achievements are evaluated against: a player's stats on a guild, including the game that just ended
+type Progress struct {
+	Games        int64 `db:"games"`
+	Wins         int64 `db:"wins"`
+	CrewmateWins int64 `db:"crewmate_wins"`
+	ImposterWins int64 `db:"imposter_wins"`
+
+	// ImposterWinStreak is how many of the player's latest imposter games they won in a row
+	ImposterWinStreak int64 `db:"imposter_win_streak"`
+
+	// LastCrewmate is if the player won the game that just ended as the last crewmate alive
+	LastCrewmate bool
+}
+
+type Achievement struct {
+	// ID is stored in Postgres, so it can't change once an achievement is released
+	ID          string
+	Emoji       string
+	Name        *i18n.Message
+	Description *i18n.Message
+
+	unlocked func(p Progress) bool
+}
+
+// All is every achievement, in the order they're listed
+var All = []*Achievement{
+	{
+		ID:    "first-win",
+		Emoji: "🎉",
+		Name: &i18n.Message{
+			ID:    "achievements.firstWin.name",
+			Other: "First Victory",
+		},
+		Description: &i18n.Message{
+			ID:    "achievements.firstWin.desc",
+			Other: "Win a game",
+		},
+		unlocked: func(p Progress) bool {
+			return p.Wins >= 1
+		},
+	},
+	{
+		ID:    "games-100",
+		Emoji: "💯",
+		Name: &i18n.Message{
+			ID:    "achievements.games100.name",
+			Other: "Centurion",
+		},
+		Description: &i18n.Message{
+			ID:    "achievements.games100.desc",
+			Other: "Play 100 games",
+		},
+		unlocked: func(p Progress) bool {
+			return p.Games >= 100
+		},
+	},
+	{
+		ID:    "crewmate-wins-50",
+		Emoji: "🔧",
+		Name: &i18n.Message{
+			ID:    "achievements.crewmateWins50.name",
+			Other: "Crew Veteran",
+		},
+		Description: &i18n.Message{
+			ID:    "achievements.crewmateWins50.desc",
+			Other: "Win 50 games as a crewmate",
+		},
+		unlocked: func(p Progress) bool {
+			return p.CrewmateWins >= 50
+		},
+	},
+	{
+		ID:    "imposter-wins-25",
+		Emoji: "🗡️",
+		Name: &i18n.Message{
+			ID:    "achievements.imposterWins25.name",
+			Other: "Impostor Syndrome",
+		},
+		Description: &i18n.Message{
+			ID:    "achievements.imposterWins25.desc",
+			Other: "Win 25 games as an imposter",
+		},
+		unlocked: func(p Progress) bool {
+			return p.ImposterWins >= 25
+		},
+	},
+	{
+		ID:    "imposter-streak-3",
+		Emoji: "🔪",
+		Name: &i18n.Message{
+			ID:    "achievements.imposterStreak3.name",
+			Other: "Hat Trick",
+		},
+		Description: &i18n.Message{
+			ID:    "achievements.imposterStreak3.desc",
+			Other: "Win 3 imposter games in a row",
+		},
+		unlocked: func(p Progress) bool {
+			return p.ImposterWinStreak >= 3
+		},
+	},
+	{
+		ID:    "last-crewmate",
+		Emoji: "🛡️",
+		Name: &i18n.Message{
+			ID:    "achievements.lastCrewmate.name",
+			Other: "Last One Standing",
+		},
+		Description: &i18n.Message{
+			ID:    "achievements.lastCrewmate.desc",
+			Other: "Win a game as the last crewmate alive",
+		},
+		unlocked: func(p Progress) bool {
+			return p.LastCrewmate
+		},
+	},
+}
+
+// Get returns the achievement with an ID, or nil if there isn't one
+func Get(id string) *Achievement {
+	for _, v := range All {
+		if v.ID == id {
+			return v
+		}
+	}
+	return nil
+}
+
+// Unlocked returns every achievement a player has earned with their progress, whether they already had it or not
+func Unlocked(p Progress) []*Achievement {
+	unlocked := make([]*Achievement, 0)
+	for _, v := range All {
+		if v.unlocked(p) {
+			unlocked = append(unlocked, v)
+		}
+	}
+	return unlocked
+}
+
+// LastCrewmate is if the player with a name was the only crewmate still alive when the crewmates won a game. players
+// is the game's player data when it ended
+func LastCrewmate(name string, gameOver game.Gameover, players map[string]amongus.PlayerData) bool {
+	switch gameOver.GameOverReason {
+	case game.HumansByVote, game.HumansByTask, game.HumansDisconnect:
+	default:
+		return false
+	}
+	isImposter := func(name string) bool {
+		for _, v := range gameOver.PlayerInfos {
+			if v.IsImpostor && strings.EqualFold(v.Name, name) {
+				return true
+			}
+		}
+		return false
+	}
+	crewmates, alive, found := 0, 0, false
+	for _, v := range players {
+		if isImposter(v.Name) {
+			continue
+		}
+		crewmates++
+		if v.IsAlive {
+			alive++
+			if strings.EqualFold(v.Name, name) {
+				found = true
+			}
+		}
+	}
+	// it doesn't count if there was nobody else on the crew
+	return found && alive == 1 && crewmates > 1
+}
@@ -0,0 +1,85 @@
+package achievement
+
+import (
+	"testing"
+
+	"github.com/automuteus/automuteus/amongus"
+	"github.com/automuteus/utils/pkg/game"
+)
+
+func ids(achievements []*Achievement) map[string]bool {
+	m := make(map[string]bool)
+	for _, v := range achievements {
+		m[v.ID] = true
+	}
+	return m
+}
+
+func TestUnlocked(t *testing.T) {
+	if unlocked := Unlocked(Progress{Games: 1}); len(unlocked) != 0 {
+		t.Errorf("Expected nothing to be unlocked after a lost game, got %d achievements", len(unlocked))
+	}
+
+	unlocked := ids(Unlocked(Progress{Games: 100, Wins: 60, ImposterWins: 10, ImposterWinStreak: 3}))
+	for _, id := range []string{"first-win", "games-100", "imposter-streak-3"} {
+		if !unlocked[id] {
+			t.Errorf("Expected %s to be unlocked", id)
+		}
+	}
+	for _, id := range []string{"crewmate-wins-50", "imposter-wins-25", "last-crewmate"} {
+		if unlocked[id] {
+			t.Errorf("Expected %s to still be locked", id)
+		}
+	}
+}
+
+func TestAchievementIDs(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, v := range All {
+		if seen[v.ID] {
+			t.Errorf("Duplicate achievement ID %s", v.ID)
+		}
+		seen[v.ID] = true
+		if len(v.ID) > 32 {
+			t.Errorf("Achievement ID %s is too long to store", v.ID)
+		}
+		if Get(v.ID) != v {
+			t.Errorf("Expected Get to find %s", v.ID)
+		}
+	}
+	if Get("nope") != nil {
+		t.Error("Expected no achievement for an unknown ID")
+	}
+}
+
+func TestLastCrewmate(t *testing.T) {
+	players := map[string]amongus.PlayerData{
+		"Red":   {Name: "Red", IsAlive: true},
+		"Blue":  {Name: "Blue", IsAlive: false},
+		"Green": {Name: "Green", IsAlive: false},
+		"Pink":  {Name: "Pink", IsAlive: false},
+	}
+	gameOver := game.Gameover{
+		GameOverReason: game.HumansByVote,
+		PlayerInfos: []game.PlayerInfo{
+			{Name: "Pink", IsImpostor: true},
+		},
+	}
+	if !LastCrewmate("red", gameOver, players) {
+		t.Error("Expected Red to be the last crewmate")
+	}
+	if LastCrewmate("Blue", gameOver, players) {
+		t.Error("Expected Blue not to count, since they died")
+	}
+
+	players["Green"] = amongus.PlayerData{Name: "Green", IsAlive: true}
+	if LastCrewmate("Red", gameOver, players) {
+		t.Error("Expected Red not to count with another crewmate alive")
+	}
+
+	players["Green"] = amongus.PlayerData{Name: "Green", IsAlive: false}
+	gameOver.GameOverReason = game.ImpostorBySabotage
+	if LastCrewmate("Red", gameOver, players) {
+		t.Error("Expected Red not to count when the imposters won")
+	}
+}
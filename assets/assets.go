@@ -0,0 +1,8 @@
+package assets
+
+import "embed"
+
+// Emojis are the crewmate images the bot uploads as emojis, named au<color>.png and au<color>dead.png
+//
+//go:embed emojis/*.png
+var Emojis embed.FS
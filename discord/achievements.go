@@ -0,0 +1,227 @@
+package discord
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/automuteus/automuteus/achievement"
+	"github.com/automuteus/automuteus/discord/command"
+	"github.com/automuteus/automuteus/storage"
+	"github.com/automuteus/utils/pkg/discord"
+	"github.com/automuteus/utils/pkg/game"
+	"github.com/automuteus/utils/pkg/settings"
+	storageutils "github.com/automuteus/utils/pkg/storage"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// awardAchievements unlocks the achievements players earned with a game that was just recorded, and announces them
+// where the match summary goes
+func (bot *Bot) awardAchievements(dgs GameState, gameOver game.Gameover, userGames []*storageutils.PostgresUserGame, sett *settings.GuildSettings) {
+	gid, err := strconv.ParseUint(dgs.GuildID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	disabled, err := storage.GetDisabledAchievements(bot.PostgresInterface.Pool, gid)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	now := int32(time.Now().Unix())
+	lines := make([]string, 0)
+	for _, v := range userGames {
+		progress, err := storage.GetAchievementProgress(bot.PostgresInterface.Pool, gid, v.UserID)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		progress.LastCrewmate = v.PlayerRole == int16(game.CrewmateRole) &&
+			achievement.LastCrewmate(v.PlayerName, gameOver, dgs.GameData.PlayerData)
+
+		ids := make([]string, 0)
+		for _, a := range achievement.Unlocked(*progress) {
+			if !disabled[a.ID] {
+				ids = append(ids, a.ID)
+			}
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		unlocked, err := storage.UnlockAchievements(bot.PostgresInterface.Pool, gid, v.UserID, dgs.MatchID, ids, now)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		for _, id := range unlocked {
+			if a := achievement.Get(id); a != nil {
+				lines = append(lines, sett.LocalizeMessage(&i18n.Message{
+					ID:    "responses.achievements.unlocked",
+					Other: "{{.User}} unlocked {{.Emoji}} **{{.Name}}**: {{.Desc}}",
+				}, map[string]interface{}{
+					"User":  discord.MentionByUserID(strconv.FormatUint(v.UserID, 10)),
+					"Emoji": a.Emoji,
+					"Name":  sett.LocalizeMessage(a.Name),
+					"Desc":  sett.LocalizeMessage(a.Description),
+				}))
+			}
+		}
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	channelID := dgs.GameStateMsg.MessageChannelID
+	if sett.GetMatchSummaryChannelID() != "" {
+		channelID = sett.GetMatchSummaryChannelID()
+	}
+	if channelID == "" {
+		return
+	}
+	_, err = bot.PrimarySession.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds: []*discordgo.MessageEmbed{
+			{
+				Title: sett.LocalizeMessage(&i18n.Message{
+					ID:    "responses.achievements.unlockedTitle",
+					Other: "Achievements Unlocked",
+				}),
+				Description: strings.Join(lines, "\n"),
+				Color:       15844367, // GOLD
+			},
+		},
+		// announce without pinging everyone that unlocked something
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// achievementsField lists the achievements a user unlocked on a guild, or returns nil if they haven't unlocked any
+func (bot *Bot) achievementsField(userID, guildID string, sett *settings.GuildSettings) *discordgo.MessageEmbedField {
+	uid, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil
+	}
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		return nil
+	}
+	unlocked, err := storage.GetUserAchievements(bot.PostgresInterface.Pool, gid, uid)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	lines := make([]string, 0, len(unlocked))
+	for _, v := range unlocked {
+		if a := achievement.Get(v.Achievement); a != nil {
+			lines = append(lines, fmt.Sprintf("%s **%s** <t:%d:d>", a.Emoji, sett.LocalizeMessage(a.Name), v.UnlockTime))
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return &discordgo.MessageEmbedField{
+		Name: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.userStatsEmbed.Achievements",
+			Other: "Achievements ({{.Count}}/{{.Total}})",
+		}, map[string]interface{}{
+			"Count": len(lines),
+			"Total": len(achievement.All),
+		}),
+		Value:  strings.Join(lines, "\n"),
+		Inline: false,
+	}
+}
+
+func (bot *Bot) deleteUserAchievements(userID string) error {
+	uid, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return err
+	}
+	return storage.DeleteAchievementsForUser(bot.PostgresInterface.Pool, uid)
+}
+
+func (bot *Bot) deleteGuildAchievements(guildID uint64) error {
+	return storage.DeleteAchievementsForGuild(bot.PostgresInterface.Pool, guildID)
+}
+
+func (bot *Bot) HandleStatsAchievementsCommand(guildID, action, id string, isAdmin bool, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Stats.Name, err, sett)
+	}
+	switch action {
+	case command.AchievementsEnable, command.AchievementsDisable:
+		if !isAdmin {
+			return command.InsufficientPermissionsResponse(sett)
+		}
+		a := achievement.Get(id)
+		if a == nil {
+			return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+				ID:    "commands.stats.achievements.notFound",
+				Other: "There's no achievement called {{.ID}}",
+			}, map[string]interface{}{
+				"ID": id,
+			}))
+		}
+		enabled := action == command.AchievementsEnable
+		err = storage.SetAchievementEnabled(bot.PostgresInterface.Pool, gid, a.ID, enabled)
+		if err != nil {
+			log.Println(err)
+			return command.PrivateErrorResponse(command.Stats.Name, err, sett)
+		}
+		msg := &i18n.Message{
+			ID:    "commands.stats.achievements.enabled",
+			Other: "{{.Emoji}} **{{.Name}}** can be unlocked on this server again",
+		}
+		if !enabled {
+			msg = &i18n.Message{
+				ID:    "commands.stats.achievements.disabled",
+				Other: "{{.Emoji}} **{{.Name}}** won't be awarded or shown on this server anymore",
+			}
+		}
+		return command.PrivateResponse(sett.LocalizeMessage(msg, map[string]interface{}{
+			"Emoji": a.Emoji,
+			"Name":  sett.LocalizeMessage(a.Name),
+		}))
+	}
+
+	disabled, err := storage.GetDisabledAchievements(bot.PostgresInterface.Pool, gid)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Stats.Name, err, sett)
+	}
+	off := sett.LocalizeMessage(&i18n.Message{
+		ID:    "commands.stats.achievements.off",
+		Other: "(off)",
+	})
+	lines := make([]string, 0, len(achievement.All))
+	for _, a := range achievement.All {
+		line := fmt.Sprintf("%s **%s** `%s`: %s", a.Emoji, sett.LocalizeMessage(a.Name), a.ID, sett.LocalizeMessage(a.Description))
+		if disabled[a.ID] {
+			line = "~~" + line + "~~ " + off
+		}
+		lines = append(lines, line)
+	}
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: 1 << 6, //private message
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title: sett.LocalizeMessage(&i18n.Message{
+						ID:    "commands.stats.achievements.list.title",
+						Other: "Achievements",
+					}),
+					Description: strings.Join(lines, "\n"),
+					Color:       15844367, // GOLD
+				},
+			},
+		},
+	}
+}
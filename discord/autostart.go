@@ -0,0 +1,189 @@
+package discord
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/automuteus/automuteus/discord/setting"
+	"github.com/automuteus/utils/pkg/premium"
+	"github.com/automuteus/utils/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// autoStartGame starts a game for a voice channel that's configured to start games on its own, once enough members
+// have joined it. The member who filled the channel up to the threshold leads the game
+func (bot *Bot) autoStartGame(s *discordgo.Session, guildID, voiceChannelID, userID string) {
+	data, err := bot.StorageInterface.GetAutoStart(guildID, voiceChannelID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if data == nil {
+		return
+	}
+	config := setting.AutoStartConfig{}
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	g, err := s.State.Guild(guildID)
+	if err != nil || g == nil {
+		return
+	}
+	if mem, err := s.State.Member(guildID, userID); err == nil && mem.User != nil && mem.User.Bot {
+		return
+	}
+	if !config.ShouldStart(countVoiceMembers(s, g, voiceChannelID)) {
+		return
+	}
+
+	autoStartLock := bot.RedisInterface.LockAutoStart(guildID, voiceChannelID)
+	if autoStartLock == nil {
+		return
+	}
+	defer autoStartLock.Release(ctx)
+
+	perm, err := s.State.UserChannelPermissions(s.State.User.ID, voiceChannelID)
+	if err != nil || checkPermissions(perm, VoicePermissions) > 0 {
+		log.Printf("Missing voice permissions to auto-start a game in guild %s, channel %s\n", guildID, voiceChannelID)
+		return
+	}
+
+	sett := bot.StorageInterface.GetGuildSettings(guildID)
+	prem := bot.getLeaderPremiumTier(guildID, userID) != premium.FreeTier
+	gameSett := bot.settingsForChannel(guildID, voiceChannelID, sett, prem)
+
+	gsr := GameStateRequest{
+		GuildID:      guildID,
+		TextChannel:  config.TextChannelID,
+		VoiceChannel: voiceChannelID,
+	}
+	lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLockRetries(gsr, 5)
+	if lock == nil {
+		log.Printf("No lock could be obtained when auto-starting a game for guild %s, channel %s\n", guildID, voiceChannelID)
+		return
+	}
+	if dgs.GameStateMsg.Exists() {
+		// there's already a game here; don't replace it like `/new` would
+		bot.RedisInterface.SetDiscordGameState(nil, lock)
+		return
+	}
+
+	log.Printf("Auto-starting a game for guild %s, channel %s with leader %s\n", guildID, voiceChannelID, userID)
+	status, info := bot.startGame(dgs, lock, g, config.TextChannelID, voiceChannelID, userID, "", sett, gameSett)
+	intro := sett.LocalizeMessage(&i18n.Message{
+		ID:    "autostart.started",
+		Other: "{{.Members}} members joined <#{{.Channel}}>, so I started a game led by {{.Leader}}!",
+	}, map[string]interface{}{
+		"Members": config.Members,
+		"Channel": voiceChannelID,
+		"Leader":  "<@" + userID + ">",
+	})
+	bot.postNewGameMessage(config.TextChannelID, intro, status, info, sett)
+}
+
+// countVoiceMembers counts the members in a voice channel, not including bots
+func countVoiceMembers(s *discordgo.Session, g *discordgo.Guild, voiceChannelID string) int {
+	count := 0
+	for _, v := range g.VoiceStates {
+		if v.ChannelID != voiceChannelID {
+			continue
+		}
+		if mem, err := s.State.Member(g.ID, v.UserID); err == nil && mem.User != nil && mem.User.Bot {
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+func (bot *Bot) HandleSettingsAutoStartCommand(guildID string, sett *settings.GuildSettings, action, voiceChannelID, textChannelID string, members int64) interface{} {
+	switch action {
+	case setting.AutoStartSet:
+		config := setting.AutoStartConfig{
+			TextChannelID: textChannelID,
+			Members:       int(members),
+		}
+		if !config.IsValid() {
+			return sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.autostart.invalid",
+				Other: "Please provide a voice channel, a text channel, and between {{.Min}} and {{.Max}} members",
+			}, map[string]interface{}{
+				"Min": setting.MinAutoStartMembers,
+				"Max": setting.MaxAutoStartMembers,
+			})
+		}
+		data, err := json.Marshal(config)
+		if err == nil {
+			err = bot.StorageInterface.SetAutoStart(guildID, voiceChannelID, data)
+		}
+		if err != nil {
+			log.Println(err)
+			return err.Error()
+		}
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.autostart.set",
+			Other: "When {{.Members}} members are in <#{{.Voice}}>, I'll start a game in <#{{.Text}}>",
+		}, map[string]interface{}{
+			"Members": members,
+			"Voice":   voiceChannelID,
+			"Text":    textChannelID,
+		})
+
+	case setting.AutoStartRemove:
+		deleted, err := bot.StorageInterface.DeleteAutoStart(guildID, voiceChannelID)
+		if err != nil {
+			log.Println(err)
+			return err.Error()
+		}
+		if !deleted {
+			return sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.autostart.notFound",
+				Other: "<#{{.Voice}}> doesn't start games on its own",
+			}, map[string]interface{}{
+				"Voice": voiceChannelID,
+			})
+		}
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.autostart.removed",
+			Other: "<#{{.Voice}}> won't start games on its own anymore",
+		}, map[string]interface{}{
+			"Voice": voiceChannelID,
+		})
+	}
+
+	data, err := bot.StorageInterface.ListAutoStart(guildID)
+	if err != nil {
+		log.Println(err)
+		return err.Error()
+	}
+	configs := setting.ParseAutoStartConfigs(data)
+	if len(configs) == 0 {
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.autostart.none",
+			Other: "No voice channels start games on their own. Use `/settings auto-start set` to add one",
+		})
+	}
+	lines := make([]string, 0, len(configs))
+	for voiceID, config := range configs {
+		lines = append(lines, fmt.Sprintf("<#%s> → <#%s> (%d)", voiceID, config.TextChannelID, config.Members))
+	}
+	sort.Strings(lines)
+	return &discordgo.MessageEmbed{
+		Title: sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.autostart.title",
+			Other: "Auto-Start",
+		}),
+		Description: sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.autostart.description",
+			Other: "Voice channels that start a game once enough members join",
+		}) + "\n\n" + strings.Join(lines, "\n"),
+		Color: 15844367, // GOLD
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/automuteus/utils/pkg/settings"
 	storageutils "github.com/automuteus/utils/pkg/storage"
 	"github.com/automuteus/utils/pkg/token"
+	"github.com/bsm/redislock"
 	"github.com/bwmarrin/discordgo"
 	"github.com/top-gg/go-dbl"
 	"log"
@@ -114,7 +115,7 @@ func MakeAndStartBot(version, commit, botToken, topGGToken, url, emojiGuildID st
 	nodeID := os.Getenv("SCW_NODE_ID")
 	go metrics.PrometheusMetricsServer(bot.RedisInterface.client, nodeID, "2112")
 
-	go metrics.StartHealthCheckServer("8080")
+	go metrics.StartHealthCheckServer("8080", bot.ServeProfile)
 
 	log.Println("Finished identifying to the Discord API. Now ready for incoming events")
 
@@ -153,6 +154,10 @@ func MakeAndStartBot(version, commit, botToken, topGGToken, url, emojiGuildID st
 	// TODO this is ugly. Should make a proper cronjob to refresh the stats regularly
 	go bot.statsRefreshWorker(rediskey.TotalUsersExpiration)
 
+	go bot.scheduleWorker()
+
+	go bot.seasonWorker()
+
 	return &bot
 }
 
@@ -317,7 +322,7 @@ func (bot *Bot) RefreshGameStateMessage(gsr GameStateRequest, sett *settings.Gui
 	}
 
 	deleted := dgs.DeleteGameStateMsg(bot.PrimarySession, false) // delete the old message
-	created := dgs.CreateMessage(bot.PrimarySession, bot.gameStateResponse(dgs, sett), dgs.GameStateMsg.MessageChannelID, dgs.GameStateMsg.LeaderID)
+	created := dgs.CreateMessage(bot.PrimarySession, bot.gameStateResponse(dgs, sett), dgs.GameStateMsg.MessageChannelID, dgs.GameStateMsg.LeaderID, sett)
 
 	if deleted && created {
 		go metrics.RecordDiscordRequests(bot.RedisInterface.client, metrics.MessageCreateDelete, 2)
@@ -401,24 +406,21 @@ func getTrackingChannel(guild *discordgo.Guild, userID string) string {
 	return ""
 }
 
-func (bot *Bot) newGame(dgs *GameState) (_ command.NewStatus, activeGames int64) {
+func (bot *Bot) newGame(dgs *GameState, voiceChannelID string) (_ command.NewStatus, activeGames int64) {
+	connectCode := bot.connectCodeForGame(dgs, voiceChannelID)
 	if dgs.GameStateMsg.Exists() {
-		if v, ok := bot.EndGameChannels[dgs.ConnectCode]; ok {
-			v <- true
+		// with a pinned code, the capture is still connected to the same code, so keep its subscription running
+		// instead of ending it (which would also delete the state the new game is about to store under that code)
+		if connectCode != dgs.ConnectCode {
+			if v, ok := bot.EndGameChannels[dgs.ConnectCode]; ok {
+				v <- true
+			}
+			delete(bot.EndGameChannels, dgs.ConnectCode)
 		}
-		delete(bot.EndGameChannels, dgs.ConnectCode)
 
 		dgs.Reset()
 	} else {
-		premStatus, days, err := bot.PostgresInterface.GetGuildOrUserPremiumStatus(
-			bot.official, bot.TopGGClient, dgs.GuildID, dgs.GameStateMsg.LeaderID)
-		if err != nil {
-			log.Println("Error in /newgame get premium:", err)
-		}
-		premTier := premium.FreeTier
-		if !premium.IsExpired(premStatus, days) {
-			premTier = premStatus
-		}
+		premTier := bot.getLeaderPremiumTier(dgs.GuildID, dgs.GameStateMsg.LeaderID)
 
 		// Premium users should always be allowed to start new games; only check the free guilds
 		if premTier == premium.FreeTier {
@@ -429,8 +431,109 @@ func (bot *Bot) newGame(dgs *GameState) (_ command.NewStatus, activeGames int64)
 		}
 	}
 
-	dgs.ConnectCode = generateConnectCode(dgs.GuildID)
+	dgs.ConnectCode = connectCode
 	dgs.Subscribed = true
 
 	return command.NewSuccess, activeGames
 }
+
+// connectCodeForGame returns the connect code pinned to the voice channel (or the guild), so capture links keep
+// working across games. A random code is used if nothing is pinned, or the pinned code is in use by another game
+func (bot *Bot) connectCodeForGame(dgs *GameState, voiceChannelID string) string {
+	code := ""
+	for _, scope := range []string{voiceChannelID, storage.PinnedCodeGuildScope} {
+		if scope == "" {
+			continue
+		}
+		pinned, err := bot.StorageInterface.GetPinnedConnectCode(dgs.GuildID, scope)
+		if err != nil {
+			log.Println(err)
+		}
+		if pinned != "" {
+			code = pinned
+			break
+		}
+	}
+	if code == "" {
+		return generateConnectCode(dgs.GuildID)
+	}
+	if code != dgs.ConnectCode && bot.RedisInterface.CheckPointer(rediskey.ConnectCodePtr(dgs.GuildID, code)) != "" {
+		log.Printf("Pinned connect code for guild %s is in use by another game; using a random code\n", dgs.GuildID)
+		return generateConnectCode(dgs.GuildID)
+	}
+	return code
+}
+
+// startGame creates a new game on a locked game state and posts the game message in the text channel. This is shared
+// by `/new` and voice channels that start games on their own. The lock is always released. gameSett is the profile
+// or voice channel settings the game runs with, if they differ from the guild settings
+func (bot *Bot) startGame(dgs *GameState, lock *redislock.Lock, g *discordgo.Guild, textChannelID, voiceChannelID, leaderID, profile string,
+	sett, gameSett *settings.GuildSettings) (command.NewStatus, command.NewInfo) {
+	status, activeGames := bot.newGame(dgs, voiceChannelID)
+	if status != command.NewSuccess {
+		// release the lock
+		bot.RedisInterface.SetDiscordGameState(nil, lock)
+		return status, command.NewInfo{
+			ActiveGames: activeGames, // only field we need for non-success messages
+		}
+	}
+	dgs.Profile = profile
+	if gameSett != sett {
+		dgs.Settings = gameSett
+	}
+	// release the lock
+	bot.RedisInterface.SetDiscordGameState(dgs, lock)
+
+	bot.RedisInterface.RefreshActiveGame(dgs.GuildID, dgs.ConnectCode)
+
+	// a game restarted with a pinned connect code is still subscribed to it
+	bot.ChannelsMapLock.Lock()
+	_, subscribed := bot.EndGameChannels[dgs.ConnectCode]
+	if !subscribed {
+		killChan := make(chan EndGameMessage)
+		bot.EndGameChannels[dgs.ConnectCode] = killChan
+		go bot.SubscribeToGameByConnectCode(dgs.GuildID, dgs.ConnectCode, killChan)
+	}
+	bot.ChannelsMapLock.Unlock()
+
+	hyperlink, minimalURL := formCaptureURL(bot.url, dgs.ConnectCode)
+
+	bot.handleGameStartMessage(dgs.GuildID, textChannelID, voiceChannelID, leaderID, gameSett, g, dgs.ConnectCode)
+
+	return status, command.NewInfo{
+		Hyperlink:   hyperlink,
+		MinimalURL:  minimalURL,
+		ConnectCode: dgs.ConnectCode,
+		ActiveGames: activeGames, // not actually needed for Success messages
+	}
+}
+
+// postNewGameMessage posts the same message `/new` responds with in a text channel, for games that weren't started by
+// a user running `/new`. The intro is only shown if the game was started
+func (bot *Bot) postNewGameMessage(textChannelID, intro string, status command.NewStatus, info command.NewInfo, sett *settings.GuildSettings) {
+	resp := command.NewResponse(status, info, sett)
+	content := resp.Data.Content
+	if status == command.NewSuccess {
+		content = intro + "\n\n" + content
+	}
+	_, err := bot.PrimarySession.ChannelMessageSendComplex(textChannelID, &discordgo.MessageSend{
+		Content: content,
+		Embeds:  resp.Data.Embeds,
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// getLeaderPremiumTier fetches the premium tier a game runs with when the provided user is in control of it
+func (bot *Bot) getLeaderPremiumTier(guildID, leaderID string) premium.Tier {
+	premStatus, days, err := bot.PostgresInterface.GetGuildOrUserPremiumStatus(
+		bot.official, bot.TopGGClient, guildID, leaderID)
+	if err != nil {
+		log.Println("Error fetching premium status for game leader:", err)
+	}
+	if premium.IsExpired(premStatus, days) {
+		return premium.FreeTier
+	}
+	return premStatus
+}
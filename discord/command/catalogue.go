@@ -0,0 +1,118 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/automuteus/automuteus/discord/setting"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// Catalogue describes every command and setting the bot supports, so the website can list them
+type Catalogue struct {
+	Language string        `json:"language"`
+	Commands []CommandInfo `json:"commands"`
+	Settings []SettingInfo `json:"settings"`
+}
+
+type CommandInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// DefaultPermission is who can use the command out of the box. Guilds can grant it to other roles and users with
+	// `/settings permissions`, which the catalogue doesn't know about
+	DefaultPermission PermissionLevel `json:"defaultPermission"`
+	Premium           bool            `json:"premium"`
+	Options           []OptionInfo    `json:"options,omitempty"`
+}
+
+type SettingInfo struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Premium     bool         `json:"premium"`
+	Arguments   []OptionInfo `json:"arguments,omitempty"`
+}
+
+type OptionInfo struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Type        string       `json:"type"`
+	Required    bool         `json:"required"`
+	Choices     []ChoiceInfo `json:"choices,omitempty"`
+	Options     []OptionInfo `json:"options,omitempty"`
+}
+
+type ChoiceInfo struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// NewCatalogue builds the catalogue of all commands and settings, localized to the provided language wherever a
+// translation exists
+func NewCatalogue(bundle *i18n.Bundle, lang string) Catalogue {
+	localizer := i18n.NewLocalizer(bundle, lang)
+	catalogue := Catalogue{
+		Language: lang,
+		Commands: make([]CommandInfo, 0, len(All)),
+		Settings: make([]SettingInfo, 0, len(setting.AllSettings)),
+	}
+	for _, cmd := range All {
+		prefix := "commands." + cmd.Name
+		catalogue.Commands = append(catalogue.Commands, CommandInfo{
+			Name:              cmd.Name,
+			Description:       localizeOrDefault(localizer, prefix+".description", cmd.Description),
+			DefaultPermission: GetPermissionLevel(cmd.Name),
+			Premium:           PremiumCommands[cmd.Name],
+			Options:           optionInfos(localizer, cmd.Options, prefix),
+		})
+	}
+	for _, sett := range setting.AllSettings {
+		prefix := "settings." + sett.Name
+		catalogue.Settings = append(catalogue.Settings, SettingInfo{
+			Name:        sett.Name,
+			Description: localizeOrDefault(localizer, prefix+".description", sett.ShortDesc),
+			Premium:     sett.Premium,
+			Arguments:   optionInfos(localizer, sett.Arguments, prefix),
+		})
+	}
+	return catalogue
+}
+
+func optionInfos(localizer *i18n.Localizer, options []*discordgo.ApplicationCommandOption, prefix string) []OptionInfo {
+	if len(options) == 0 {
+		return nil
+	}
+	infos := make([]OptionInfo, 0, len(options))
+	for _, opt := range options {
+		optPrefix := prefix + "." + opt.Name
+		info := OptionInfo{
+			Name:        opt.Name,
+			Description: localizeOrDefault(localizer, optPrefix+".description", opt.Description),
+			Type:        opt.Type.String(),
+			Required:    opt.Required,
+			Options:     optionInfos(localizer, opt.Options, optPrefix),
+		}
+		for _, choice := range opt.Choices {
+			info.Choices = append(info.Choices, ChoiceInfo{
+				Name:  localizeOrDefault(localizer, fmt.Sprintf("%s.choices.%v.name", optPrefix, choice.Value), choice.Name),
+				Value: choice.Value,
+			})
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// localizeOrDefault looks up a translation without logging anything when it's missing; most option and choice keys
+// aren't translated, and we don't want a warning for each of them on every request
+func localizeOrDefault(localizer *i18n.Localizer, id, def string) string {
+	msg, err := localizer.Localize(&i18n.LocalizeConfig{
+		DefaultMessage: &i18n.Message{
+			ID:    id,
+			Other: def,
+		},
+	})
+	if err != nil || msg == "" {
+		return def
+	}
+	return msg
+}
@@ -0,0 +1,43 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/automuteus/automuteus/discord/setting"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+)
+
+func TestNewCatalogue(t *testing.T) {
+	bundle := i18n.NewBundle(language.English)
+	bundle.MustAddMessages(language.French,
+		&i18n.Message{ID: "commands.new.description", Other: "Commencer une nouvelle partie"},
+	)
+
+	catalogue := NewCatalogue(bundle, "fr")
+	if len(catalogue.Commands) != len(All) {
+		t.Errorf("Expected %d commands, got %d", len(All), len(catalogue.Commands))
+	}
+	if len(catalogue.Settings) != len(setting.AllSettings) {
+		t.Errorf("Expected %d settings, got %d", len(setting.AllSettings), len(catalogue.Settings))
+	}
+	for _, cmd := range catalogue.Commands {
+		switch cmd.Name {
+		case New.Name:
+			if cmd.Description != "Commencer une nouvelle partie" {
+				t.Errorf("Expected /new to be localized, got %s", cmd.Description)
+			}
+			if cmd.DefaultPermission != PermissionOperator {
+				t.Errorf("Expected /new to require operator permissions by default, got %s", cmd.DefaultPermission)
+			}
+		case Download.Name:
+			if !cmd.Premium || cmd.DefaultPermission != PermissionAdmin {
+				t.Error("Expected /download to be a premium, admin-only command")
+			}
+		case Help.Name:
+			if cmd.Description != Help.Description {
+				t.Errorf("Expected untranslated descriptions to fall back to English, got %s", cmd.Description)
+			}
+		}
+	}
+}
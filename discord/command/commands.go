@@ -24,6 +24,8 @@ var All = []*discordgo.ApplicationCommand{
 	&Refresh,
 	&Pause,
 	&End,
+	&Transfer,
+	&Schedule,
 	&Link,
 	&Unlink,
 	&Settings,
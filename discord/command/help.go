@@ -33,6 +33,14 @@ var Help = discordgo.ApplicationCommand{
 					Name:  End.Name,
 					Value: End.Name,
 				},
+				{
+					Name:  Transfer.Name,
+					Value: Transfer.Name,
+				},
+				{
+					Name:  Schedule.Name,
+					Value: Schedule.Name,
+				},
 				{
 					Name:  Link.Name,
 					Value: Link.Name,
@@ -0,0 +1,116 @@
+package command
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+)
+
+// bundleLangToDiscordLocales maps the languages of our locale files to the Discord locales that use them, where the
+// language code alone isn't a valid Discord locale
+var bundleLangToDiscordLocales = map[string][]discordgo.Locale{
+	"es": {discordgo.SpanishES},
+	"pt": {discordgo.PortugueseBR},
+	"sv": {discordgo.Swedish},
+	"zh": {discordgo.ChineseCN, discordgo.ChineseTW},
+}
+
+// commandNameRegex is the pattern Discord enforces for command and option names. Names must also be lowercase
+var commandNameRegex = regexp.MustCompile(`^[-_\p{L}\p{N}]{1,32}$`)
+
+const maxDescriptionLength = 100
+
+// LocalizeCommands populates the name and description localizations of every command, option and choice using the
+// translations in the provided bundle. Keys follow the "commands.<name>.description" format used by /help, with
+// options and choices nested under their parent, e.g. "commands.stats.view.user.description" or
+// "commands.map.map_name.choices.skeld.name".
+// Translations Discord would reject are dropped, so that one bad translation can't fail the whole command sync
+func LocalizeCommands(commands []*discordgo.ApplicationCommand, bundle *i18n.Bundle, langs []string) {
+	for _, cmd := range commands {
+		prefix := "commands." + cmd.Name
+		names := localizationsForKey(bundle, langs, prefix+".name", validCommandName)
+		if len(names) > 0 {
+			cmd.NameLocalizations = &names
+		}
+		descriptions := localizationsForKey(bundle, langs, prefix+".description", validDescription)
+		if len(descriptions) > 0 {
+			cmd.DescriptionLocalizations = &descriptions
+		}
+		localizeOptions(cmd.Options, bundle, langs, prefix)
+	}
+}
+
+func localizeOptions(options []*discordgo.ApplicationCommandOption, bundle *i18n.Bundle, langs []string, prefix string) {
+	for _, opt := range options {
+		optPrefix := prefix + "." + opt.Name
+		opt.NameLocalizations = localizationsForKey(bundle, langs, optPrefix+".name", validCommandName)
+		opt.DescriptionLocalizations = localizationsForKey(bundle, langs, optPrefix+".description", validDescription)
+		for _, choice := range opt.Choices {
+			choice.NameLocalizations = localizationsForKey(bundle, langs,
+				fmt.Sprintf("%s.choices.%v.name", optPrefix, choice.Value), validDescription)
+		}
+		localizeOptions(opt.Options, bundle, langs, optPrefix)
+	}
+}
+
+// localizationsForKey returns the translations for a message ID in every language that actually defines it, keyed by
+// Discord locale. Languages that would fall back to the bundle's default language, or whose translation fails the
+// provided validation, are omitted
+func localizationsForKey(bundle *i18n.Bundle, langs []string, id string, valid func(string) bool) map[discordgo.Locale]string {
+	localizations := make(map[discordgo.Locale]string)
+	for _, lang := range langs {
+		locales := discordLocales(lang)
+		if len(locales) == 0 {
+			continue
+		}
+		msg, tag, err := i18n.NewLocalizer(bundle, lang).LocalizeWithTag(&i18n.LocalizeConfig{
+			MessageID: id,
+		})
+		if err != nil || msg == "" {
+			continue
+		}
+		base, _ := tag.Base()
+		if reqBase, _ := language.Make(lang).Base(); base != reqBase {
+			continue
+		}
+		if !valid(msg) {
+			log.Printf("Ignoring invalid %s translation for %s: \"%s\"\n", lang, id, msg)
+			continue
+		}
+		for _, l := range locales {
+			localizations[l] = msg
+		}
+	}
+	if len(localizations) == 0 {
+		return nil
+	}
+	return localizations
+}
+
+func discordLocales(lang string) []discordgo.Locale {
+	lang = strings.ToLower(lang)
+	if locales, ok := bundleLangToDiscordLocales[lang]; ok {
+		return locales
+	}
+	if _, ok := discordgo.Locales[discordgo.Locale(lang)]; ok {
+		return []discordgo.Locale{discordgo.Locale(lang)}
+	}
+	return nil
+}
+
+// validCommandName reports whether Discord accepts the name for a command or option
+func validCommandName(name string) bool {
+	return commandNameRegex.MatchString(name) && strings.ToLower(name) == name
+}
+
+// validDescription reports whether Discord accepts the text as a description or choice name
+func validDescription(desc string) bool {
+	length := utf8.RuneCountInString(desc)
+	return length >= 1 && length <= maxDescriptionLength
+}
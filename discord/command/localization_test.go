@@ -0,0 +1,146 @@
+package command
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/automuteus/utils/pkg/locale"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"golang.org/x/text/language"
+)
+
+func TestLocalizeCommands(t *testing.T) {
+	bundle := i18n.NewBundle(language.English)
+	bundle.MustAddMessages(language.English,
+		&i18n.Message{ID: "commands.test.description", Other: "A test command"},
+		&i18n.Message{ID: "commands.test.user.description", Other: "A user"},
+	)
+	bundle.MustAddMessages(language.French,
+		&i18n.Message{ID: "commands.test.description", Other: "Une commande de test"},
+	)
+	bundle.MustAddMessages(language.Portuguese,
+		&i18n.Message{ID: "commands.test.opt.choices.a.name", Other: "Escolha A"},
+	)
+
+	cmd := &discordgo.ApplicationCommand{
+		Name:        "test",
+		Description: "A test command",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        "user",
+				Description: "A user",
+			},
+			{
+				Name:        "opt",
+				Description: "An option",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "a", Value: "a"},
+				},
+			},
+		},
+	}
+	LocalizeCommands([]*discordgo.ApplicationCommand{cmd}, bundle, []string{"en", "fr", "pt"})
+
+	if cmd.NameLocalizations != nil {
+		t.Error("Expected no name localizations for a command without translated names")
+	}
+	if cmd.DescriptionLocalizations == nil {
+		t.Fatal("Expected description localizations for the command")
+	}
+	descs := *cmd.DescriptionLocalizations
+	if descs[discordgo.French] != "Une commande de test" {
+		t.Errorf("Expected French description, got %s", descs[discordgo.French])
+	}
+	if _, ok := descs[discordgo.PortugueseBR]; ok {
+		t.Error("Portuguese shouldn't fall back to the English description")
+	}
+	if cmd.Options[0].DescriptionLocalizations != nil {
+		t.Error("Expected no localizations for an option that is only defined in English")
+	}
+	if cmd.Options[1].Choices[0].NameLocalizations[discordgo.PortugueseBR] != "Escolha A" {
+		t.Error("Expected the choice name to be localized for pt-BR")
+	}
+}
+
+func TestLocalizeCommandsDropsInvalidTranslations(t *testing.T) {
+	bundle := i18n.NewBundle(language.English)
+	bundle.MustAddMessages(language.French,
+		&i18n.Message{ID: "commands.test.name", Other: "Commande Test"},
+		&i18n.Message{ID: "commands.test.opt.name", Other: "option"},
+	)
+	bundle.MustAddMessages(language.German,
+		&i18n.Message{ID: "commands.test.name", Other: "prüfung"},
+		&i18n.Message{ID: "commands.test.opt.name", Other: "eine option"},
+	)
+
+	cmd := &discordgo.ApplicationCommand{
+		Name:        "test",
+		Description: "A test command",
+		Options: []*discordgo.ApplicationCommandOption{
+			{Name: "opt", Description: "An option"},
+		},
+	}
+	LocalizeCommands([]*discordgo.ApplicationCommand{cmd}, bundle, []string{"fr", "de"})
+
+	if cmd.NameLocalizations == nil {
+		t.Fatal("Expected the valid German name to be kept")
+	}
+	names := *cmd.NameLocalizations
+	if _, ok := names[discordgo.French]; ok {
+		t.Error("Expected the French name with uppercase letters and a space to be dropped")
+	}
+	if names[discordgo.German] != "prüfung" {
+		t.Errorf("Expected German name prüfung, got %s", names[discordgo.German])
+	}
+	optNames := cmd.Options[0].NameLocalizations
+	if optNames[discordgo.French] != "option" {
+		t.Errorf("Expected French option name, got %s", optNames[discordgo.French])
+	}
+	if _, ok := optNames[discordgo.German]; ok {
+		t.Error("Expected the German option name with a space to be dropped")
+	}
+}
+
+func TestLocalizeCommandsLocaleFiles(t *testing.T) {
+	bundle := locale.LoadTranslations("../../locales", "en")
+
+	cmd := &discordgo.ApplicationCommand{Name: Help.Name, Description: Help.Description}
+	LocalizeCommands([]*discordgo.ApplicationCommand{cmd}, bundle, []string{"en", "fr"})
+
+	if cmd.DescriptionLocalizations == nil || (*cmd.DescriptionLocalizations)[discordgo.French] == "" {
+		t.Error("Expected a French description for /help from the locale files")
+	}
+	if cmd.NameLocalizations == nil || !validCommandName((*cmd.NameLocalizations)[discordgo.French]) {
+		t.Error("Expected a valid French name for /help from the locale files")
+	}
+}
+
+func TestLocaleFilesHaveEveryCommandKey(t *testing.T) {
+	bundle := locale.LoadTranslations("../../locales", "en")
+	localizer := i18n.NewLocalizer(bundle, "en")
+	hasKey := func(id string) {
+		if _, err := localizer.Localize(&i18n.LocalizeConfig{MessageID: id}); err != nil {
+			t.Errorf("Expected %s in the English locale file", id)
+		}
+	}
+
+	var checkOptions func(prefix string, options []*discordgo.ApplicationCommandOption)
+	checkOptions = func(prefix string, options []*discordgo.ApplicationCommandOption) {
+		for _, opt := range options {
+			optPrefix := prefix + "." + opt.Name
+			hasKey(optPrefix + ".name")
+			hasKey(optPrefix + ".description")
+			for _, choice := range opt.Choices {
+				hasKey(fmt.Sprintf("%s.choices.%v.name", optPrefix, choice.Value))
+			}
+			checkOptions(optPrefix, opt.Options)
+		}
+	}
+	for _, cmd := range All {
+		prefix := "commands." + cmd.Name
+		hasKey(prefix + ".name")
+		hasKey(prefix + ".description")
+		checkOptions(prefix, cmd.Options)
+	}
+}
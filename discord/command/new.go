@@ -13,6 +13,7 @@ const (
 	NewSuccess NewStatus = iota
 	NewNoVoiceChannel
 	NewLockout
+	NewProfileNotFound
 )
 
 type NewInfo struct {
@@ -20,17 +21,35 @@ type NewInfo struct {
 	MinimalURL  string
 	ConnectCode string
 	ActiveGames int64
+	Profile     string
 }
 
 var New = discordgo.ApplicationCommand{
 	Name:        "new",
 	Description: "Start a new game",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "profile",
+			Description: "Settings profile to use for this game",
+			Required:    false,
+		},
+	},
+}
+
+func GetNewParams(options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	for _, v := range options {
+		if v.Name == "profile" {
+			return v.StringValue()
+		}
+	}
+	return ""
 }
 
 func NewResponse(status NewStatus, info NewInfo, sett *settings.GuildSettings) *discordgo.InteractionResponse {
 	var content string
 	var embeds []*discordgo.MessageEmbed
-	var flags uint64 = 1 << 6 // private message by default
+	var flags discordgo.MessageFlags = 1 << 6 // private message by default
 
 	switch status {
 	case NewSuccess:
@@ -81,6 +100,13 @@ func NewResponse(status NewStatus, info NewInfo, sett *settings.GuildSettings) *
 		})
 		flags = 0 // public message
 
+	case NewProfileNotFound:
+		content = sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.new.profileNotFound",
+			Other: "I couldn't find a settings profile named `{{.Profile}}`. See `/settings profile list`",
+		}, map[string]interface{}{
+			"Profile": info.Profile,
+		})
 	}
 	return &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -0,0 +1,172 @@
+package command
+
+import (
+	"github.com/automuteus/utils/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+)
+
+type PermissionLevel string
+
+const (
+	PermissionEveryone PermissionLevel = "everyone"
+	PermissionOperator PermissionLevel = "operator"
+	PermissionAdmin    PermissionLevel = "admin"
+)
+
+// Permissions is the minimum permission level needed to use each command. Commands not listed here can be used by
+// everyone (although specific subcommands, such as clearing another user's stats, may still require more)
+var Permissions = map[string]PermissionLevel{
+	New.Name:      PermissionOperator,
+	Pause.Name:    PermissionOperator,
+	End.Name:      PermissionOperator,
+	Link.Name:     PermissionOperator,
+	Unlink.Name:   PermissionOperator,
+	Transfer.Name: PermissionOperator,
+	Schedule.Name: PermissionOperator,
+	Settings.Name: PermissionAdmin,
+	Download.Name: PermissionAdmin,
+}
+
+// PremiumCommands are the commands that can only be used by premium guilds
+var PremiumCommands = map[string]bool{
+	Download.Name: true,
+}
+
+func GetPermissionLevel(commandName string) PermissionLevel {
+	if level, ok := Permissions[commandName]; ok {
+		return level
+	}
+	return PermissionEveryone
+}
+
+// Grants are the roles and users allowed to use a command. Empty legacy grants allow everyone, but empty grants that
+// were configured for a command leave it to the guild owner only
+type Grants struct {
+	Roles []string `json:"roles,omitempty"`
+	Users []string `json:"users,omitempty"`
+}
+
+func (grants Grants) IsEmpty() bool {
+	return len(grants.Roles) == 0 && len(grants.Users) == 0
+}
+
+// GuildPermissions are the grants a guild has configured for individual commands, through `/settings permissions`.
+// Commands without any configured grants fall back to LegacyGrants
+type GuildPermissions map[string]Grants
+
+// LegacyGrants expresses the admin and operator lists from the guild settings as grants for a command, keeping their
+// original behavior: if there are no admins, operators are admins, and if neither is set, everyone is both
+func LegacyGrants(commandName string, sett *settings.GuildSettings) Grants {
+	switch GetPermissionLevel(commandName) {
+	case PermissionAdmin:
+		if len(sett.AdminUserIDs) == 0 {
+			return Grants{Roles: sett.PermissionRoleIDs}
+		}
+		return Grants{Users: sett.AdminUserIDs}
+	case PermissionOperator:
+		return Grants{Roles: sett.PermissionRoleIDs}
+	default:
+		return Grants{}
+	}
+}
+
+// GrantsFor returns the grants for a command, and whether they were configured for that command specifically (as
+// opposed to coming from the admin and operator lists)
+func (perms GuildPermissions) GrantsFor(commandName string, sett *settings.GuildSettings) (Grants, bool) {
+	if grants, ok := perms[commandName]; ok {
+		return grants, true
+	}
+	return LegacyGrants(commandName, sett), false
+}
+
+// Grant gives a role and/or user access to a command. The first time a command's grants are changed, they start from
+// what the admin and operator settings allowed, so the new grant doesn't take access away from the roles and users
+// listed there. If those settings let everyone use the command, nothing can be kept, and the grant restricts the
+// command to the new role or user; restricted reports when that happens
+func (perms GuildPermissions) Grant(commandName, roleID, userID string, sett *settings.GuildSettings) (restricted bool) {
+	grants, configured := perms.GrantsFor(commandName, sett)
+	if !configured {
+		restricted = grants.IsEmpty()
+		grants = Grants{
+			Roles: append([]string{}, grants.Roles...),
+			Users: append([]string{}, grants.Users...),
+		}
+	}
+	grants.Roles = addID(grants.Roles, roleID)
+	grants.Users = addID(grants.Users, userID)
+	perms[commandName] = grants
+	return restricted
+}
+
+// Revoke takes access to a command away from a role and/or user. Revoking the last grant leaves the command to the
+// guild owner only; use Reset to go back to the admin and operator settings
+func (perms GuildPermissions) Revoke(commandName, roleID, userID string, sett *settings.GuildSettings) {
+	grants, configured := perms.GrantsFor(commandName, sett)
+	if !configured {
+		if grants.IsEmpty() {
+			// everyone can use the command, so there's nobody specific to revoke
+			return
+		}
+		grants = Grants{
+			Roles: append([]string{}, grants.Roles...),
+			Users: append([]string{}, grants.Users...),
+		}
+	}
+	grants.Roles = removeID(grants.Roles, roleID)
+	grants.Users = removeID(grants.Users, userID)
+	perms[commandName] = grants
+}
+
+// Reset removes the grants configured for a command, so it falls back to the admin and operator settings
+func (perms GuildPermissions) Reset(commandName string) {
+	delete(perms, commandName)
+}
+
+// CanUse reports whether a member can use a command. The guild owner can always use every command
+func (perms GuildPermissions) CanUse(commandName, ownerID string, member *discordgo.Member, sett *settings.GuildSettings) bool {
+	if member == nil || member.User == nil {
+		return false
+	}
+	if member.User.ID == ownerID {
+		return true
+	}
+	grants, configured := perms.GrantsFor(commandName, sett)
+	if grants.IsEmpty() {
+		return !configured
+	}
+	for _, v := range grants.Users {
+		if v == member.User.ID {
+			return true
+		}
+	}
+	for _, role := range member.Roles {
+		for _, v := range grants.Roles {
+			if v == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func addID(ids []string, id string) []string {
+	if id == "" {
+		return ids
+	}
+	for _, v := range ids {
+		if v == id {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
+
+func removeID(ids []string, id string) []string {
+	result := make([]string, 0, len(ids))
+	for _, v := range ids {
+		if v != id {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/automuteus/automuteus/discord/setting"
+	"github.com/automuteus/utils/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestPermissionCommands(t *testing.T) {
+	if len(setting.PermissionCommands) != len(Permissions) {
+		t.Errorf("Expected %d commands to be grantable, got %d", len(Permissions), len(setting.PermissionCommands))
+	}
+	for _, v := range setting.PermissionCommands {
+		if _, ok := Permissions[v]; !ok {
+			t.Errorf("%s can be granted, but doesn't require any permissions", v)
+		}
+	}
+}
+
+func TestGuildPermissionsCanUse(t *testing.T) {
+	sett := settings.MakeGuildSettings()
+	owner := &discordgo.Member{User: &discordgo.User{ID: "1"}}
+	admin := &discordgo.Member{User: &discordgo.User{ID: "2"}}
+	operator := &discordgo.Member{User: &discordgo.User{ID: "3"}, Roles: []string{"10"}}
+	host := &discordgo.Member{User: &discordgo.User{ID: "4"}, Roles: []string{"20"}}
+	perms := GuildPermissions{}
+
+	// with no admins or operators, everyone can use everything
+	if !perms.CanUse(Settings.Name, "1", host, sett) || !perms.CanUse(New.Name, "1", host, sett) {
+		t.Error("Expected everyone to have access when no permissions are set")
+	}
+
+	sett.SetAdminUserIDs([]string{"2"})
+	sett.SetPermissionRoleIDs([]string{"10"})
+	if !perms.CanUse(Settings.Name, "1", owner, sett) {
+		t.Error("Expected the owner to always have access")
+	}
+	if !perms.CanUse(Settings.Name, "1", admin, sett) || perms.CanUse(Settings.Name, "1", operator, sett) {
+		t.Error("Expected only admins to use /settings")
+	}
+	if !perms.CanUse(New.Name, "1", operator, sett) || perms.CanUse(New.Name, "1", host, sett) {
+		t.Error("Expected only operators to use /new")
+	}
+	if !perms.CanUse(Help.Name, "1", host, sett) {
+		t.Error("Expected everyone to use /help")
+	}
+
+	perms[New.Name] = Grants{Roles: []string{"20"}}
+	if !perms.CanUse(New.Name, "1", host, sett) || perms.CanUse(New.Name, "1", operator, sett) {
+		t.Error("Expected the configured grants to replace the operator roles for /new")
+	}
+	if perms.CanUse(Settings.Name, "1", host, sett) {
+		t.Error("Expected grants for /new not to affect /settings")
+	}
+}
+
+func TestGuildPermissionsGrantAndRevoke(t *testing.T) {
+	sett := settings.MakeGuildSettings()
+	operator := &discordgo.Member{User: &discordgo.User{ID: "3"}, Roles: []string{"10"}}
+	host := &discordgo.Member{User: &discordgo.User{ID: "4"}, Roles: []string{"20"}}
+	other := &discordgo.Member{User: &discordgo.User{ID: "5"}}
+	perms := GuildPermissions{}
+
+	// with no operators set, everyone can use /new, so the first grant restricts it
+	if !perms.Grant(New.Name, "20", "", sett) {
+		t.Error("Expected granting a command everyone could use to report it as restricted")
+	}
+	if !perms.CanUse(New.Name, "1", host, sett) || perms.CanUse(New.Name, "1", other, sett) {
+		t.Error("Expected only the granted role to use /new")
+	}
+	perms.Reset(New.Name)
+	if !perms.CanUse(New.Name, "1", other, sett) {
+		t.Error("Expected a reset command to fall back to everyone")
+	}
+
+	// with operators set, the first grant keeps their access
+	sett.SetPermissionRoleIDs([]string{"10"})
+	if perms.Grant(New.Name, "20", "", sett) {
+		t.Error("Expected granting a command with operators not to report it as restricted")
+	}
+	if !perms.CanUse(New.Name, "1", operator, sett) || !perms.CanUse(New.Name, "1", host, sett) {
+		t.Error("Expected the first grant to keep the operator role's access")
+	}
+
+	// revoking the last grant leaves the command to the owner, instead of falling back to the operators
+	perms.Revoke(New.Name, "10", "", sett)
+	perms.Revoke(New.Name, "20", "", sett)
+	if perms.CanUse(New.Name, "1", operator, sett) || perms.CanUse(New.Name, "1", host, sett) {
+		t.Error("Expected revoking every grant to take access away from everyone")
+	}
+	if !perms.CanUse(New.Name, "3", operator, sett) {
+		t.Error("Expected the owner to keep access after every grant is revoked")
+	}
+
+	// revoking on a command everyone can use changes nothing
+	perms.Revoke(Link.Name, "20", "", settings.MakeGuildSettings())
+	if _, configured := perms.GrantsFor(Link.Name, sett); configured {
+		t.Error("Expected revoking on a command everyone can use not to configure it")
+	}
+}
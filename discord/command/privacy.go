@@ -14,6 +14,9 @@ const (
 	PrivacyShowMe = "show-me"
 	PrivacyOptIn  = "opt-in"
 	PrivacyOptOut = "opt-out"
+
+	PrivacyProfileOn  = "profile-on"
+	PrivacyProfileOff = "profile-off"
 )
 
 var Privacy = discordgo.ApplicationCommand{
@@ -42,6 +45,14 @@ var Privacy = discordgo.ApplicationCommand{
 					Name:  PrivacyOptOut,
 					Value: PrivacyOptOut,
 				},
+				{
+					Name:  PrivacyProfileOn,
+					Value: PrivacyProfileOn,
+				},
+				{
+					Name:  PrivacyProfileOff,
+					Value: PrivacyProfileOff,
+				},
 			},
 			Required: false,
 		},
@@ -0,0 +1,153 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	ScheduleCreate = "create"
+	ScheduleList   = "list"
+	ScheduleCancel = "cancel"
+
+	// MaxScheduledGames is the most upcoming sessions a guild can have scheduled at once
+	MaxScheduledGames = 10
+
+	// MaxScheduleAhead is how far in the future a session can be scheduled
+	MaxScheduleAhead = time.Hour * 24 * 30
+
+	scheduleTimeLayout = "2006-01-02 15:04"
+	dateLayout         = "2006-01-02"
+)
+
+var Schedule = discordgo.ApplicationCommand{
+	Name:        "schedule",
+	Description: "Schedule game sessions",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Name:        ScheduleCreate,
+			Description: "Schedule a game session",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "time",
+					Description: "When the session starts, like 2h30m, 2024-05-01 20:00 (UTC) or a Discord timestamp",
+					Required:    true,
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "voice",
+					Description:  "Voice channel to play in",
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+					Required:     true,
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "text",
+					Description:  "Text channel to post the game in",
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					Required:     true,
+				},
+			},
+		},
+		{
+			Name:        ScheduleList,
+			Description: "View upcoming game sessions",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+		},
+		{
+			Name:        ScheduleCancel,
+			Description: "Cancel a game session",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "id",
+					Description: "ID of the session to cancel",
+					Required:    true,
+				},
+			},
+		},
+	},
+}
+
+type ScheduleParams struct {
+	Action         string
+	Time           string
+	VoiceChannelID string
+	TextChannelID  string
+	ID             int64
+}
+
+func GetScheduleParams(options []*discordgo.ApplicationCommandInteractionDataOption) ScheduleParams {
+	if len(options) == 0 {
+		return ScheduleParams{Action: ScheduleList}
+	}
+	params := ScheduleParams{Action: options[0].Name}
+	for _, v := range options[0].Options {
+		switch v.Name {
+		case "time":
+			params.Time = v.StringValue()
+		case "voice":
+			params.VoiceChannelID = fmt.Sprintf("%v", v.Value)
+		case "text":
+			params.TextChannelID = fmt.Sprintf("%v", v.Value)
+		case "id":
+			params.ID = v.IntValue()
+		}
+	}
+	return params
+}
+
+var discordTimestampRegex = regexp.MustCompile(`^<t:(\d+)(:[tTdDfFR])?>$`)
+
+var (
+	ErrScheduleTimeFormat = errors.New("unrecognized time; use a duration like 2h30m, a UTC time like 2024-05-01 20:00, or a Discord timestamp")
+	ErrScheduleTimePast   = errors.New("that time has already passed")
+	ErrScheduleTimeFar    = errors.New("sessions can only be scheduled up to 30 days ahead")
+)
+
+// ParseTimestamp reads a point in time given as a Discord timestamp (<t:1234567890:F>), a unix timestamp, a UTC date
+// and time, a UTC date, or RFC 3339
+func ParseTimestamp(value string) (time.Time, bool) {
+	if match := discordTimestampRegex.FindStringSubmatch(value); match != nil {
+		unix, _ := strconv.ParseInt(match[1], 10, 64)
+		return time.Unix(unix, 0), true
+	}
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(unix, 0), true
+	}
+	for _, layout := range []string{scheduleTimeLayout, dateLayout, time.RFC3339} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ParseScheduleTime reads the start time of a session, given as a duration from now, or in any format ParseTimestamp
+// understands
+func ParseScheduleTime(value string, now time.Time) (time.Time, error) {
+	var t time.Time
+	if d, err := time.ParseDuration(value); err == nil {
+		t = now.Add(d)
+	} else if parsed, ok := ParseTimestamp(value); ok {
+		t = parsed
+	} else {
+		return time.Time{}, ErrScheduleTimeFormat
+	}
+
+	if !t.After(now) {
+		return time.Time{}, ErrScheduleTimePast
+	}
+	if t.Sub(now) > MaxScheduleAhead {
+		return time.Time{}, ErrScheduleTimeFar
+	}
+	return t, nil
+}
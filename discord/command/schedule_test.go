@@ -0,0 +1,56 @@
+package command
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParseScheduleTime(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Time
+		err      error
+	}{
+		{"2h30m", now.Add(time.Hour*2 + time.Minute*30), nil},
+		{"2024-05-01 20:00", time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC), nil},
+		{"2024-05-01T20:00:00+02:00", time.Date(2024, 5, 1, 18, 0, 0, 0, time.UTC), nil},
+		{"<t:1714593600:F>", time.Unix(1714593600, 0), nil},
+		{"1714593600", time.Unix(1714593600, 0), nil},
+		{"-1h", time.Time{}, ErrScheduleTimePast},
+		{"2024-04-30 20:00", time.Time{}, ErrScheduleTimePast},
+		{"2024-07-01 20:00", time.Time{}, ErrScheduleTimeFar},
+		{"tomorrow", time.Time{}, ErrScheduleTimeFormat},
+	}
+	for _, test := range tests {
+		parsed, err := ParseScheduleTime(test.value, now)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: expected error %v, got %v", test.value, test.err, err)
+		}
+		if !parsed.Equal(test.expected) {
+			t.Errorf("%s: expected %s, got %s", test.value, test.expected, parsed)
+		}
+	}
+}
+
+func TestGetScheduleParams(t *testing.T) {
+	options := []*discordgo.ApplicationCommandInteractionDataOption{
+		{
+			Name: ScheduleCreate,
+			Type: discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: "time", Type: discordgo.ApplicationCommandOptionString, Value: "2h"},
+				{Name: "voice", Type: discordgo.ApplicationCommandOptionChannel, Value: "1234"},
+				{Name: "text", Type: discordgo.ApplicationCommandOptionChannel, Value: "5678"},
+			},
+		},
+	}
+	params := GetScheduleParams(options)
+	if params.Action != ScheduleCreate || params.Time != "2h" || params.VoiceChannelID != "1234" || params.TextChannelID != "5678" {
+		t.Errorf("Unexpected params %+v", params)
+	}
+}
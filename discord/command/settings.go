@@ -1,6 +1,7 @@
 package command
 
 import (
+	"fmt"
 	"github.com/automuteus/automuteus/discord/setting"
 	"github.com/bwmarrin/discordgo"
 	"log"
@@ -86,3 +87,93 @@ func settingsToCommandOptions() []*discordgo.ApplicationCommandOption {
 	}
 	return choices
 }
+
+// GetSettingsProfileParams returns the profile action (use, save, list or delete) and the profile name, if any
+func GetSettingsProfileParams(options []*discordgo.ApplicationCommandInteractionDataOption) (string, string) {
+	if len(options) == 0 || len(options[0].Options) == 0 {
+		return setting.List, ""
+	}
+	action := options[0].Options[0]
+	if len(action.Options) == 0 {
+		return action.Name, ""
+	}
+	return action.Name, action.Options[0].StringValue()
+}
+
+// GetSettingsChannelParam removes the voice channel argument from settings that can be set per channel, so the
+// remaining arguments can be parsed by GetSettingsParams as usual. It returns the channel ID, or "" for the server
+func GetSettingsChannelParam(options []*discordgo.ApplicationCommandInteractionDataOption) (string, []*discordgo.ApplicationCommandInteractionDataOption) {
+	if len(options) == 0 || !setting.ChannelSettings[options[0].Name] {
+		return "", options
+	}
+	channelID := ""
+	sub := *options[0]
+	sub.Options = make([]*discordgo.ApplicationCommandInteractionDataOption, 0, len(options[0].Options))
+	for _, v := range options[0].Options {
+		if v.Name == setting.Channel && v.Type == discordgo.ApplicationCommandOptionChannel {
+			channelID = fmt.Sprintf("%v", v.Value)
+		} else {
+			sub.Options = append(sub.Options, v)
+		}
+	}
+	return channelID, append([]*discordgo.ApplicationCommandInteractionDataOption{&sub}, options[1:]...)
+}
+
+// GetSettingsPermissionsParams returns the permissions action (view, grant, revoke or reset), along with the command,
+// role ID and user ID it applies to, if any
+func GetSettingsPermissionsParams(options []*discordgo.ApplicationCommandInteractionDataOption) (action, commandName, roleID, userID string) {
+	if len(options) == 0 || len(options[0].Options) == 0 {
+		return setting.View, "", "", ""
+	}
+	sub := options[0].Options[0]
+	action = sub.Name
+	for _, v := range sub.Options {
+		switch v.Name {
+		case "command":
+			commandName = v.StringValue()
+		case setting.Role:
+			roleID = fmt.Sprintf("%v", v.Value)
+		case setting.User:
+			userID = fmt.Sprintf("%v", v.Value)
+		}
+	}
+	return action, commandName, roleID, userID
+}
+
+// GetSettingsAutoStartParams returns the auto-start action (view, set or remove), along with the voice channel, text
+// channel and member count it applies to, if any
+func GetSettingsAutoStartParams(options []*discordgo.ApplicationCommandInteractionDataOption) (action, voiceChannelID, textChannelID string, members int64) {
+	if len(options) == 0 || len(options[0].Options) == 0 {
+		return setting.View, "", "", 0
+	}
+	sub := options[0].Options[0]
+	action = sub.Name
+	for _, v := range sub.Options {
+		switch v.Name {
+		case setting.VoiceChannel:
+			voiceChannelID = fmt.Sprintf("%v", v.Value)
+		case setting.TextChannel:
+			textChannelID = fmt.Sprintf("%v", v.Value)
+		case setting.Members:
+			members = v.IntValue()
+		}
+	}
+	return action, voiceChannelID, textChannelID, members
+}
+
+// GetSettingsRotateCodeParams returns the voice channel to rotate the pinned connect code for ("" for the whole
+// server), and if the code should be unpinned instead
+func GetSettingsRotateCodeParams(options []*discordgo.ApplicationCommandInteractionDataOption) (voiceChannelID string, unpin bool) {
+	if len(options) == 0 {
+		return "", false
+	}
+	for _, v := range options[0].Options {
+		switch v.Name {
+		case setting.VoiceChannel:
+			voiceChannelID = fmt.Sprintf("%v", v.Value)
+		case setting.Unpin:
+			unpin = v.BoolValue()
+		}
+	}
+	return voiceChannelID, unpin
+}
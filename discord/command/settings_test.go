@@ -56,3 +56,101 @@ func TestGetSettingsParams(t *testing.T) {
 }
 
 // TODO construct a test to validate complex settings behavior, like voice rules or delays
+
+func TestGetSettingsChannelParam(t *testing.T) {
+	options := []*discordgo.ApplicationCommandInteractionDataOption{
+		{
+			Name: setting.Delays,
+			Type: discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: "start-phase", Type: discordgo.ApplicationCommandOptionString, Value: "LOBBY"},
+				{Name: "end-phase", Type: discordgo.ApplicationCommandOptionString, Value: "TASKS"},
+				{Name: setting.Channel, Type: discordgo.ApplicationCommandOptionChannel, Value: "1234"},
+			},
+		},
+	}
+	channelID, stripped := GetSettingsChannelParam(options)
+	if channelID != "1234" {
+		t.Errorf("Expected channel 1234, got %s", channelID)
+	}
+	settingName, args := GetSettingsParams(stripped)
+	if settingName != setting.Delays || len(args) != 2 || args[0] != "LOBBY" || args[1] != "TASKS" {
+		t.Errorf("Unexpected setting %s with args %v", settingName, args)
+	}
+	if len(options[0].Options) != 3 {
+		t.Error("Expected the original options to be left unchanged")
+	}
+
+	options = []*discordgo.ApplicationCommandInteractionDataOption{
+		{
+			Name: setting.MatchSummaryChannel,
+			Type: discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: "channel", Type: discordgo.ApplicationCommandOptionChannel, Value: "1234"},
+			},
+		},
+	}
+	channelID, _ = GetSettingsChannelParam(options)
+	if channelID != "" {
+		t.Error("Expected the match summary channel not to be treated as a channel override")
+	}
+}
+
+func TestGetSettingsAutoStartParams(t *testing.T) {
+	options := []*discordgo.ApplicationCommandInteractionDataOption{
+		{
+			Name: setting.AutoStart,
+			Type: discordgo.ApplicationCommandOptionSubCommandGroup,
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{
+					Name: setting.AutoStartSet,
+					Type: discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandInteractionDataOption{
+						{Name: setting.VoiceChannel, Type: discordgo.ApplicationCommandOptionChannel, Value: "1234"},
+						{Name: setting.TextChannel, Type: discordgo.ApplicationCommandOptionChannel, Value: "5678"},
+						{Name: setting.Members, Type: discordgo.ApplicationCommandOptionInteger, Value: float64(6)},
+					},
+				},
+			},
+		},
+	}
+	action, voiceChannelID, textChannelID, members := GetSettingsAutoStartParams(options)
+	if action != setting.AutoStartSet || voiceChannelID != "1234" || textChannelID != "5678" || members != 6 {
+		t.Errorf("Unexpected params %s %s %s %d", action, voiceChannelID, textChannelID, members)
+	}
+
+	action, _, _, _ = GetSettingsAutoStartParams(options[:0])
+	if action != setting.View {
+		t.Errorf("Expected %s with no options, got %s", setting.View, action)
+	}
+}
+
+func TestSettingsCommandOptionLimit(t *testing.T) {
+	// Discord rejects commands with more than 25 options
+	if len(settingsToCommandOptions()) > 25 {
+		t.Errorf("/settings has %d subcommands, but Discord only allows 25", len(settingsToCommandOptions()))
+	}
+}
+
+func TestGetSettingsRotateCodeParams(t *testing.T) {
+	options := []*discordgo.ApplicationCommandInteractionDataOption{
+		{
+			Name: setting.RotateCode,
+			Type: discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: setting.VoiceChannel, Type: discordgo.ApplicationCommandOptionChannel, Value: "1234"},
+				{Name: setting.Unpin, Type: discordgo.ApplicationCommandOptionBoolean, Value: true},
+			},
+		},
+	}
+	voiceChannelID, unpin := GetSettingsRotateCodeParams(options)
+	if voiceChannelID != "1234" || !unpin {
+		t.Errorf("Unexpected params %s %t", voiceChannelID, unpin)
+	}
+
+	options[0].Options = nil
+	voiceChannelID, unpin = GetSettingsRotateCodeParams(options)
+	if voiceChannelID != "" || unpin {
+		t.Error("Expected the whole server and no unpin without any options")
+	}
+}
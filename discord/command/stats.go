@@ -1,13 +1,86 @@
 package command
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/automuteus/automuteus/achievement"
 	"github.com/automuteus/automuteus/discord/setting"
 	"github.com/bwmarrin/discordgo"
 )
 
 const (
-	Match = "match"
-	Guild = "guild"
+	Match       = "match"
+	Guild       = "guild"
+	Leaderboard = "leaderboard"
+	Metric      = "metric"
+	Order       = "order"
+	Period      = "period"
+	Season      = "season"
+	Versus      = "versus"
+	Opponent    = "opponent"
+
+	Achievements = "achievements"
+	Achievement  = "achievement"
+
+	Unlinked = "unlinked"
+	Claim    = "claim"
+
+	Scope       = "scope"
+	ScopeGuild  = "guild"
+	ScopeGlobal = "global"
+)
+
+// stats periods; games are included if they started in the period
+const (
+	PeriodAll    = "all"
+	PeriodWeek   = "7d"
+	PeriodMonth  = "30d"
+	PeriodSeason = "season"
+)
+
+const (
+	SeasonCreate = "create"
+	SeasonList   = "list"
+	SeasonDelete = "delete"
+
+	MaxSeasonNameLength = 32
+	MaxSeasonLength     = time.Hour * 24 * 366
+)
+
+const (
+	AchievementsList    = "list"
+	AchievementsEnable  = "enable"
+	AchievementsDisable = "disable"
+)
+
+const (
+	UnlinkedList    = "list"
+	UnlinkedEnable  = "enable"
+	UnlinkedDisable = "disable"
+
+	// MaxPlayerNameLength is the longest name Among Us allows, and the longest stored with a game
+	MaxPlayerNameLength = 10
+)
+
+// leaderboard metrics
+const (
+	MetricGames           = "games"
+	MetricWins            = "wins"
+	MetricCrewmateWinRate = "crewmate-winrate"
+	MetricImposterWinRate = "imposter-winrate"
+	MetricRating          = "rating"
+	MetricCurrentStreak   = "current-streak"
+	MetricBestStreak      = "best-streak"
+)
+
+// leaderboard sort orders
+const (
+	OrderDescending = "desc"
+	OrderAscending  = "asc"
 )
 
 var Stats = discordgo.ApplicationCommand{
@@ -30,6 +103,35 @@ var Stats = discordgo.ApplicationCommand{
 							Type:        discordgo.ApplicationCommandOptionUser,
 							Required:    true,
 						},
+						{
+							Name:        Period,
+							Description: "Only count games from this period",
+							Type:        discordgo.ApplicationCommandOptionString,
+							Choices:     periodChoices(),
+							Required:    false,
+						},
+						{
+							Name:        Season,
+							Description: "Only count games from this season, including past ones (see `/stats season list`)",
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Required:    false,
+						},
+						{
+							Name:        Scope,
+							Description: "Count games from this guild, or from every guild the user played on",
+							Type:        discordgo.ApplicationCommandOptionString,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{
+									Name:  ScopeGuild,
+									Value: ScopeGuild,
+								},
+								{
+									Name:  ScopeGlobal,
+									Value: ScopeGlobal,
+								},
+							},
+							Required: false,
+						},
 					},
 				},
 				{
@@ -49,6 +151,225 @@ var Stats = discordgo.ApplicationCommand{
 					Name:        Guild,
 					Description: "View this guild's stats",
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Name:        Period,
+							Description: "Only count games from this period",
+							Type:        discordgo.ApplicationCommandOptionString,
+							Choices:     periodChoices(),
+							Required:    false,
+						},
+						{
+							Name:        Season,
+							Description: "Only count games from this season, including past ones (see `/stats season list`)",
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Required:    false,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        Leaderboard,
+			Description: "View this guild's leaderboard",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        Metric,
+					Description: "What to rank players by",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{
+							Name:  MetricRating,
+							Value: MetricRating,
+						},
+						{
+							Name:  MetricGames,
+							Value: MetricGames,
+						},
+						{
+							Name:  MetricWins,
+							Value: MetricWins,
+						},
+						{
+							Name:  MetricCrewmateWinRate,
+							Value: MetricCrewmateWinRate,
+						},
+						{
+							Name:  MetricImposterWinRate,
+							Value: MetricImposterWinRate,
+						},
+						{
+							Name:  MetricCurrentStreak,
+							Value: MetricCurrentStreak,
+						},
+						{
+							Name:  MetricBestStreak,
+							Value: MetricBestStreak,
+						},
+					},
+					Required: false,
+				},
+				{
+					Name:        Order,
+					Description: "Show the top or the bottom of the leaderboard first",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{
+							Name:  OrderDescending,
+							Value: OrderDescending,
+						},
+						{
+							Name:  OrderAscending,
+							Value: OrderAscending,
+						},
+					},
+					Required: false,
+				},
+			},
+		},
+		{
+			Name:        Versus,
+			Description: "Compare two players head-to-head",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        User,
+					Description: "First player",
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Required:    true,
+				},
+				{
+					Name:        Opponent,
+					Description: "Second player",
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        Season,
+			Description: "Manage this guild's stats seasons",
+			Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        SeasonCreate,
+					Description: "Create a season",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Name:        "name",
+							Description: "Name of the season",
+							Type:        discordgo.ApplicationCommandOptionString,
+							Required:    true,
+						},
+						{
+							Name:        "start",
+							Description: "When the season starts, like 2024-05-01 (UTC) or a Discord timestamp",
+							Type:        discordgo.ApplicationCommandOptionString,
+							Required:    true,
+						},
+						{
+							Name:        "end",
+							Description: "When the season ends, like 2024-06-01 (UTC) or a Discord timestamp",
+							Type:        discordgo.ApplicationCommandOptionString,
+							Required:    true,
+						},
+					},
+				},
+				{
+					Name:        SeasonList,
+					Description: "View this guild's seasons",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+				{
+					Name:        SeasonDelete,
+					Description: "Delete a season",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Name:        "id",
+							Description: "ID of the season to delete",
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        Achievements,
+			Description: "View or manage this guild's achievements",
+			Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        AchievementsList,
+					Description: "View the achievements players can unlock",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+				{
+					Name:        AchievementsEnable,
+					Description: "Turn an achievement on",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Name:        Achievement,
+							Description: "Achievement to turn on",
+							Type:        discordgo.ApplicationCommandOptionString,
+							Choices:     achievementChoices(),
+							Required:    true,
+						},
+					},
+				},
+				{
+					Name:        AchievementsDisable,
+					Description: "Turn an achievement off. It won't be awarded or shown on this guild",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Name:        Achievement,
+							Description: "Achievement to turn off",
+							Type:        discordgo.ApplicationCommandOptionString,
+							Choices:     achievementChoices(),
+							Required:    true,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        Unlinked,
+			Description: "Manage stats for players that aren't linked to a Discord user",
+			Type:        discordgo.ApplicationCommandOptionSubCommandGroup,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        UnlinkedList,
+					Description: "View the in-game names with unlinked games",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+				{
+					Name:        UnlinkedEnable,
+					Description: "Record the games of unlinked players by in-game name",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+				{
+					Name:        UnlinkedDisable,
+					Description: "Stop recording the games of unlinked players",
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+				},
+			},
+		},
+		{
+			Name:        Claim,
+			Description: "Ask to add the unlinked games played under an in-game name to your stats",
+			Type:        discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Name:        "name",
+					Description: "In-game name you played as",
+					Type:        discordgo.ApplicationCommandOptionString,
+					MaxLength:   MaxPlayerNameLength,
+					Required:    true,
 				},
 			},
 		},
@@ -80,8 +401,24 @@ var Stats = discordgo.ApplicationCommand{
 	},
 }
 
+// GetStatsParams returns the action and the type of stats it's for. For leaderboards, the type is the metric to rank by
 func GetStatsParams(s *discordgo.Session, guildID string, options []*discordgo.ApplicationCommandInteractionDataOption) (action string, opType string, id string) {
 	action = options[0].Name
+	switch action {
+	case Leaderboard:
+		opType = MetricRating
+		for _, v := range options[0].Options {
+			if v.Name == Metric {
+				opType = v.StringValue()
+			}
+		}
+		return action, opType, guildID
+	case Versus:
+		// see GetStatsVersusParams
+		return action, "", guildID
+	case Claim:
+		return action, "", options[0].Options[0].StringValue()
+	}
 	opType = options[0].Options[0].Name
 	switch opType {
 	case User:
@@ -93,3 +430,177 @@ func GetStatsParams(s *discordgo.Session, guildID string, options []*discordgo.A
 	}
 	return action, opType, id
 }
+
+// GetStatsLeaderboardOrder returns the order `/stats leaderboard` was asked to sort by, highest first by default
+func GetStatsLeaderboardOrder(options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	for _, v := range options[0].Options {
+		if v.Name == Order {
+			return v.StringValue()
+		}
+	}
+	return OrderDescending
+}
+
+// periodChoices returns new choices every time, so each option using them is localized separately
+func periodChoices() []*discordgo.ApplicationCommandOptionChoice {
+	return []*discordgo.ApplicationCommandOptionChoice{
+		{
+			Name:  PeriodAll,
+			Value: PeriodAll,
+		},
+		{
+			Name:  PeriodWeek,
+			Value: PeriodWeek,
+		},
+		{
+			Name:  PeriodMonth,
+			Value: PeriodMonth,
+		},
+		{
+			Name:  PeriodSeason,
+			Value: PeriodSeason,
+		},
+	}
+}
+
+// GetStatsPeriod returns the period picked for `/stats view`, or PeriodAll if none was. A season picked by ID takes
+// precedence over the period, and is returned in the form from SeasonPeriod
+func GetStatsPeriod(options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	if len(options) == 0 || len(options[0].Options) == 0 {
+		return PeriodAll
+	}
+	period := PeriodAll
+	for _, v := range options[0].Options[0].Options {
+		switch v.Name {
+		case Season:
+			return SeasonPeriod(v.IntValue())
+		case Period:
+			period = v.StringValue()
+		}
+	}
+	return period
+}
+
+// SeasonPeriod is the period for one specific season, as "season:<id>"
+func SeasonPeriod(seasonID int64) string {
+	return fmt.Sprintf("%s:%d", PeriodSeason, seasonID)
+}
+
+// ParseSeasonPeriod returns the season ID of a period from SeasonPeriod
+func ParseSeasonPeriod(period string) (int64, bool) {
+	idStr := strings.TrimPrefix(period, PeriodSeason+":")
+	if idStr == period {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	return id, err == nil
+}
+
+// IsSeasonPeriod reports whether a period covers a season, either the current one or one picked by ID
+func IsSeasonPeriod(period string) bool {
+	_, ok := ParseSeasonPeriod(period)
+	return ok || period == PeriodSeason
+}
+
+// GetStatsScope returns the scope picked for `/stats view user`, or ScopeGuild if none was
+func GetStatsScope(options []*discordgo.ApplicationCommandInteractionDataOption) string {
+	if len(options) == 0 || len(options[0].Options) == 0 {
+		return ScopeGuild
+	}
+	for _, v := range options[0].Options[0].Options {
+		if v.Name == Scope {
+			return v.StringValue()
+		}
+	}
+	return ScopeGuild
+}
+
+// GetStatsVersusParams returns the IDs of the two players to compare with `/stats versus`
+func GetStatsVersusParams(s *discordgo.Session, options []*discordgo.ApplicationCommandInteractionDataOption) (userID, opponentID string) {
+	for _, v := range options[0].Options {
+		switch v.Name {
+		case User:
+			userID = v.UserValue(s).ID
+		case Opponent:
+			opponentID = v.UserValue(s).ID
+		}
+	}
+	return userID, opponentID
+}
+
+type SeasonParams struct {
+	Action string
+	Name   string
+	Start  string
+	End    string
+	ID     int64
+}
+
+func GetStatsSeasonParams(options []*discordgo.ApplicationCommandInteractionDataOption) SeasonParams {
+	params := SeasonParams{Action: options[0].Options[0].Name}
+	for _, v := range options[0].Options[0].Options {
+		switch v.Name {
+		case "name":
+			params.Name = v.StringValue()
+		case "start":
+			params.Start = v.StringValue()
+		case "end":
+			params.End = v.StringValue()
+		case "id":
+			params.ID = v.IntValue()
+		}
+	}
+	return params
+}
+
+// GetStatsAchievementParams returns the `/stats achievements` subcommand, and the achievement it's for
+func GetStatsAchievementParams(options []*discordgo.ApplicationCommandInteractionDataOption) (action string, id string) {
+	action = options[0].Options[0].Name
+	for _, v := range options[0].Options[0].Options {
+		if v.Name == Achievement {
+			id = v.StringValue()
+		}
+	}
+	return action, id
+}
+
+// achievementChoices returns new choices every time, so each option using them is localized separately
+func achievementChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, len(achievement.All))
+	for i, v := range achievement.All {
+		choices[i] = &discordgo.ApplicationCommandOptionChoice{
+			Name:  v.ID,
+			Value: v.ID,
+		}
+	}
+	return choices
+}
+
+var (
+	ErrSeasonTimeFormat = errors.New("unrecognized time; use a UTC date like 2024-05-01, a UTC time like 2024-05-01 20:00, or a Discord timestamp")
+	ErrSeasonEnd        = errors.New("a season has to end after it starts")
+	ErrSeasonLength     = errors.New("seasons can't be longer than a year")
+	ErrSeasonName       = fmt.Errorf("season names have to be between 1 and %d characters", MaxSeasonNameLength)
+)
+
+// ParseSeason validates a season's name, and reads its start and end times in any format ParseTimestamp understands
+func ParseSeason(name, start, end string) (time.Time, time.Time, error) {
+	if name == "" || len([]rune(name)) > MaxSeasonNameLength {
+		return time.Time{}, time.Time{}, ErrSeasonName
+	}
+	startTime, ok := ParseTimestamp(start)
+	if !ok {
+		return time.Time{}, time.Time{}, ErrSeasonTimeFormat
+	}
+	endTime, ok := ParseTimestamp(end)
+	if !ok {
+		return time.Time{}, time.Time{}, ErrSeasonTimeFormat
+	}
+	if !endTime.After(startTime) {
+		return time.Time{}, time.Time{}, ErrSeasonEnd
+	}
+	if endTime.Sub(startTime) > MaxSeasonLength {
+		return time.Time{}, time.Time{}, ErrSeasonLength
+	}
+	return startTime, endTime, nil
+}
@@ -0,0 +1,53 @@
+package command
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseSeason(t *testing.T) {
+	start, end, err := ParseSeason("Spring", "2024-03-01", "2024-06-01 12:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !start.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected season times %s - %s", start, end)
+	}
+
+	tests := []struct {
+		name, start, end string
+		err              error
+	}{
+		{"", "2024-03-01", "2024-06-01", ErrSeasonName},
+		{"A season name that is way too long to fit", "2024-03-01", "2024-06-01", ErrSeasonName},
+		{"Spring", "March", "2024-06-01", ErrSeasonTimeFormat},
+		{"Spring", "2024-06-01", "2024-03-01", ErrSeasonEnd},
+		{"Spring", "2024-03-01", "2026-03-01", ErrSeasonLength},
+	}
+	for _, test := range tests {
+		_, _, err := ParseSeason(test.name, test.start, test.end)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s %s %s: expected error %v, got %v", test.name, test.start, test.end, test.err, err)
+		}
+	}
+}
+
+func TestSeasonPeriod(t *testing.T) {
+	period := SeasonPeriod(42)
+	if id, ok := ParseSeasonPeriod(period); !ok || id != 42 {
+		t.Errorf("Expected %s to be season 42, got %d %t", period, id, ok)
+	}
+	if !IsSeasonPeriod(period) || !IsSeasonPeriod(PeriodSeason) {
+		t.Errorf("Expected %s and %s to cover a season", period, PeriodSeason)
+	}
+
+	for _, period := range []string{PeriodAll, PeriodWeek, PeriodSeason, PeriodSeason + ":", PeriodSeason + ":abc", "42"} {
+		if id, ok := ParseSeasonPeriod(period); ok {
+			t.Errorf("Expected %s not to pick a season, got %d", period, id)
+		}
+	}
+	if IsSeasonPeriod(PeriodAll) || IsSeasonPeriod(PeriodWeek) {
+		t.Error("Expected all time and weekly periods not to cover a season")
+	}
+}
@@ -0,0 +1,191 @@
+package command
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// SyncCommands makes the registered commands for a guild (or globally, for an empty guildID) match the provided
+// commands. The existing commands are fetched and compared first, and only if something differs are they bulk
+// overwritten; this avoids needless rate limits, and commands never disappear while the bot restarts
+func SyncCommands(s *discordgo.Session, appID, guildID string, commands []*discordgo.ApplicationCommand) (bool, error) {
+	existing, err := s.ApplicationCommands(appID, guildID)
+	if err != nil {
+		return false, err
+	}
+	if CommandsEqual(existing, commands) {
+		return false, nil
+	}
+	_, err = s.ApplicationCommandBulkOverwrite(appID, guildID, commands)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// SyncCommandsForGuilds runs SyncCommands for every guild ID provided, where an empty ID means global commands
+func SyncCommandsForGuilds(s *discordgo.Session, appID string, guildIDs []string, commands []*discordgo.ApplicationCommand) error {
+	for _, guild := range guildIDs {
+		scope := "GLOBALLY"
+		if guild != "" {
+			scope = "in guild " + guild
+		}
+		changed, err := SyncCommands(s, appID, guild, commands)
+		if err != nil {
+			return fmt.Errorf("syncing commands %s: %w", scope, err)
+		}
+		if changed {
+			log.Printf("Commands changed; overwrote all %d commands %s\n", len(commands), scope)
+		} else {
+			log.Printf("Commands %s are already up to date\n", scope)
+		}
+	}
+	return nil
+}
+
+// CommandsEqual reports whether the commands registered with Discord match the desired commands, ignoring ordering
+// and the fields (IDs, versions) that Discord assigns itself
+func CommandsEqual(existing, desired []*discordgo.ApplicationCommand) bool {
+	if len(existing) != len(desired) {
+		return false
+	}
+	byName := make(map[string]*discordgo.ApplicationCommand, len(existing))
+	for _, cmd := range existing {
+		byName[cmd.Name] = cmd
+	}
+	for _, cmd := range desired {
+		other, ok := byName[cmd.Name]
+		if !ok || !commandEqual(other, cmd) {
+			return false
+		}
+	}
+	return true
+}
+
+func commandEqual(a, b *discordgo.ApplicationCommand) bool {
+	if commandType(a) != commandType(b) || a.Description != b.Description {
+		return false
+	}
+	if !int64PtrEqual(a.DefaultMemberPermissions, b.DefaultMemberPermissions) || dmPermission(a) != dmPermission(b) {
+		return false
+	}
+	if !localizationsEqual(derefLocalizations(a.NameLocalizations), derefLocalizations(b.NameLocalizations)) ||
+		!localizationsEqual(derefLocalizations(a.DescriptionLocalizations), derefLocalizations(b.DescriptionLocalizations)) {
+		return false
+	}
+	return optionsEqual(a.Options, b.Options)
+}
+
+func optionsEqual(a, b []*discordgo.ApplicationCommandOption) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	// option order matters to Discord (it's the order users see them in), so compare positionally
+	for i := range a {
+		x, y := a[i], b[i]
+		if x.Type != y.Type || x.Name != y.Name || x.Description != y.Description ||
+			x.Required != y.Required || x.Autocomplete != y.Autocomplete {
+			return false
+		}
+		if !float64PtrEqual(x.MinValue, y.MinValue) || x.MaxValue != y.MaxValue ||
+			intOrZero(x.MinLength) != intOrZero(y.MinLength) || x.MaxLength != y.MaxLength {
+			return false
+		}
+		if !localizationsEqual(x.NameLocalizations, y.NameLocalizations) ||
+			!localizationsEqual(x.DescriptionLocalizations, y.DescriptionLocalizations) {
+			return false
+		}
+		if !channelTypesEqual(x.ChannelTypes, y.ChannelTypes) || !choicesEqual(x.Choices, y.Choices) {
+			return false
+		}
+		if !optionsEqual(x.Options, y.Options) {
+			return false
+		}
+	}
+	return true
+}
+
+func choicesEqual(a, b []*discordgo.ApplicationCommandOptionChoice) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		// Discord sends numeric values back as floats; compare their printed forms instead
+		if a[i].Name != b[i].Name || fmt.Sprint(a[i].Value) != fmt.Sprint(b[i].Value) ||
+			!localizationsEqual(a[i].NameLocalizations, b[i].NameLocalizations) {
+			return false
+		}
+	}
+	return true
+}
+
+func channelTypesEqual(a, b []discordgo.ChannelType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func localizationsEqual(a, b map[discordgo.Locale]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func derefLocalizations(l *map[discordgo.Locale]string) map[discordgo.Locale]string {
+	if l == nil {
+		return nil
+	}
+	return *l
+}
+
+func int64PtrEqual(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// float64PtrEqual tells an unset value apart from zero, as a minimum of 0 still restricts the option
+func float64PtrEqual(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func intOrZero(i *int) int {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+// dmPermission treats an unset DM permission as allowed, which is what Discord defaults it to
+func dmPermission(cmd *discordgo.ApplicationCommand) bool {
+	if cmd.DMPermission == nil {
+		return true
+	}
+	return *cmd.DMPermission
+}
+
+// commandType treats an unset type as a chat input command, which is what Discord defaults it to
+func commandType(cmd *discordgo.ApplicationCommand) discordgo.ApplicationCommandType {
+	if cmd.Type == 0 {
+		return discordgo.ChatApplicationCommand
+	}
+	return cmd.Type
+}
@@ -0,0 +1,107 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestCommandsEqual(t *testing.T) {
+	desired := []*discordgo.ApplicationCommand{&Help, &Map, &Stats}
+	if !CommandsEqual(desired, desired) {
+		t.Error("Expected identical command lists to be equal")
+	}
+
+	// Discord returns the command type, IDs, and integer values as floats; none of these should count as changes
+	existing := []*discordgo.ApplicationCommand{
+		{
+			ID:          "123",
+			Version:     "1",
+			Type:        discordgo.ChatApplicationCommand,
+			Name:        "test",
+			Description: "Test command",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "num",
+					Description: "A number",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "one", Value: float64(1)},
+					},
+				},
+			},
+		},
+	}
+	cmd := &discordgo.ApplicationCommand{
+		Name:        "test",
+		Description: "Test command",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "num",
+				Description: "A number",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "one", Value: 1},
+				},
+			},
+		},
+	}
+	if !CommandsEqual(existing, []*discordgo.ApplicationCommand{cmd}) {
+		t.Error("Expected Discord-assigned fields to be ignored when comparing commands")
+	}
+
+	cmd.Options[0].Required = true
+	if CommandsEqual(existing, []*discordgo.ApplicationCommand{cmd}) {
+		t.Error("Expected a changed option to make the commands differ")
+	}
+	cmd.Options[0].Required = false
+
+	minValue := float64(1)
+	cmd.Options[0].MinValue = &minValue
+	if CommandsEqual(existing, []*discordgo.ApplicationCommand{cmd}) {
+		t.Error("Expected an added minimum value to make the commands differ")
+	}
+	existingMin := float64(1)
+	existing[0].Options[0].MinValue = &existingMin
+	if !CommandsEqual(existing, []*discordgo.ApplicationCommand{cmd}) {
+		t.Error("Expected equal minimum values to be in sync")
+	}
+	minValue = 2
+	if CommandsEqual(existing, []*discordgo.ApplicationCommand{cmd}) {
+		t.Error("Expected a changed minimum value to make the commands differ")
+	}
+	minValue = 1
+
+	cmd.Options[0].MaxValue = 10
+	if CommandsEqual(existing, []*discordgo.ApplicationCommand{cmd}) {
+		t.Error("Expected a changed maximum value to make the commands differ")
+	}
+	cmd.Options[0].MaxValue = 0
+
+	perms := int64(discordgo.PermissionManageServer)
+	cmd.DefaultMemberPermissions = &perms
+	if CommandsEqual(existing, []*discordgo.ApplicationCommand{cmd}) {
+		t.Error("Expected changed default member permissions to make the commands differ")
+	}
+	cmd.DefaultMemberPermissions = nil
+
+	dm := true
+	cmd.DMPermission = &dm
+	if !CommandsEqual(existing, []*discordgo.ApplicationCommand{cmd}) {
+		t.Error("Expected an explicit DM permission of true to match Discord's default")
+	}
+	dm = false
+	if CommandsEqual(existing, []*discordgo.ApplicationCommand{cmd}) {
+		t.Error("Expected a disabled DM permission to make the commands differ")
+	}
+	cmd.DMPermission = nil
+
+	cmd.DescriptionLocalizations = &map[discordgo.Locale]string{discordgo.French: "Commande de test"}
+	if CommandsEqual(existing, []*discordgo.ApplicationCommand{cmd}) {
+		t.Error("Expected added localizations to make the commands differ")
+	}
+
+	if CommandsEqual(existing, desired) {
+		t.Error("Expected command lists of different lengths to differ")
+	}
+}
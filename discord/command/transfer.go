@@ -0,0 +1,75 @@
+package command
+
+import (
+	"github.com/automuteus/utils/pkg/discord"
+	"github.com/automuteus/utils/pkg/premium"
+	"github.com/automuteus/utils/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+type TransferStatus int
+
+const (
+	TransferSuccess TransferStatus = iota
+	TransferAlreadyLeader
+	TransferBot
+)
+
+var Transfer = discordgo.ApplicationCommand{
+	Name:        "transfer",
+	Description: "Transfer control of the current game to another user",
+	Options: []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "user",
+			Description: "User to transfer the game to",
+			Required:    true,
+		},
+	},
+}
+
+func GetTransferParams(s *discordgo.Session, options []*discordgo.ApplicationCommandInteractionDataOption) *discordgo.User {
+	return options[0].UserValue(s)
+}
+
+func TransferResponse(status TransferStatus, userID string, tier premium.Tier, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	var content string
+	switch status {
+	case TransferSuccess:
+		content = sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.transfer.success",
+			Other: "Transferred control of the game to {{.UserMention}}",
+		}, map[string]interface{}{
+			"UserMention": discord.MentionByUserID(userID),
+		})
+		if tier != premium.FreeTier {
+			content += "\n" + sett.LocalizeMessage(&i18n.Message{
+				ID:    "commands.transfer.premium",
+				Other: "This game is now running with {{.Tier}} premium",
+			}, map[string]interface{}{
+				"Tier": premium.TierStrings[tier],
+			})
+		}
+	case TransferAlreadyLeader:
+		content = sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.transfer.alreadyLeader",
+			Other: "{{.UserMention}} is already in control of the game",
+		}, map[string]interface{}{
+			"UserMention": discord.MentionByUserID(userID),
+		})
+	case TransferBot:
+		content = sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.transfer.bot",
+			Other: "I can't transfer the game to a bot",
+		})
+	}
+
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:   1 << 6,
+			Content: content,
+		},
+	}
+}
@@ -29,6 +29,11 @@ type GameState struct {
 	GameStateMsg GameStateMessage `json:"gameStateMessage"`
 
 	GameData amongus.GameData `json:"amongUsData"`
+
+	// Profile is the settings profile the game was started with, if any. Settings holds a snapshot of that profile
+	// and any overrides for the voice channel, so later changes don't affect games already in progress
+	Profile  string                  `json:"profile,omitempty"`
+	Settings *settings.GuildSettings `json:"settings,omitempty"`
 }
 
 func NewDiscordGameState(guildID string) *GameState {
@@ -49,6 +54,8 @@ func (dgs *GameState) Reset() {
 	dgs.VoiceChannel = ""
 	dgs.GameStateMsg = MakeGameStateMessage()
 	dgs.GameData = amongus.NewGameData()
+	dgs.Profile = ""
+	dgs.Settings = nil
 }
 
 func (dgs *GameState) checkCacheAndAddUser(g *discordgo.Guild, s *discordgo.Session, userID string) (UserData, bool) {
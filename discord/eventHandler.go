@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/automuteus/automuteus/amongus"
+	"github.com/automuteus/automuteus/discord/setting"
 	"github.com/automuteus/automuteus/metrics"
 	"github.com/automuteus/utils/pkg/discord"
 	"github.com/automuteus/utils/pkg/game"
@@ -66,7 +67,6 @@ func (bot *Bot) SubscribeToGameByConnectCode(guildID, connectCode string, endGam
 					Payload:   job.Payload.(string),
 				}
 				correlatedUserID := ""
-				sett := bot.StorageInterface.GetGuildSettings(guildID)
 
 				switch job.JobType {
 				case task.ConnectionJob:
@@ -81,6 +81,7 @@ func (bot *Bot) SubscribeToGameByConnectCode(guildID, connectCode string, endGam
 					}
 					dgs.ConnectCode = connectCode
 					bot.RedisInterface.SetDiscordGameState(dgs, lock)
+					sett := bot.settingsForGame(dgs)
 
 					bot.handleTrackedMembers(bot.PrimarySession, sett, 0, NoPriority, dgsRequest)
 					bot.DispatchRefreshOrEdit(dgs, dgsRequest, sett)
@@ -93,7 +94,7 @@ func (bot *Bot) SubscribeToGameByConnectCode(guildID, connectCode string, endGam
 						break
 					}
 
-					bot.processLobby(sett, lobby, dgsRequest)
+					bot.processLobby(lobby, dgsRequest)
 				case task.StateJob:
 					num, err := strconv.ParseInt(job.Payload.(string), 10, 64)
 					if err != nil {
@@ -113,9 +114,10 @@ func (bot *Bot) SubscribeToGameByConnectCode(guildID, connectCode string, endGam
 						break
 					}
 
-					shouldHandleTracked, userID, readOnlyDgs, err := bot.processPlayer(sett, player, dgsRequest)
+					shouldHandleTracked, userID, readOnlyDgs, sett, err := bot.processPlayer(player, dgsRequest)
 					if shouldHandleTracked {
-						bot.handleTrackedMembers(bot.PrimarySession, sett, 0, NoPriority, dgsRequest)
+						delay := setting.GetRevealDelay(sett, readOnlyDgs.GameData.GetPhase(), player.Action)
+						bot.handleTrackedMembers(bot.PrimarySession, sett, delay, NoPriority, dgsRequest)
 					}
 					if err != nil {
 						bot.PrimarySession.ChannelMessageSend(readOnlyDgs.GameStateMsg.MessageChannelID, sett.LocalizeMessage(&i18n.Message{
@@ -143,6 +145,7 @@ func (bot *Bot) SubscribeToGameByConnectCode(guildID, connectCode string, endGam
 					// we only need a read-only state for making the game summary message
 					dgs := bot.RedisInterface.GetReadOnlyDiscordGameState(dgsRequest)
 					if dgs != nil {
+						sett := bot.settingsForGame(dgs)
 						delTime := sett.GetDeleteGameSummaryMinutes()
 						if delTime != 0 {
 							winners := getWinners(*dgs, gameOverResult)
@@ -172,7 +175,12 @@ func (bot *Bot) SubscribeToGameByConnectCode(guildID, connectCode string, endGam
 								metrics.RecordDiscordRequests(bot.RedisInterface.client, metrics.MessageCreateDelete, 1)
 							}
 						}
-						go dumpGameToPostgres(*dgs, bot.PostgresInterface, gameOverResult)
+						go func(dgs GameState) {
+							userGames := dumpGameToPostgres(dgs, bot.PostgresInterface, bot.RedisInterface, gameOverResult)
+							if len(userGames) > 0 {
+								bot.awardAchievements(dgs, gameOverResult, userGames, sett)
+							}
+						}(*dgs)
 
 						// refresh the game message if the setting is marked (it is not locked, the previous dgs is
 						// read-only). This means the original msg is refreshed, not the gameover message
@@ -275,7 +283,9 @@ func getWinners(dgs GameState, gameOver game.Gameover) []winnerRecord {
 	return winners
 }
 
-func (bot *Bot) processPlayer(sett *settings.GuildSettings, player game.Player, dgsRequest GameStateRequest) (bool, string, *GameState, error) {
+// processPlayer applies a player update to the game state. The settings the game runs with are returned alongside
+// the state, so the caller doesn't need to resolve them again
+func (bot *Bot) processPlayer(player game.Player, dgsRequest GameStateRequest) (bool, string, *GameState, *settings.GuildSettings, error) {
 	var err error
 	if player.Name != "" {
 		lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLock(dgsRequest)
@@ -283,6 +293,7 @@ func (bot *Bot) processPlayer(sett *settings.GuildSettings, player game.Player,
 			lock, dgs = bot.RedisInterface.GetDiscordGameStateAndLock(dgsRequest)
 		}
 		dgs.Linked = true
+		sett := bot.settingsForGame(dgs)
 
 		defer bot.RedisInterface.SetDiscordGameState(dgs, lock)
 
@@ -310,7 +321,7 @@ func (bot *Bot) processPlayer(sett *settings.GuildSettings, player game.Player,
 				bot.DispatchRefreshOrEdit(dgs, dgsRequest, sett)
 			}
 
-			return true, userID, dgs, err
+			return true, userID, dgs, sett, err
 		}
 		updated, isAliveUpdated, data := dgs.GameData.UpdatePlayer(player)
 		switch {
@@ -323,7 +334,7 @@ func (bot *Bot) processPlayer(sett *settings.GuildSettings, player game.Player,
 				userID = dgs.AttemptPairingByUserIDs(data, uids)
 			}
 			bot.DispatchRefreshOrEdit(dgs, dgsRequest, sett)
-			return true, userID, dgs, err
+			return true, userID, dgs, sett, err
 		case updated:
 			userID := dgs.AttemptPairingByMatchingNames(data)
 			if userID == "" {
@@ -334,29 +345,29 @@ func (bot *Bot) processPlayer(sett *settings.GuildSettings, player game.Player,
 			if isAliveUpdated && dgs.GameData.GetPhase() == game.TASKS {
 				if sett.GetUnmuteDeadDuringTasks() || player.Action == game.EXILED {
 					bot.DispatchRefreshOrEdit(dgs, dgsRequest, sett)
-					return true, userID, dgs, err
+					return true, userID, dgs, sett, err
 				}
 				log.Println("NOT updating the discord status message; would leak info")
-				return false, userID, dgs, err
+				return false, userID, dgs, sett, err
 			}
 			bot.DispatchRefreshOrEdit(dgs, dgsRequest, sett)
 			if player.Action == game.EXILED {
-				return false, userID, dgs, err // don't apply a mute to this player
+				return false, userID, dgs, sett, err // don't apply a mute to this player
 			}
-			return true, userID, dgs, err
+			return true, userID, dgs, sett, err
 		default:
-			return false, "", nil, nil
+			return false, "", nil, nil, nil
 		}
 	}
-	return false, "", nil, nil
+	return false, "", nil, nil, nil
 }
 
 func (bot *Bot) processTransition(phase game.Phase, dgsRequest GameStateRequest) {
-	sett := bot.StorageInterface.GetGuildSettings(dgsRequest.GuildID)
 	lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLock(dgsRequest)
 	for lock == nil {
 		lock, dgs = bot.RedisInterface.GetDiscordGameStateAndLock(dgsRequest)
 	}
+	sett := bot.settingsForGame(dgs)
 
 	oldPhase := dgs.GameData.UpdatePhase(phase)
 	if oldPhase == phase {
@@ -377,22 +388,23 @@ func (bot *Bot) processTransition(phase game.Phase, dgsRequest GameStateRequest)
 	switch phase {
 	case game.MENU:
 		bot.DispatchRefreshOrEdit(dgs, dgsRequest, sett)
+		if delay := setting.GetDelay(sett, oldPhase, phase); delay > 0 {
+			log.Printf("Sleeping for %d seconds before unmuting users in the menu\n", delay)
+			time.Sleep(time.Second * time.Duration(delay))
+		}
 		err := bot.applyToAll(dgs, false, false)
 		if err != nil {
 			log.Println("Error in unmuting all users when returning to menu ", err)
 		}
-		// on a gameover event from the capture, it's like going to the lobby; use that delay
-	case game.GAMEOVER:
-		phase = game.LOBBY
-		fallthrough
-	case game.LOBBY:
-		delay := sett.Delays.GetDelay(oldPhase, phase)
+		// on a gameover event from the capture, it's like going to the lobby (unless a GAMEOVER delay is set)
+	case game.GAMEOVER, game.LOBBY:
+		delay := setting.GetDelay(sett, oldPhase, phase)
 		bot.handleTrackedMembers(bot.PrimarySession, sett, delay, NoPriority, dgsRequest)
 
 		bot.DispatchRefreshOrEdit(dgs, dgsRequest, sett)
 
 	case game.TASKS:
-		delay := sett.Delays.GetDelay(oldPhase, phase)
+		delay := setting.GetDelay(sett, oldPhase, phase)
 		// when going from discussion to tasks, we should mute alive players FIRST
 		priority := AlivePriority
 		if oldPhase == game.LOBBY {
@@ -403,7 +415,7 @@ func (bot *Bot) processTransition(phase game.Phase, dgsRequest GameStateRequest)
 		bot.DispatchRefreshOrEdit(dgs, dgsRequest, sett)
 
 	case game.DISCUSS:
-		delay := sett.Delays.GetDelay(oldPhase, phase)
+		delay := setting.GetDelay(sett, oldPhase, phase)
 		bot.handleTrackedMembers(bot.PrimarySession, sett, delay, DeadPriority, dgsRequest)
 
 		if sett.AutoRefresh {
@@ -414,11 +426,12 @@ func (bot *Bot) processTransition(phase game.Phase, dgsRequest GameStateRequest)
 	}
 }
 
-func (bot *Bot) processLobby(sett *settings.GuildSettings, lobby game.Lobby, dgsRequest GameStateRequest) {
+func (bot *Bot) processLobby(lobby game.Lobby, dgsRequest GameStateRequest) {
 	lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLock(dgsRequest)
 	for lock == nil {
 		lock, dgs = bot.RedisInterface.GetDiscordGameStateAndLock(dgsRequest)
 	}
+	sett := bot.settingsForGame(dgs)
 
 	dgs.GameData.SetRoomRegionMap(lobby.LobbyCode, lobby.Region.ToString(), lobby.PlayMap)
 	bot.RedisInterface.SetDiscordGameState(dgs, lock)
@@ -450,20 +463,17 @@ func startGameInPostgres(dgs GameState, psql *storage.PsqlInterface) uint64 {
 	return i
 }
 
-func dumpGameToPostgres(dgs GameState, psql *storage.PsqlInterface, gameOver game.Gameover) {
+// dumpGameToPostgres records a game that ended, and returns the games of the linked players in it, or nil if it
+// wasn't recorded
+func dumpGameToPostgres(dgs GameState, psql *storage.PsqlInterface, redis *RedisInterface, gameOver game.Gameover) []*storage.PostgresUserGame {
 	if dgs.MatchID < 0 || dgs.MatchStartUnix < 0 {
 		log.Println("dgs match id or start time is <0; not dumping game to Postgres")
-		return
+		return nil
 	}
 	end := time.Now().Unix()
 
 	userGames := make([]*storage.PostgresUserGame, 0)
 
-	imposterWin := gameOver.GameOverReason == game.ImpostorByKill ||
-		gameOver.GameOverReason == game.ImpostorBySabotage ||
-		gameOver.GameOverReason == game.ImpostorByVote ||
-		gameOver.GameOverReason == game.ImpostorDisconnect
-
 	for _, v := range dgs.UserData {
 		if v.GetPlayerName() != amongus.UnlinkedPlayerName {
 			inGameData, found := dgs.GameData.GetByName(v.GetPlayerName())
@@ -489,19 +499,7 @@ func dumpGameToPostgres(dgs GameState, psql *storage.PsqlInterface, gameOver gam
 				continue
 			}
 
-			// assume crewmate by default
-			won := !imposterWin
-			role := game.CrewmateRole
-			for _, pi := range gameOver.PlayerInfos {
-				// only override for the imposters
-				if pi.IsImpostor {
-					if strings.EqualFold(pi.Name, inGameData.Name) {
-						role = game.ImposterRole
-						won = imposterWin
-						break
-					}
-				}
-			}
+			role, won := playerResult(inGameData.Name, gameOver)
 
 			userGames = append(userGames, &storage.PostgresUserGame{
 				UserID:      puser.UserID,
@@ -519,5 +517,26 @@ func dumpGameToPostgres(dgs GameState, psql *storage.PsqlInterface, gameOver gam
 	err := psql.UpdateGameAndPlayers(dgs.MatchID, int16(gameOver.GameOverReason), end, userGames)
 	if err != nil {
 		log.Println(err)
+		return nil
+	}
+	rateGame(psql.Pool, dgs.GuildID, dgs.MatchID, userGames)
+	recordUnlinkedPlayers(dgs, psql, redis, gameOver, userGames)
+	return userGames
+}
+
+// playerResult returns the role a player had in a game that ended, and if they won
+func playerResult(name string, gameOver game.Gameover) (game.GameRole, bool) {
+	imposterWin := gameOver.GameOverReason == game.ImpostorByKill ||
+		gameOver.GameOverReason == game.ImpostorBySabotage ||
+		gameOver.GameOverReason == game.ImpostorByVote ||
+		gameOver.GameOverReason == game.ImpostorDisconnect
+
+	// assume crewmate by default
+	for _, pi := range gameOver.PlayerInfos {
+		// only override for the imposters
+		if pi.IsImpostor && strings.EqualFold(pi.Name, name) {
+			return game.ImposterRole, imposterWin
+		}
 	}
+	return game.CrewmateRole, !imposterWin
 }
@@ -13,6 +13,13 @@ import (
 const DeferredEditSeconds = 2
 const colorSelectID = "select-color"
 
+const (
+	pauseButtonID     = "game-pause"
+	endButtonID       = "game-end"
+	refreshButtonID   = "game-refresh"
+	unmuteAllButtonID = "game-unmute-all"
+)
+
 type GameStateMessage struct {
 	MessageID        string `json:"messageID"`
 	MessageChannelID string `json:"messageChannelID"`
@@ -105,7 +112,7 @@ func deferredEditWorker(s *discordgo.Session, channelID, messageID string) {
 	}
 }
 
-func (dgs *GameState) CreateMessage(s *discordgo.Session, me *discordgo.MessageEmbed, channelID string, authorID string) bool {
+func (dgs *GameState) CreateMessage(s *discordgo.Session, me *discordgo.MessageEmbed, channelID string, authorID string, sett *settings.GuildSettings) bool {
 	components := []discordgo.MessageComponent{
 		discordgo.ActionsRow{
 			Components: []discordgo.MessageComponent{
@@ -116,6 +123,7 @@ func (dgs *GameState) CreateMessage(s *discordgo.Session, me *discordgo.MessageE
 				},
 			},
 		},
+		gameControlComponents(sett),
 	}
 	msg := sendEmbedWithComponents(s, channelID, me, components)
 	if msg != nil {
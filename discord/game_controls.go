@@ -0,0 +1,228 @@
+package discord
+
+import (
+	"log"
+
+	"github.com/automuteus/automuteus/discord/command"
+	"github.com/automuteus/utils/pkg/premium"
+	"github.com/automuteus/utils/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// gameControlComponents are the buttons shown under the color select on the game state message.
+// They share their handlers with the equivalent slash commands (/pause, /end, /refresh and /debug unmute-all)
+func gameControlComponents(sett *settings.GuildSettings) discordgo.ActionsRow {
+	return discordgo.ActionsRow{
+		Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				CustomID: pauseButtonID,
+				Style:    discordgo.PrimaryButton,
+				Label: sett.LocalizeMessage(&i18n.Message{
+					ID:    "gameControls.button.pause",
+					Other: "Pause/Resume",
+				}),
+			},
+			discordgo.Button{
+				CustomID: endButtonID,
+				Style:    discordgo.DangerButton,
+				Label: sett.LocalizeMessage(&i18n.Message{
+					ID:    "gameControls.button.end",
+					Other: "End",
+				}),
+			},
+			discordgo.Button{
+				CustomID: refreshButtonID,
+				Style:    discordgo.SecondaryButton,
+				Label: sett.LocalizeMessage(&i18n.Message{
+					ID:    "gameControls.button.refresh",
+					Other: "Refresh",
+				}),
+			},
+			discordgo.Button{
+				CustomID: unmuteAllButtonID,
+				Style:    discordgo.SecondaryButton,
+				Label: sett.LocalizeMessage(&i18n.Message{
+					ID:    "gameControls.button.unmuteAll",
+					Other: "Unmute all",
+				}),
+			},
+		},
+	}
+}
+
+func (bot *Bot) refreshGame(gsr GameStateRequest, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	if bot.RefreshGameStateMessage(gsr, sett) {
+		return command.PrivateResponse(ThumbsUp)
+	}
+	return command.NoGameResponse(sett)
+}
+
+func (bot *Bot) pauseOrResumeGame(gsr GameStateRequest, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLockRetries(gsr, 5)
+	if lock == nil {
+		log.Printf("No lock could be obtained when pausing game for guild %s, channel %s\n", gsr.GuildID, gsr.TextChannel)
+		return command.DeadlockGameStateResponse(command.Pause.Name, sett)
+	}
+	if !dgs.GameStateMsg.Exists() {
+		bot.RedisInterface.SetDiscordGameState(nil, lock)
+		return command.NoGameResponse(sett)
+	}
+
+	dgs.Running = !dgs.Running
+
+	bot.RedisInterface.SetDiscordGameState(dgs, lock)
+	var err error
+	// if we paused the game, unmute/undeafen all players
+	if !dgs.Running {
+		err = bot.applyToAll(dgs, false, false)
+	}
+	bot.DispatchRefreshOrEdit(dgs, gsr, sett)
+	if err != nil {
+		return command.PrivateErrorResponse(command.Pause.Name, err, sett)
+	}
+	return command.PrivateResponse(ThumbsUp)
+}
+
+func (bot *Bot) endGame(gsr GameStateRequest, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	dgs := bot.RedisInterface.GetReadOnlyDiscordGameState(gsr)
+	if dgs == nil {
+		return command.DeadlockGameStateResponse(command.End.Name, sett)
+	}
+	if !dgs.GameStateMsg.Exists() {
+		return command.NoGameResponse(sett)
+	}
+
+	if v, ok := bot.EndGameChannels[dgs.ConnectCode]; ok {
+		v <- true
+	}
+	delete(bot.EndGameChannels, dgs.ConnectCode)
+
+	err := bot.applyToAll(dgs, false, false)
+	if err != nil {
+		return command.PrivateErrorResponse(command.End.Name, err, sett)
+	}
+	return command.PrivateResponse(ThumbsUp)
+}
+
+func (bot *Bot) unmuteAll(gsr GameStateRequest, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	dgs := bot.RedisInterface.GetReadOnlyDiscordGameState(gsr)
+	if dgs == nil {
+		return command.DeadlockGameStateResponse(command.UnmuteAll, sett)
+	}
+	err := bot.applyToAll(dgs, false, false)
+	if err != nil {
+		return command.PrivateErrorResponse(command.UnmuteAll, err, sett)
+	}
+	return command.PrivateResponse(ThumbsUp)
+}
+
+func (bot *Bot) transferGame(gsr GameStateRequest, userID string, isPermissioned bool, target *discordgo.User, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	if target.Bot {
+		return command.TransferResponse(command.TransferBot, target.ID, premium.FreeTier, sett)
+	}
+	lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLockRetries(gsr, 5)
+	if lock == nil {
+		log.Printf("No lock could be obtained when transferring game for guild %s, channel %s\n", gsr.GuildID, gsr.TextChannel)
+		return command.DeadlockGameStateResponse(command.Transfer.Name, sett)
+	}
+	if !dgs.GameStateMsg.Exists() {
+		bot.RedisInterface.SetDiscordGameState(nil, lock)
+		return command.NoGameResponse(sett)
+	}
+	// the current leader can always hand the game off, even without the permissioned role
+	if !isPermissioned && dgs.GameStateMsg.LeaderID != userID {
+		bot.RedisInterface.SetDiscordGameState(nil, lock)
+		return command.InsufficientPermissionsResponse(sett)
+	}
+	if dgs.GameStateMsg.LeaderID == target.ID {
+		bot.RedisInterface.SetDiscordGameState(nil, lock)
+		return command.TransferResponse(command.TransferAlreadyLeader, target.ID, premium.FreeTier, sett)
+	}
+
+	tier := bot.transferLeader(dgs, target.ID)
+	bot.RedisInterface.SetDiscordGameState(dgs, lock)
+	bot.DispatchRefreshOrEdit(dgs, gsr, sett)
+
+	return command.TransferResponse(command.TransferSuccess, target.ID, tier, sett)
+}
+
+// transferLeader hands the game to a new leader, and re-evaluates the premium tier of the game for that leader. If the
+// game gains or loses premium, its profile and voice channel settings are applied again, so premium-only settings
+// follow the new leader. UserData is left untouched, so any existing links are preserved
+func (bot *Bot) transferLeader(dgs *GameState, leaderID string) premium.Tier {
+	oldPrem := bot.getLeaderPremiumTier(dgs.GuildID, dgs.GameStateMsg.LeaderID) != premium.FreeTier
+	dgs.GameStateMsg.LeaderID = leaderID
+	tier := bot.getLeaderPremiumTier(dgs.GuildID, leaderID)
+	if prem := tier != premium.FreeTier; prem != oldPrem {
+		bot.reapplyGameSettings(dgs, prem)
+	}
+	return tier
+}
+
+// reapplyGameSettings rebuilds the settings of a running game from the guild settings, its profile and the overrides
+// of its voice channel, the same way `/new` does. If the profile can't be loaded anymore, the game keeps its settings
+func (bot *Bot) reapplyGameSettings(dgs *GameState, prem bool) {
+	sett := bot.StorageInterface.GetGuildSettings(dgs.GuildID)
+	gameSett := sett
+	if dgs.Profile != "" {
+		profileSett, _, err := bot.loadSettingsProfile(dgs.GuildID, dgs.Profile, sett, prem)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		if profileSett == nil {
+			return
+		}
+		gameSett = profileSett
+	}
+	gameSett = bot.settingsForChannel(dgs.GuildID, dgs.VoiceChannel, gameSett, prem)
+	if gameSett == sett {
+		dgs.Settings = nil
+	} else {
+		dgs.Settings = gameSett
+	}
+}
+
+// transferOnLeaderLeave automatically hands the game to another member of the tracked voice channel when the leader
+// leaves it. Linked players are preferred over spectators
+func (bot *Bot) transferOnLeaderLeave(g *discordgo.Guild, oldChannelID, userID string, sett *settings.GuildSettings) {
+	gsr := GameStateRequest{
+		GuildID:      g.ID,
+		VoiceChannel: oldChannelID,
+	}
+	lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLockRetries(gsr, 5)
+	if lock == nil {
+		return
+	}
+	if !dgs.GameStateMsg.Exists() || dgs.VoiceChannel != oldChannelID || dgs.GameStateMsg.LeaderID != userID {
+		bot.RedisInterface.SetDiscordGameState(nil, lock)
+		return
+	}
+
+	newLeaderID := ""
+	for _, v := range g.VoiceStates {
+		if v.ChannelID != oldChannelID || v.UserID == userID {
+			continue
+		}
+		if mem, err := bot.PrimarySession.State.Member(g.ID, v.UserID); err == nil && mem.User != nil && mem.User.Bot {
+			continue
+		}
+		if newLeaderID == "" {
+			newLeaderID = v.UserID
+		}
+		if userData, err := dgs.GetUser(v.UserID); err == nil && userData.InGameName != "" {
+			newLeaderID = v.UserID
+			break
+		}
+	}
+	if newLeaderID == "" {
+		bot.RedisInterface.SetDiscordGameState(nil, lock)
+		return
+	}
+
+	log.Printf("Leader %s left voice channel %s; transferring game to %s\n", userID, oldChannelID, newLeaderID)
+	bot.transferLeader(dgs, newLeaderID)
+	bot.RedisInterface.SetDiscordGameState(dgs, lock)
+	bot.DispatchRefreshOrEdit(dgs, gsr, sett)
+}
@@ -0,0 +1,250 @@
+package discord
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/automuteus/automuteus/discord/command"
+	"github.com/automuteus/automuteus/storage"
+	"github.com/automuteus/utils/pkg/game"
+	"github.com/automuteus/utils/pkg/premium"
+	"github.com/automuteus/utils/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// LeaderboardPageSize is how many players are shown on each page of `/stats leaderboard`
+const LeaderboardPageSize = 10
+
+// LeaderboardEmbed shows a page of the guild's leaderboard for a metric, and whether there's another page after it.
+// Only players with at least LeaderboardMin games (as the role, for role winrates) are ranked
+func (bot *Bot) LeaderboardEmbed(guildID, metric string, ascending bool, page int, sett *settings.GuildSettings) (*discordgo.MessageEmbed, bool) {
+	if metric == command.MetricRating {
+		return bot.RatingLeaderboardEmbed(guildID, ascending, page, sett)
+	}
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return nil, false
+	}
+
+	var role int16
+	var order storage.RankingOrder
+	var streakOrder storage.StreakOrder
+	var title *i18n.Message
+	switch metric {
+	case command.MetricGames:
+		role, order = storage.AnyRole, storage.OrderByGames
+		title = &i18n.Message{
+			ID:    "responses.leaderboardEmbed.Games",
+			Other: "Leaderboard: Games Played",
+		}
+	case command.MetricWins:
+		role, order = storage.AnyRole, storage.OrderByWins
+		title = &i18n.Message{
+			ID:    "responses.leaderboardEmbed.Wins",
+			Other: "Leaderboard: Wins",
+		}
+	case command.MetricCrewmateWinRate:
+		role, order = int16(game.CrewmateRole), storage.OrderByWinRate
+		title = &i18n.Message{
+			ID:    "responses.leaderboardEmbed.CrewmateWinrate",
+			Other: "Leaderboard: Crewmate Winrate",
+		}
+	case command.MetricImposterWinRate:
+		role, order = int16(game.ImposterRole), storage.OrderByWinRate
+		title = &i18n.Message{
+			ID:    "responses.leaderboardEmbed.ImposterWinrate",
+			Other: "Leaderboard: Imposter Winrate",
+		}
+	case command.MetricCurrentStreak:
+		role, streakOrder = storage.AnyRole, storage.OrderByCurrentStreak
+		title = &i18n.Message{
+			ID:    "responses.leaderboardEmbed.CurrentStreak",
+			Other: "Leaderboard: Current Win Streak",
+		}
+	case command.MetricBestStreak:
+		role, streakOrder = storage.AnyRole, storage.OrderByBestStreak
+		title = &i18n.Message{
+			ID:    "responses.leaderboardEmbed.BestStreak",
+			Other: "Leaderboard: Best Win Streak",
+		}
+	default:
+		return nil, false
+	}
+
+	leaderboardMin := sett.GetLeaderboardMin()
+	// fetch one extra to know if there's another page
+	limit, offset := LeaderboardPageSize+1, page*LeaderboardPageSize
+	userIDs := make([]uint64, 0, limit)
+	values := make([]string, 0, limit)
+	if streakOrder != "" {
+		ranking, err := storage.GetStreakRankings(bot.PostgresInterface.Pool, gid, role, streakOrder, ascending, leaderboardMin, limit, offset)
+		if err != nil {
+			log.Println(err)
+			return nil, false
+		}
+		for _, v := range ranking {
+			userIDs = append(userIDs, v.UserID)
+			if streakOrder == storage.OrderByCurrentStreak {
+				values = append(values, fmt.Sprintf("%d", v.Current))
+			} else {
+				values = append(values, fmt.Sprintf("%d", v.Best))
+			}
+		}
+	} else {
+		ranking, err := storage.GetPlayerRankings(bot.PostgresInterface.Pool, gid, role, storage.AllTime, order, ascending,
+			leaderboardMin, limit, offset)
+		if err != nil {
+			log.Println(err)
+			return nil, false
+		}
+		for _, v := range ranking {
+			userIDs = append(userIDs, v.UserID)
+			switch order {
+			case storage.OrderByGames:
+				values = append(values, fmt.Sprintf("%d", v.Games))
+			case storage.OrderByWins:
+				values = append(values, fmt.Sprintf("%d/%d", v.Wins, v.Games))
+			default:
+				values = append(values, fmt.Sprintf("%.0f%% (%d/%d)", v.WinRate, v.Wins, v.Games))
+			}
+		}
+	}
+	hasNext := false
+	if len(userIDs) > LeaderboardPageSize {
+		userIDs = userIDs[:LeaderboardPageSize]
+		hasNext = true
+	}
+
+	buf := bytes.NewBuffer([]byte{})
+	for i, userID := range userIDs {
+		buf.WriteString(fmt.Sprintf("%d. %s | %s", offset+i+1, values[i],
+			bot.MentionWithCacheData(strconv.FormatUint(userID, 10), guildID, sett)))
+		if i < len(userIDs)-1 {
+			buf.WriteByte('\n')
+		}
+	}
+	if len(userIDs) == 0 {
+		buf.WriteString(sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.ratingLeaderboardEmbed.Empty",
+			Other: "Nobody yet",
+		}))
+	}
+
+	desc := sett.LocalizeMessage(&i18n.Message{
+		ID:    "responses.leaderboardEmbed.Desc",
+		Other: "Players with {{.Min}}+ games",
+	}, map[string]interface{}{
+		"Min": leaderboardMin,
+	})
+	if ascending {
+		desc += ", " + sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.leaderboardEmbed.Ascending",
+			Other: "lowest first",
+		})
+	}
+	return &discordgo.MessageEmbed{
+		Title:       sett.LocalizeMessage(title),
+		Description: desc + "\n\n" + buf.String(),
+		Color:       3066993, // GREEN
+		Footer:      leaderboardFooter(page, sett),
+	}, hasNext
+}
+
+func leaderboardFooter(page int, sett *settings.GuildSettings) *discordgo.MessageEmbedFooter {
+	return &discordgo.MessageEmbedFooter{
+		Text: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.leaderboardEmbed.Page",
+			Other: "Page {{.Page}}",
+		}, map[string]interface{}{
+			"Page": page + 1,
+		}),
+	}
+}
+
+func leaderboardComponents(metric, order string, page int, hasNext bool, sett *settings.GuildSettings) []discordgo.MessageComponent {
+	if page == 0 && !hasNext {
+		return nil
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					CustomID: fmt.Sprintf("%s%s:%s:%d", leaderboardPagePrefix, metric, order, page-1),
+					Style:    discordgo.SecondaryButton,
+					Disabled: page <= 0,
+					Label: sett.LocalizeMessage(&i18n.Message{
+						ID:    "responses.matchTimeline.button.previous",
+						Other: "Previous",
+					}),
+				},
+				discordgo.Button{
+					CustomID: fmt.Sprintf("%s%s:%s:%d", leaderboardPagePrefix, metric, order, page+1),
+					Style:    discordgo.SecondaryButton,
+					Disabled: !hasNext,
+					Label: sett.LocalizeMessage(&i18n.Message{
+						ID:    "responses.matchTimeline.button.next",
+						Other: "Next",
+					}),
+				},
+			},
+		},
+	}
+}
+
+// LeaderboardResponse shows a page of the leaderboard, either as a new message or by updating the message whose
+// page button was clicked
+func (bot *Bot) LeaderboardResponse(guildID, metric, order string, page int, update bool, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	if page < 0 {
+		page = 0
+	}
+	embed, hasNext := bot.LeaderboardEmbed(guildID, metric, order == command.OrderAscending, page, sett)
+	if embed == nil {
+		return nil
+	}
+	responseType := discordgo.InteractionResponseChannelMessageWithSource
+	if update {
+		responseType = discordgo.InteractionResponseUpdateMessage
+	}
+	return &discordgo.InteractionResponse{
+		Type: responseType,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: leaderboardComponents(metric, order, page, hasNext, sett),
+		},
+	}
+}
+
+// handleLeaderboardPage shows the page of the leaderboard in a button's ID, which looks like METRIC:ORDER:PAGE
+func (bot *Bot) handleLeaderboardPage(guildID, userID, customID string, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	tokens := strings.Split(strings.TrimPrefix(customID, leaderboardPagePrefix), ":")
+	if len(tokens) != 3 {
+		return nil
+	}
+	tier, days, err := bot.PostgresInterface.GetGuildOrUserPremiumStatus(bot.official, bot.TopGGClient, guildID, userID)
+	if err != nil {
+		log.Println(err)
+	}
+	if premium.IsExpired(tier, days) {
+		return leaderboardNoPremiumResponse(sett)
+	}
+	page, err := strconv.Atoi(tokens[2])
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	return bot.LeaderboardResponse(guildID, tokens[0], tokens[1], page, true, sett)
+}
+
+// leaderboardNoPremiumResponse is shown instead of `/stats leaderboard`, as leaderboards (like those in the guild stats)
+// are only available with premium
+func leaderboardNoPremiumResponse(sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+		ID:    "responses.leaderboard.NoPremium",
+		Other: "Leaderboards are only available for AutoMuteUs Premium users; type `/premium` to learn more",
+	}))
+}
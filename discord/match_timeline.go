@@ -0,0 +1,423 @@
+package discord
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/automuteus/automuteus/discord/command"
+	"github.com/automuteus/utils/pkg/game"
+	"github.com/automuteus/utils/pkg/premium"
+	"github.com/automuteus/utils/pkg/settings"
+	storageutils "github.com/automuteus/utils/pkg/storage"
+	"github.com/automuteus/utils/pkg/task"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// MatchTimelinePageSize is how many timeline entries are shown on each page of a match's timeline
+const MatchTimelinePageSize = 20
+
+type timelineEntryType int
+
+const (
+	timelineTasks timelineEntryType = iota
+	timelineDiscuss
+	timelineDeath
+	timelineExile
+	timelineDisconnect
+	timelineGameOver
+)
+
+type timelineEntry struct {
+	Type   timelineEntryType
+	Offset time.Duration
+	Player game.Player
+}
+
+// matchTimeline turns the events recorded for a game into phase changes, deaths, exiles and disconnects, ending with
+// the game's result
+func matchTimeline(pgame *storageutils.PostgresGame, events []*storageutils.PostgresGameEvent) []timelineEntry {
+	offset := func(eventTime int32) time.Duration {
+		if eventTime < pgame.StartTime {
+			return 0
+		}
+		return time.Second * time.Duration(eventTime-pgame.StartTime)
+	}
+	entries := make([]timelineEntry, 0, len(events)+1)
+	for _, v := range events {
+		switch v.EventType {
+		case int16(task.StateJob):
+			if v.Payload == storageutils.DiscussCode {
+				entries = append(entries, timelineEntry{Type: timelineDiscuss, Offset: offset(v.EventTime)})
+			} else if v.Payload == storageutils.TasksCode {
+				entries = append(entries, timelineEntry{Type: timelineTasks, Offset: offset(v.EventTime)})
+			}
+		case int16(task.PlayerJob):
+			player := game.Player{}
+			err := json.Unmarshal([]byte(v.Payload), &player)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			entry := timelineEntry{Offset: offset(v.EventTime), Player: player}
+			switch player.Action {
+			case game.DIED:
+				entry.Type = timelineDeath
+			case game.EXILED:
+				entry.Type = timelineExile
+			case game.DISCONNECTED:
+				entry.Type = timelineDisconnect
+			default:
+				continue
+			}
+			entries = append(entries, entry)
+		}
+	}
+	if pgame.EndTime != -1 {
+		entries = append(entries, timelineEntry{Type: timelineGameOver, Offset: offset(pgame.EndTime)})
+	}
+	return entries
+}
+
+func formatTimelineOffset(d time.Duration) string {
+	d = d.Round(time.Second)
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	seconds := int(d.Seconds()) % 60
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%02d:%02d", minutes, seconds)
+}
+
+func gameResultMessage(result game.GameResult, sett *settings.GuildSettings) string {
+	var msg *i18n.Message
+	switch result {
+	case game.HumansByVote:
+		msg = &i18n.Message{ID: "responses.matchTimeline.result.humansByVote", Other: "Crewmates won by voting off the last Imposter"}
+	case game.HumansByTask:
+		msg = &i18n.Message{ID: "responses.matchTimeline.result.humansByTask", Other: "Crewmates won by completing tasks"}
+	case game.HumansDisconnect:
+		msg = &i18n.Message{ID: "responses.matchTimeline.result.humansDisconnect", Other: "Crewmates won because the last Imposter disconnected"}
+	case game.ImpostorByVote:
+		msg = &i18n.Message{ID: "responses.matchTimeline.result.impostorByVote", Other: "Imposters won by voting off the last Crewmate"}
+	case game.ImpostorByKill:
+		msg = &i18n.Message{ID: "responses.matchTimeline.result.impostorByKill", Other: "Imposters won by killing the last Crewmate"}
+	case game.ImpostorBySabotage:
+		msg = &i18n.Message{ID: "responses.matchTimeline.result.impostorBySabotage", Other: "Imposters won by sabotage"}
+	case game.ImpostorDisconnect:
+		msg = &i18n.Message{ID: "responses.matchTimeline.result.impostorDisconnect", Other: "Imposters won because the last Crewmate disconnected"}
+	default:
+		msg = &i18n.Message{ID: "responses.matchTimeline.result.unknown", Other: "Game over"}
+	}
+	return sett.LocalizeMessage(msg)
+}
+
+// timelineLine describes one entry of the timeline. The embed gets emojis and markdown, while the text export only
+// uses player names and colors
+func (bot *Bot) timelineLine(entry timelineEntry, result game.GameResult, plain bool, sett *settings.GuildSettings) string {
+	player := entry.Player.Name
+	if plain {
+		if color := game.GetColorStringForInt(entry.Player.Color); color != "" {
+			player = fmt.Sprintf("%s (%s)", player, color)
+		}
+	} else {
+		alive := entry.Type == timelineDisconnect
+		if entry.Player.Color >= 0 && entry.Player.Color < len(bot.StatusEmojis[alive]) {
+			emoji := bot.StatusEmojis[alive][entry.Player.Color]
+			player = emoji.FormatForInline() + " **" + player + "**"
+		} else {
+			player = "**" + player + "**"
+		}
+	}
+
+	var icon, text string
+	switch entry.Type {
+	case timelineTasks:
+		icon = "🔨"
+		text = sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchTimeline.Tasks",
+			Other: "Tasks phase begins",
+		})
+	case timelineDiscuss:
+		icon = "💬"
+		text = sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchTimeline.Discuss",
+			Other: "Discussion begins",
+		})
+	case timelineDeath:
+		icon = "☠️"
+		text = sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchTimeline.Death",
+			Other: "{{.Player}} died",
+		}, map[string]interface{}{
+			"Player": player,
+		})
+	case timelineExile:
+		icon = "🗳️"
+		text = sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchTimeline.Exile",
+			Other: "{{.Player}} was exiled",
+		}, map[string]interface{}{
+			"Player": player,
+		})
+	case timelineDisconnect:
+		icon = "🔌"
+		text = sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchTimeline.Disconnect",
+			Other: "{{.Player}} disconnected",
+		}, map[string]interface{}{
+			"Player": player,
+		})
+	case timelineGameOver:
+		icon = "🏁"
+		text = gameResultMessage(result, sett)
+	}
+	if plain {
+		return fmt.Sprintf("[%s] %s", formatTimelineOffset(entry.Offset), text)
+	}
+	return fmt.Sprintf("`%s` %s %s", formatTimelineOffset(entry.Offset), icon, text)
+}
+
+func timelinePages(entries []timelineEntry) int {
+	if len(entries) == 0 {
+		return 1
+	}
+	return (len(entries) + MatchTimelinePageSize - 1) / MatchTimelinePageSize
+}
+
+// clampTimelinePage keeps a page from a button within the timeline, which may have changed since the button was sent
+func clampTimelinePage(page, pages int) int {
+	if page > pages-1 {
+		page = pages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+	return page
+}
+
+func (bot *Bot) matchTimelineEmbed(combinedID string, pgame *storageutils.PostgresGame, entries []timelineEntry, page int, sett *settings.GuildSettings) *discordgo.MessageEmbed {
+	start := page * MatchTimelinePageSize
+	end := start + MatchTimelinePageSize
+	if end > len(entries) {
+		end = len(entries)
+	}
+	lines := make([]string, 0, MatchTimelinePageSize)
+	for _, entry := range entries[start:end] {
+		lines = append(lines, bot.timelineLine(entry, game.GameResult(pgame.WinType), false, sett))
+	}
+	desc := strings.Join(lines, "\n")
+	if len(lines) == 0 {
+		desc = sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchTimeline.NoEvents",
+			Other: "No events were recorded for this game",
+		})
+	}
+	return &discordgo.MessageEmbed{
+		Title: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchTimeline.Title",
+			Other: "Game `{{.MatchID}}` Timeline",
+		}, map[string]interface{}{
+			"MatchID": combinedID,
+		}),
+		Description: desc,
+		Color:       10181046, // PURPLE
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.matchTimeline.Page",
+				Other: "Page {{.Page}}/{{.Pages}} · {{.Events}} events",
+			}, map[string]interface{}{
+				"Page":   page + 1,
+				"Pages":  timelinePages(entries),
+				"Events": len(entries),
+			}),
+		},
+	}
+}
+
+// matchTimelineText is the whole timeline as plain text, for the export button
+func (bot *Bot) matchTimelineText(combinedID string, pgame *storageutils.PostgresGame, entries []timelineEntry, sett *settings.GuildSettings) string {
+	var b strings.Builder
+	b.WriteString(sett.LocalizeMessage(&i18n.Message{
+		ID:    "responses.matchTimeline.ExportHeader",
+		Other: "Game {{.MatchID}}, started {{.Start}}",
+	}, map[string]interface{}{
+		"MatchID": combinedID,
+		"Start":   time.Unix(int64(pgame.StartTime), 0).UTC().Format("2006-01-02 15:04:05 MST"),
+	}))
+	b.WriteString("\n\n")
+	for _, entry := range entries {
+		b.WriteString(bot.timelineLine(entry, game.GameResult(pgame.WinType), true, sett))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// matchStatsComponents are the buttons below a match's stats: the timeline's page buttons when a page is shown, and
+// the button to switch between the summary and the timeline
+func matchStatsComponents(combinedID string, page, pages int, timeline bool, sett *settings.GuildSettings) []discordgo.MessageComponent {
+	buttons := make([]discordgo.MessageComponent, 0, 4)
+	if timeline {
+		buttons = append(buttons,
+			discordgo.Button{
+				CustomID: fmt.Sprintf("%s%s:%d", matchTimelinePrefix, combinedID, page-1),
+				Style:    discordgo.SecondaryButton,
+				Disabled: page <= 0,
+				Label: sett.LocalizeMessage(&i18n.Message{
+					ID:    "responses.matchTimeline.button.previous",
+					Other: "Previous",
+				}),
+			},
+			discordgo.Button{
+				CustomID: fmt.Sprintf("%s%s:%d", matchTimelinePrefix, combinedID, page+1),
+				Style:    discordgo.SecondaryButton,
+				Disabled: page >= pages-1,
+				Label: sett.LocalizeMessage(&i18n.Message{
+					ID:    "responses.matchTimeline.button.next",
+					Other: "Next",
+				}),
+			},
+			discordgo.Button{
+				CustomID: matchSummaryPrefix + combinedID,
+				Style:    discordgo.PrimaryButton,
+				Label: sett.LocalizeMessage(&i18n.Message{
+					ID:    "responses.matchTimeline.button.summary",
+					Other: "Summary",
+				}),
+			},
+		)
+	} else {
+		buttons = append(buttons, discordgo.Button{
+			CustomID: fmt.Sprintf("%s%s:%d", matchTimelinePrefix, combinedID, 0),
+			Style:    discordgo.PrimaryButton,
+			Label: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.matchTimeline.button.timeline",
+				Other: "Timeline",
+			}),
+		})
+	}
+	buttons = append(buttons, discordgo.Button{
+		CustomID: matchExportPrefix + combinedID,
+		Style:    discordgo.SecondaryButton,
+		Label: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.matchTimeline.button.export",
+			Other: "Export as Text",
+		}),
+	})
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: buttons,
+		},
+	}
+}
+
+// handleMatchStatsComponent switches a match's stats message between its summary and timeline pages, or sends the
+// timeline as a text file
+func (bot *Bot) handleMatchStatsComponent(i *discordgo.InteractionCreate, customID string, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	var prefix string
+	for _, v := range []string{matchTimelinePrefix, matchSummaryPrefix, matchExportPrefix} {
+		if strings.HasPrefix(customID, v) {
+			prefix = v
+		}
+	}
+	// CODE:ID for the summary and export, CODE:ID:PAGE for the timeline
+	tokens := strings.Split(strings.TrimPrefix(customID, prefix), ":")
+	if len(tokens) < 2 {
+		return nil
+	}
+	connectCode, matchID := tokens[0], tokens[1]
+	combinedID := connectCode + ":" + matchID
+	page := 0
+	if prefix == matchTimelinePrefix && len(tokens) > 2 {
+		var err error
+		page, err = strconv.Atoi(tokens[2])
+		if err != nil {
+			log.Println(err)
+			return nil
+		}
+	}
+
+	tier, days, err := bot.PostgresInterface.GetGuildOrUserPremiumStatus(bot.official, bot.TopGGClient, i.GuildID, i.Member.User.ID)
+	if err != nil {
+		log.Println(err)
+	}
+	if premium.IsExpired(tier, days) {
+		return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.gameStatsEmbed.NoPremium",
+			Other: "Detailed match stats are only available for AutoMuteUs Premium users; type `/premium` to learn more",
+		}))
+	}
+
+	if prefix == matchSummaryPrefix {
+		embed := bot.GameStatsEmbed(i.GuildID, matchID, connectCode, true, sett)
+		if embed == nil {
+			return matchNotFoundResponse(combinedID, sett)
+		}
+		return &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseUpdateMessage,
+			Data: &discordgo.InteractionResponseData{
+				Embeds:     []*discordgo.MessageEmbed{embed},
+				Components: matchStatsComponents(combinedID, 0, 0, false, sett),
+			},
+		}
+	}
+
+	pgame, err := bot.PostgresInterface.GetGame(i.GuildID, connectCode, matchID)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Stats.Name+" "+command.Match, err, sett)
+	}
+	if pgame == nil {
+		return matchNotFoundResponse(combinedID, sett)
+	}
+	events, err := bot.PostgresInterface.GetGameEvents(matchID)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Stats.Name+" "+command.Match, err, sett)
+	}
+	entries := matchTimeline(pgame, events)
+
+	if prefix == matchExportPrefix {
+		return &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Flags: 1 << 6, //private message
+				Content: sett.LocalizeMessage(&i18n.Message{
+					ID:    "commands.download.file.success",
+					Other: "Here's that file for you!",
+				}),
+				Files: []*discordgo.File{
+					{
+						Name:        fmt.Sprintf("game-%s-%s.txt", connectCode, matchID),
+						ContentType: "text/plain",
+						Reader:      strings.NewReader(bot.matchTimelineText(combinedID, pgame, entries, sett)),
+					},
+				},
+			},
+		}
+	}
+
+	pages := timelinePages(entries)
+	page = clampTimelinePage(page, pages)
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{bot.matchTimelineEmbed(combinedID, pgame, entries, page, sett)},
+			Components: matchStatsComponents(combinedID, page, pages, true, sett),
+		},
+	}
+}
+
+func matchNotFoundResponse(combinedID string, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+		ID:    "responses.matchTimeline.NotFound",
+		Other: "Couldn't find game `{{.MatchID}}` on this server",
+	}, map[string]interface{}{
+		"MatchID": combinedID,
+	}))
+}
@@ -0,0 +1,101 @@
+package discord
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/automuteus/utils/pkg/game"
+	storageutils "github.com/automuteus/utils/pkg/storage"
+	"github.com/automuteus/utils/pkg/task"
+)
+
+func playerEvent(t *testing.T, eventTime int32, player game.Player) *storageutils.PostgresGameEvent {
+	payload, err := json.Marshal(player)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &storageutils.PostgresGameEvent{EventTime: eventTime, EventType: int16(task.PlayerJob), Payload: string(payload)}
+}
+
+func TestMatchTimeline(t *testing.T) {
+	pgame := &storageutils.PostgresGame{StartTime: 1000, EndTime: 1300, WinType: int16(game.HumansByVote)}
+	events := []*storageutils.PostgresGameEvent{
+		{EventTime: 995, EventType: int16(task.StateJob), Payload: storageutils.TasksCode},
+		playerEvent(t, 1060, game.Player{Action: game.DIED, Name: "red", Color: 0}),
+		playerEvent(t, 1061, game.Player{Action: game.JOINED, Name: "blue", Color: 1}),
+		{EventTime: 1062, EventType: int16(task.StateJob), Payload: storageutils.DiscussCode},
+		{EventTime: 1063, EventType: int16(task.PlayerJob), Payload: "not json"},
+		playerEvent(t, 1120, game.Player{Action: game.EXILED, Name: "blue", Color: 1}),
+		playerEvent(t, 1200, game.Player{Action: game.DISCONNECTED, Name: "green", Color: 2}),
+		{EventTime: 1210, EventType: int16(task.LobbyJob), Payload: "{}"},
+	}
+
+	entries := matchTimeline(pgame, events)
+	expected := []struct {
+		Type   timelineEntryType
+		Offset time.Duration
+		Player string
+	}{
+		{timelineTasks, 0, ""},
+		{timelineDeath, 60 * time.Second, "red"},
+		{timelineDiscuss, 62 * time.Second, ""},
+		{timelineExile, 120 * time.Second, "blue"},
+		{timelineDisconnect, 200 * time.Second, "green"},
+		{timelineGameOver, 300 * time.Second, ""},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d: %v", len(expected), len(entries), entries)
+	}
+	for i, v := range expected {
+		if entries[i].Type != v.Type || entries[i].Offset != v.Offset || entries[i].Player.Name != v.Player {
+			t.Errorf("Entry %d: expected %v, got %v", i, v, entries[i])
+		}
+	}
+
+	pgame.EndTime = -1
+	entries = matchTimeline(pgame, events)
+	if entries[len(entries)-1].Type == timelineGameOver {
+		t.Error("Expected no game over entry for a game that hasn't ended")
+	}
+}
+
+func TestFormatTimelineOffset(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                       "00:00",
+		65 * time.Second:        "01:05",
+		1600 * time.Millisecond: "00:02",
+		time.Hour + 2*time.Minute + 5*time.Second: "1:02:05",
+	}
+	for d, expected := range tests {
+		if actual := formatTimelineOffset(d); actual != expected {
+			t.Errorf("Expected %s for %s, got %s", expected, d, actual)
+		}
+	}
+}
+
+func TestTimelinePages(t *testing.T) {
+	if pages := timelinePages(nil); pages != 1 {
+		t.Errorf("Expected an empty timeline to have 1 page, got %d", pages)
+	}
+	if pages := timelinePages(make([]timelineEntry, MatchTimelinePageSize)); pages != 1 {
+		t.Errorf("Expected a full page to be 1 page, got %d", pages)
+	}
+	if pages := timelinePages(make([]timelineEntry, MatchTimelinePageSize+1)); pages != 2 {
+		t.Errorf("Expected one more entry than a page to be 2 pages, got %d", pages)
+	}
+
+	tests := []struct {
+		page, pages, expected int
+	}{
+		{0, 1, 0},
+		{-1, 2, 0},
+		{1, 2, 1},
+		{5, 2, 1},
+	}
+	for _, v := range tests {
+		if actual := clampTimelinePage(v.page, v.pages); actual != v.expected {
+			t.Errorf("Expected page %d of %d to clamp to %d, got %d", v.page, v.pages, v.expected, actual)
+		}
+	}
+}
@@ -31,6 +31,19 @@ func (bot *Bot) handleVoiceStateChange(s *discordgo.Session, m *discordgo.VoiceS
 	}
 
 	sett := bot.StorageInterface.GetGuildSettings(m.GuildID)
+
+	// the user left (or moved out of) a voice channel; if they were leading a game there, hand it off
+	if m.BeforeUpdate != nil && m.BeforeUpdate.ChannelID != "" && m.BeforeUpdate.ChannelID != m.ChannelID {
+		if g, err := s.State.Guild(m.GuildID); err == nil && g != nil {
+			go bot.transferOnLeaderLeave(g, m.BeforeUpdate.ChannelID, m.UserID, sett)
+		}
+	}
+
+	// the user joined a voice channel; it might now have enough members to start a game on its own
+	if m.ChannelID != "" && (m.BeforeUpdate == nil || m.BeforeUpdate.ChannelID != m.ChannelID) {
+		go bot.autoStartGame(s, m.GuildID, m.ChannelID, m.UserID)
+	}
+
 	gsr := GameStateRequest{
 		GuildID:      m.GuildID,
 		VoiceChannel: m.ChannelID,
@@ -41,6 +54,7 @@ func (bot *Bot) handleVoiceStateChange(s *discordgo.Session, m *discordgo.VoiceS
 		return
 	}
 	defer stateLock.Release(ctx)
+	sett = bot.settingsForGame(dgs)
 
 	var voiceLock *redislock.Lock
 	if dgs.ConnectCode != "" {
@@ -138,7 +152,7 @@ func (bot *Bot) handleGameStartMessage(guildID, textChannelID, voiceChannelID, u
 		}
 	}
 
-	_ = dgs.CreateMessage(bot.PrimarySession, bot.gameStateResponse(dgs, sett), textChannelID, userID)
+	_ = dgs.CreateMessage(bot.PrimarySession, bot.gameStateResponse(dgs, sett), textChannelID, userID, sett)
 
 	// release the lock
 	bot.RedisInterface.SetDiscordGameState(dgs, lock)
@@ -0,0 +1,345 @@
+package discord
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/automuteus/automuteus/discord/command"
+	"github.com/automuteus/automuteus/storage"
+	"github.com/automuteus/utils/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+	"github.com/gorilla/mux"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// profileURL is where a user's public profile page can be found, or "" if this bot doesn't serve profile pages.
+// PROFILE_URL is the public address of the bot's HTTP server, like https://stats.example.com
+func profileURL(userID string) string {
+	base := strings.TrimSuffix(os.Getenv("PROFILE_URL"), "/")
+	if base == "" {
+		return ""
+	}
+	return base + "/profile/" + userID
+}
+
+// optedOut reports if a user opted out of data collection. Users that never played haven't opted out
+func (bot *Bot) optedOut(userID string) bool {
+	user, err := bot.PostgresInterface.GetUserByString(userID)
+	return err == nil && user != nil && !user.Opt
+}
+
+// globalStatsUnavailableResponse explains why a user's stats from every guild can't be shown to the requester, or returns
+// nil if they can. Users can always see their own, but other users' are only shown if they made their profile public
+func (bot *Bot) globalStatsUnavailableResponse(userID, requesterID, period string, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	if command.IsSeasonPeriod(period) {
+		return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.globalStats.season",
+			Other: "Seasons only cover this server, so they can't be combined with global stats",
+		}))
+	}
+	if bot.optedOut(userID) {
+		return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.globalStats.optedOut",
+			Other: "{{.User}} opted out of data collection, so their global stats aren't available",
+		}, map[string]interface{}{
+			"User": "<@!" + userID + ">",
+		}))
+	}
+	if userID == requesterID {
+		return nil
+	}
+	uid, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Stats.Name, err, sett)
+	}
+	public, err := storage.IsProfilePublic(bot.PostgresInterface.Pool, uid)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Stats.Name, err, sett)
+	}
+	if !public {
+		return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.globalStats.private",
+			Other: "{{.User}} hasn't made their global stats public; they can with `/privacy profile-on`",
+		}, map[string]interface{}{
+			"User": "<@!" + userID + ">",
+		}))
+	}
+	return nil
+}
+
+// GlobalUserStatsEmbed is the counterpart of UserPeriodStatsEmbed for games played on every guild
+func (bot *Bot) GlobalUserStatsEmbed(userID string, period statsPeriod, sett *settings.GuildSettings, isPrem bool) *discordgo.MessageEmbed {
+	uid, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	stats, err := storage.GetGlobalUserStats(bot.PostgresInterface.Pool, uid, period.TimeRange)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+
+	fields := []*discordgo.MessageEmbedField{
+		{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.userStatsEmbed.GamesPlayed",
+				Other: "Games Played",
+			}),
+			Value:  fmt.Sprintf("%d", stats.Games),
+			Inline: true,
+		},
+		{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.userStatsEmbed.Winrate",
+				Other: "Winrate",
+			}),
+			Value:  fmt.Sprintf("%d/%d | %.0f%%", stats.Wins, stats.Games, percent(stats.Wins, stats.Games)),
+			Inline: true,
+		},
+		serversPlayedInField(stats.Guilds, sett),
+	}
+
+	extraDesc := sett.LocalizeMessage(&i18n.Message{
+		ID:    "responses.userStatsEmbed.NoPremium",
+		Other: "Detailed stats are only available for AutoMuteUs Premium users; type `/premium` to learn more",
+	})
+	if isPrem {
+		extraDesc = ""
+		games := sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.stats.Games",
+			Other: "Games",
+		})
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.userStatsEmbed.CrewmateWins",
+				Other: "Crewmate Wins",
+			}),
+			Value:  fmt.Sprintf("%d/%d %s | %.0f%%", stats.CrewmateWins, stats.CrewmateGames, games, percent(stats.CrewmateWins, stats.CrewmateGames)),
+			Inline: true,
+		}, &discordgo.MessageEmbedField{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.userStatsEmbed.ImposterWins",
+				Other: "Imposter Wins",
+			}),
+			Value:  fmt.Sprintf("%d/%d %s | %.0f%%", stats.ImposterWins, stats.ImposterGames, games, percent(stats.ImposterWins, stats.ImposterGames)),
+			Inline: true,
+		}, &discordgo.MessageEmbedField{
+			Name:   "\u200b",
+			Value:  "\u200b",
+			Inline: true,
+		})
+	}
+
+	label := sett.LocalizeMessage(&i18n.Message{
+		ID:    "responses.globalStats.label",
+		Other: "Every server",
+	})
+	if period.Label != "" {
+		label += ", " + period.Label
+	}
+	return &discordgo.MessageEmbed{
+		Title: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.globalStats.Title",
+			Other: "Global User Stats",
+		}),
+		Description: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.userStatsEmbed.Desc",
+			Other: "User stats for {{.User}}",
+		}, map[string]interface{}{
+			"User": "<@!" + userID + ">",
+		}) + "\n**" + label + "**\n\n" + extraDesc,
+		Color:  3066993, // GREEN
+		Fields: fields,
+	}
+}
+
+func (bot *Bot) hideProfile(userID string) error {
+	uid, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return err
+	}
+	return storage.SetProfilePublic(bot.PostgresInterface.Pool, uid, false, 0)
+}
+
+// PrivacyProfileResponse shows or hides a user's public profile page with `/privacy`
+func (bot *Bot) PrivacyProfileResponse(userID string, public bool, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	uid, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Privacy.Name, err, sett)
+	}
+	if !public {
+		err = bot.hideProfile(userID)
+		if err != nil {
+			log.Println(err)
+			return command.PrivateErrorResponse(command.Privacy.Name, err, sett)
+		}
+		return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.privacy.profile.off",
+			Other: "✅ Your profile page is hidden",
+		}))
+	}
+
+	url := profileURL(userID)
+	if url == "" {
+		return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.privacy.profile.unavailable",
+			Other: "❌ This bot doesn't host profile pages",
+		}))
+	}
+	user, err := bot.PostgresInterface.EnsureUserExists(uid)
+	if err != nil || user == nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Privacy.Name, err, sett)
+	}
+	if !user.Opt {
+		return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.privacy.profile.optedOut",
+			Other: "❌ You're opted out of data collection for game statistics; opt in with `/privacy opt-in` first",
+		}))
+	}
+	err = storage.SetProfilePublic(bot.PostgresInterface.Pool, uid, true, int32(time.Now().Unix()))
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Privacy.Name, err, sett)
+	}
+	return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+		ID:    "commands.privacy.profile.on",
+		Other: "✅ Anyone can now see your stats from every server at {{.URL}}",
+	}, map[string]interface{}{
+		"URL": url,
+	}))
+}
+
+var profileTemplate = template.Must(template.New("profile").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}} - AutoMuteUs</title>
+</head>
+<body>
+<h1>{{if .Avatar}}<img src="{{.Avatar}}" alt="" width="64" height="64"> {{end}}{{.Name}}</h1>
+<table>
+{{range .Rows}}<tr><th>{{.Label}}</th><td>{{.Value}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+type profileRow struct {
+	Label string
+	Value string
+}
+
+// ServeProfile serves the public profile page of a user that turned it on with `/privacy`, at /profile/{userID}.
+// Pass ?lang= to pick the language
+func (bot *Bot) ServeProfile(w http.ResponseWriter, r *http.Request) {
+	userID := mux.Vars(r)["userID"]
+	uid, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	public, err := storage.IsProfilePublic(bot.PostgresInterface.Pool, uid)
+	if err != nil {
+		log.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	// opting out hides the profile too, but check in case it was turned on before
+	if !public || bot.optedOut(userID) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	stats, err := storage.GetGlobalUserStats(bot.PostgresInterface.Pool, uid, storage.AllTime)
+	if err != nil {
+		log.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	sett := settings.MakeGuildSettings()
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		sett.SetLanguage(lang)
+	}
+	name, avatar := bot.profileUser(userID)
+	games := sett.LocalizeMessage(&i18n.Message{
+		ID:    "responses.stats.Games",
+		Other: "Games",
+	})
+	data := struct {
+		Name   string
+		Avatar string
+		Rows   []profileRow
+	}{
+		Name:   name,
+		Avatar: avatar,
+		Rows: []profileRow{
+			{
+				Label: sett.LocalizeMessage(&i18n.Message{
+					ID:    "responses.userStatsEmbed.GamesPlayed",
+					Other: "Games Played",
+				}),
+				Value: fmt.Sprintf("%d", stats.Games),
+			},
+			{
+				Label: sett.LocalizeMessage(&i18n.Message{
+					ID:    "responses.userStatsEmbed.Winrate",
+					Other: "Winrate",
+				}),
+				Value: fmt.Sprintf("%d/%d | %.0f%%", stats.Wins, stats.Games, percent(stats.Wins, stats.Games)),
+			},
+			{
+				Label: sett.LocalizeMessage(&i18n.Message{
+					ID:    "responses.userStatsEmbed.CrewmateWins",
+					Other: "Crewmate Wins",
+				}),
+				Value: fmt.Sprintf("%d/%d %s | %.0f%%", stats.CrewmateWins, stats.CrewmateGames, games, percent(stats.CrewmateWins, stats.CrewmateGames)),
+			},
+			{
+				Label: sett.LocalizeMessage(&i18n.Message{
+					ID:    "responses.userStatsEmbed.ImposterWins",
+					Other: "Imposter Wins",
+				}),
+				Value: fmt.Sprintf("%d/%d %s | %.0f%%", stats.ImposterWins, stats.ImposterGames, games, percent(stats.ImposterWins, stats.ImposterGames)),
+			},
+		},
+	}
+	played := serversPlayedInField(stats.Guilds, sett)
+	data.Rows = append(data.Rows, profileRow{Label: played.Name, Value: played.Value})
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	err = profileTemplate.Execute(w, data)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// profileUser returns the name and avatar to show on a user's public profile page. The page needs no login, so they're
+// cached instead of fetched from Discord on every visit; a failed fetch is cached too, with the user ID as the name
+func (bot *Bot) profileUser(userID string) (string, string) {
+	if name, avatar, ok := bot.RedisInterface.GetCachedProfileUser(userID); ok {
+		return name, avatar
+	}
+	name, avatar := userID, ""
+	if user, err := bot.PrimarySession.User(userID); err == nil {
+		name, avatar = user.Username, user.AvatarURL("64")
+	} else {
+		log.Println(err)
+	}
+	err := bot.RedisInterface.SetCachedProfileUser(userID, name, avatar)
+	if err != nil {
+		log.Println(err)
+	}
+	return name, avatar
+}
@@ -0,0 +1,176 @@
+package discord
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/automuteus/automuteus/rating"
+	"github.com/automuteus/automuteus/storage"
+	"github.com/automuteus/utils/pkg/game"
+	"github.com/automuteus/utils/pkg/settings"
+	storageutils "github.com/automuteus/utils/pkg/storage"
+	"github.com/bwmarrin/discordgo"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// rateGame updates the guild ratings of everyone that was linked in a game that just finished
+func rateGame(pool *pgxpool.Pool, guildID string, gameID int64, userGames []*storageutils.PostgresUserGame) {
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	players := make([]rating.Player, len(userGames))
+	for i, v := range userGames {
+		players[i] = rating.Player{
+			ID:   v.UserID,
+			Role: v.PlayerRole,
+			Won:  v.PlayerWon,
+		}
+	}
+	err = storage.RateGame(pool, gid, gameID, players)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// deleteUserRatings resets a user's ratings along with their stats
+func (bot *Bot) deleteUserRatings(userID string) error {
+	uid, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return err
+	}
+	return storage.DeleteRatingsForUser(bot.PostgresInterface.Pool, uid)
+}
+
+func (bot *Bot) deleteGuildRatings(guildID uint64) error {
+	return storage.DeleteRatingsForGuild(bot.PostgresInterface.Pool, guildID)
+}
+
+// ratingFields returns the embed fields showing a user's ratings, or nil if they haven't played a rated game
+func (bot *Bot) ratingFields(userID, guildID string, sett *settings.GuildSettings) []*discordgo.MessageEmbedField {
+	uid, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil
+	}
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		return nil
+	}
+	r, err := storage.GetUserRating(bot.PostgresInterface.Pool, gid, uid)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	if r == nil {
+		return nil
+	}
+	games := sett.LocalizeMessage(&i18n.Message{
+		ID:    "responses.stats.Games",
+		Other: "Games",
+	})
+	return []*discordgo.MessageEmbedField{
+		{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.userStatsEmbed.CrewmateRating",
+				Other: "Crewmate Rating",
+			}),
+			Value:  fmt.Sprintf("%.0f | %d %s", r.CrewmateRating, r.CrewmateGames, games),
+			Inline: true,
+		},
+		{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.userStatsEmbed.ImposterRating",
+				Other: "Imposter Rating",
+			}),
+			Value:  fmt.Sprintf("%.0f | %d %s", r.ImposterRating, r.ImposterGames, games),
+			Inline: true,
+		},
+		{
+			Name:   "\u200b",
+			Value:  "\u200b",
+			Inline: true,
+		},
+	}
+}
+
+// RatingLeaderboardEmbed shows a page of the crewmate and imposter rating leaderboards side by side, and whether
+// either of them has another page
+func (bot *Bot) RatingLeaderboardEmbed(guildID string, ascending bool, page int, sett *settings.GuildSettings) (*discordgo.MessageEmbed, bool) {
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return nil, false
+	}
+	leaderboardMin := sett.GetLeaderboardMin()
+	hasNext := false
+
+	fields := make([]*discordgo.MessageEmbedField, 0, 2)
+	roles := []struct {
+		role int16
+		name *i18n.Message
+	}{
+		{int16(game.CrewmateRole), &i18n.Message{
+			ID:    "responses.ratingLeaderboardEmbed.Crewmate",
+			Other: "Crewmate Rating ({{.Min}}+ Games)",
+		}},
+		{int16(game.ImposterRole), &i18n.Message{
+			ID:    "responses.ratingLeaderboardEmbed.Imposter",
+			Other: "Imposter Rating ({{.Min}}+ Games)",
+		}},
+	}
+	for _, v := range roles {
+		// fetch one extra to know if there's another page
+		ratings, err := storage.GetRatingLeaderboard(bot.PostgresInterface.Pool, gid, v.role, ascending, leaderboardMin,
+			LeaderboardPageSize+1, page*LeaderboardPageSize)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		if len(ratings) > LeaderboardPageSize {
+			ratings = ratings[:LeaderboardPageSize]
+			hasNext = true
+		}
+		buf := bytes.NewBuffer([]byte{})
+		for i, r := range ratings {
+			value, _ := r.ForRole(v.role)
+			buf.WriteString(fmt.Sprintf("%d. %.0f | %s", page*LeaderboardPageSize+i+1, value,
+				bot.MentionWithCacheData(strconv.FormatUint(r.UserID, 10), guildID, sett)))
+			if i < len(ratings)-1 {
+				buf.WriteByte('\n')
+			}
+		}
+		if len(ratings) == 0 {
+			buf.WriteString(sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.ratingLeaderboardEmbed.Empty",
+				Other: "Nobody yet",
+			}))
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name: sett.LocalizeMessage(v.name, map[string]interface{}{
+				"Min": leaderboardMin,
+			}),
+			Value:  buf.String(),
+			Inline: true,
+		})
+	}
+
+	return &discordgo.MessageEmbed{
+		Title: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.ratingLeaderboardEmbed.Title",
+			Other: "Rating Leaderboard",
+		}),
+		Description: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.ratingLeaderboardEmbed.Desc",
+			Other: "Skill ratings for crewmates and imposters on this server. Everyone starts at {{.Initial}}, and wins against stronger teams are worth more",
+		}, map[string]interface{}{
+			"Initial": fmt.Sprintf("%.0f", rating.InitialRating),
+		}),
+		Color:  3066993, // GREEN
+		Footer: leaderboardFooter(page, sett),
+		Fields: fields,
+	}, hasNext
+}
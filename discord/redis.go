@@ -21,6 +21,9 @@ const LockTimeoutMs = 250
 const LinearBackoffMs = 100
 const MaxRetries = 10
 const SnowflakeLockMs = 3000
+const AutoStartLockMs = 10000
+const ScheduleLockMs = 30000
+const SeasonLockMs = 30000
 
 // 15 minute timeout
 const GameTimeoutSeconds = 900
@@ -424,6 +427,43 @@ func (redisInterface *RedisInterface) setUsernameOrUserIDMappings(guildID, key s
 	return err
 }
 
+type cachedProfileUser struct {
+	Name   string `json:"name"`
+	Avatar string `json:"avatar"`
+}
+
+func profileUserKey(userID string) string {
+	return "automuteus:profile:user:" + userID
+}
+
+// GetCachedProfileUser returns the name and avatar shown on a user's public profile page, if they're still cached
+func (redisInterface *RedisInterface) GetCachedProfileUser(userID string) (name, avatar string, ok bool) {
+	jBytes, err := redisInterface.client.Get(ctx, profileUserKey(userID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return "", "", false
+	} else if err != nil {
+		log.Println(err)
+		return "", "", false
+	}
+	var user cachedProfileUser
+	err = json.Unmarshal(jBytes, &user)
+	if err != nil {
+		log.Println(err)
+		return "", "", false
+	}
+	return user.Name, user.Avatar, true
+}
+
+// SetCachedProfileUser caches the name and avatar shown on a user's public profile page, so the page doesn't have to
+// ask Discord for them on every visit
+func (redisInterface *RedisInterface) SetCachedProfileUser(userID, name, avatar string) error {
+	jBytes, err := json.Marshal(cachedProfileUser{Name: name, Avatar: avatar})
+	if err != nil {
+		return err
+	}
+	return redisInterface.client.Set(ctx, profileUserKey(userID), jBytes, rediskey.CachedUserDataExpiration).Err()
+}
+
 func (redisInterface *RedisInterface) LockSnowflake(snowflake string) *redislock.Lock {
 	locker := redislock.New(redisInterface.client)
 	lock, err := locker.Obtain(ctx, rediskey.SnowflakeLockID(snowflake), time.Millisecond*SnowflakeLockMs, nil)
@@ -436,6 +476,48 @@ func (redisInterface *RedisInterface) LockSnowflake(snowflake string) *redislock
 	return lock
 }
 
+// LockAutoStart makes sure only one shard tries to start a game for a voice channel that just filled up. No retries
+// are made; if another shard has the lock, it's already starting the game
+func (redisInterface *RedisInterface) LockAutoStart(guildID, voiceChannelID string) *redislock.Lock {
+	locker := redislock.New(redisInterface.client)
+	key := "automuteus:autostart:lock:" + string(rediskey.HashGuildID(guildID)) + ":" + voiceChannelID
+	lock, err := locker.Obtain(ctx, key, time.Millisecond*AutoStartLockMs, nil)
+	if errors.Is(err, redislock.ErrNotObtained) {
+		return nil
+	} else if err != nil {
+		log.Println(err)
+		return nil
+	}
+	return lock
+}
+
+// LockSchedule makes sure only one shard posts the reminder or creates the game for a scheduled session. No retries
+// are made; if another shard has the lock, it's already handling the session
+func (redisInterface *RedisInterface) LockSchedule(scheduleID int64) *redislock.Lock {
+	locker := redislock.New(redisInterface.client)
+	lock, err := locker.Obtain(ctx, fmt.Sprintf("automuteus:schedule:lock:%d", scheduleID), time.Millisecond*ScheduleLockMs, nil)
+	if errors.Is(err, redislock.ErrNotObtained) {
+		return nil
+	} else if err != nil {
+		log.Println(err)
+		return nil
+	}
+	return lock
+}
+
+// LockSeason makes sure only one shard posts the final leaderboard of a season that ended
+func (redisInterface *RedisInterface) LockSeason(seasonID int64) *redislock.Lock {
+	locker := redislock.New(redisInterface.client)
+	lock, err := locker.Obtain(ctx, fmt.Sprintf("automuteus:season:lock:%d", seasonID), time.Millisecond*SeasonLockMs, nil)
+	if errors.Is(err, redislock.ErrNotObtained) {
+		return nil
+	} else if err != nil {
+		log.Println(err)
+		return nil
+	}
+	return lock
+}
+
 func (redisInterface *RedisInterface) Close() error {
 	return redisInterface.client.Close()
 }
@@ -41,8 +41,12 @@ func settingResponse(settings []setting.Setting, sett *settings.GuildSettings, p
 	}
 
 	fields := make([]*discordgo.MessageEmbedField, 0)
+	// commands for managing settings as a whole are listed together, to stay within Discord's limit on embed fields
+	var management []string
 	for _, v := range settings {
-		if !v.Premium {
+		if setting.ManagementSettings[v.Name] {
+			management = append(management, "`"+v.Name+"`")
+		} else if !v.Premium {
 			name := v.Name
 			fields = append(fields, &discordgo.MessageEmbedField{
 				Name:   name,
@@ -51,6 +55,16 @@ func settingResponse(settings []setting.Setting, sett *settings.GuildSettings, p
 			})
 		}
 	}
+	if len(management) > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.settingResponse.Management",
+				Other: "Managing Settings",
+			}),
+			Value:  strings.Join(management, " "),
+			Inline: false,
+		})
+	}
 	var desc string
 	if prem {
 		desc = sett.LocalizeMessage(&i18n.Message{
@@ -74,7 +88,7 @@ func settingResponse(settings []setting.Setting, sett *settings.GuildSettings, p
 		Inline: false,
 	})
 	for _, v := range settings {
-		if v.Premium {
+		if v.Premium && !setting.ManagementSettings[v.Name] {
 			name := v.Name
 			fields = append(fields, &discordgo.MessageEmbedField{
 				Name:   name,
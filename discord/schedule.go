@@ -0,0 +1,499 @@
+package discord
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/automuteus/automuteus/discord/command"
+	"github.com/automuteus/automuteus/storage"
+	"github.com/automuteus/utils/pkg/premium"
+	"github.com/automuteus/utils/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+const (
+	// ScheduleWorkerInterval is how often each shard checks for sessions that need a reminder or a game
+	ScheduleWorkerInterval = time.Second * 30
+
+	// ScheduleReminderLead is how long before a session starts its reminder is posted
+	ScheduleReminderLead = time.Minute * 15
+
+	// ScheduleStartGrace is how late a session can still be started, such as after the bot was down. Sessions older
+	// than this are dropped without creating a game
+	ScheduleStartGrace = time.Minute * 30
+)
+
+func (bot *Bot) HandleScheduleCommand(i *discordgo.InteractionCreate, params command.ScheduleParams, canManage bool, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	gid, err := strconv.ParseUint(i.GuildID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Schedule.Name, err, sett)
+	}
+	switch params.Action {
+	case command.ScheduleCreate:
+		if !canManage {
+			return command.InsufficientPermissionsResponse(sett)
+		}
+		return bot.createScheduledGame(i, gid, params, sett)
+	case command.ScheduleCancel:
+		if !canManage {
+			return command.InsufficientPermissionsResponse(sett)
+		}
+		deleted, err := storage.DeleteScheduledGame(bot.PostgresInterface.Pool, gid, params.ID)
+		if err != nil {
+			log.Println(err)
+			return command.PrivateErrorResponse(command.Schedule.Name, err, sett)
+		}
+		if !deleted {
+			return schedulePrivateResponse(scheduleNotFoundMessage(params.ID, sett))
+		}
+		return schedulePrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.schedule.cancel.success",
+			Other: "Canceled session #{{.ID}}",
+		}, map[string]interface{}{
+			"ID": params.ID,
+		}))
+	}
+
+	games, err := storage.GetScheduledGames(bot.PostgresInterface.Pool, gid, command.MaxScheduledGames)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Schedule.Name, err, sett)
+	}
+	if len(games) == 0 {
+		return schedulePrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.schedule.list.none",
+			Other: "There aren't any sessions scheduled. Use `/schedule create` to add one",
+		}))
+	}
+	lines := make([]string, 0, len(games))
+	for _, game := range games {
+		rsvps, err := storage.GetScheduledGameRSVPs(bot.PostgresInterface.Pool, game.ScheduleID)
+		if err != nil {
+			log.Println(err)
+		}
+		lines = append(lines, fmt.Sprintf("**#%d** <t:%d:F> (<t:%d:R>) <#%d> → <#%d> · %d RSVP",
+			game.ScheduleID, game.StartTime, game.StartTime, game.VoiceChannelID, game.TextChannelID, len(rsvps)))
+	}
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: 1 << 6, //private message
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title: sett.LocalizeMessage(&i18n.Message{
+						ID:    "commands.schedule.list.title",
+						Other: "Upcoming Sessions",
+					}),
+					Description: strings.Join(lines, "\n"),
+					Color:       15844367, // GOLD
+				},
+			},
+		},
+	}
+}
+
+func (bot *Bot) createScheduledGame(i *discordgo.InteractionCreate, gid uint64, params command.ScheduleParams, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	start, err := command.ParseScheduleTime(params.Time, time.Now())
+	if err != nil {
+		return schedulePrivateResponse(err.Error())
+	}
+	voiceID, err := strconv.ParseUint(params.VoiceChannelID, 10, 64)
+	if err != nil {
+		return command.PrivateErrorResponse(command.Schedule.Name, err, sett)
+	}
+	textID, err := strconv.ParseUint(params.TextChannelID, 10, 64)
+	if err != nil {
+		return command.PrivateErrorResponse(command.Schedule.Name, err, sett)
+	}
+	creatorID, err := strconv.ParseUint(i.Member.User.ID, 10, 64)
+	if err != nil {
+		return command.PrivateErrorResponse(command.Schedule.Name, err, sett)
+	}
+
+	upcoming, err := storage.GetScheduledGames(bot.PostgresInterface.Pool, gid, command.MaxScheduledGames)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Schedule.Name, err, sett)
+	}
+	if len(upcoming) >= command.MaxScheduledGames {
+		return schedulePrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.schedule.create.tooMany",
+			Other: "You can only have {{.Max}} sessions scheduled at once. Cancel one with `/schedule cancel` first",
+		}, map[string]interface{}{
+			"Max": command.MaxScheduledGames,
+		}))
+	}
+
+	game := &storage.ScheduledGame{
+		GuildID:        gid,
+		VoiceChannelID: voiceID,
+		TextChannelID:  textID,
+		CreatorID:      creatorID,
+		StartTime:      int32(start.Unix()),
+	}
+	game.ScheduleID, err = storage.AddScheduledGame(bot.PostgresInterface.Pool, game)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Schedule.Name, err, sett)
+	}
+	// whoever schedules a session is assumed to be going
+	err = storage.SetScheduledGameRSVP(bot.PostgresInterface.Pool, game.ScheduleID, creatorID, true)
+	if err != nil {
+		log.Println(err)
+	}
+
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{scheduledGameEmbed(game, []uint64{creatorID}, sett)},
+			Components: scheduleRSVPComponents(game.ScheduleID, sett),
+		},
+	}
+}
+
+// handleScheduleRSVP adds or removes the user's RSVP when a button on a session's message is clicked, and updates the
+// message with the new list of attendees
+func (bot *Bot) handleScheduleRSVP(i *discordgo.InteractionCreate, customID string, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	attending := strings.HasPrefix(customID, scheduleRSVPPrefix)
+	scheduleID, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimPrefix(customID, scheduleRSVPPrefix), scheduleLeavePrefix), 10, 64)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	game, err := storage.GetScheduledGame(bot.PostgresInterface.Pool, scheduleID)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Schedule.Name, err, sett)
+	}
+	if game == nil || fmt.Sprintf("%d", game.GuildID) != i.GuildID || game.Started {
+		return schedulePrivateResponse(scheduleNotFoundMessage(scheduleID, sett))
+	}
+	userID, err := strconv.ParseUint(i.Member.User.ID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	err = storage.SetScheduledGameRSVP(bot.PostgresInterface.Pool, scheduleID, userID, attending)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Schedule.Name, err, sett)
+	}
+	rsvps, err := storage.GetScheduledGameRSVPs(bot.PostgresInterface.Pool, scheduleID)
+	if err != nil {
+		log.Println(err)
+	}
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    i.Message.Content,
+			Embeds:     []*discordgo.MessageEmbed{scheduledGameEmbed(game, rsvps, sett)},
+			Components: scheduleRSVPComponents(scheduleID, sett),
+		},
+	}
+}
+
+// scheduleWorker posts reminders and creates games for scheduled sessions. Every shard runs it, but each only handles
+// the guilds it's connected to, and a lock plus the reminded/started flags make sure nothing happens twice
+func (bot *Bot) scheduleWorker() {
+	for {
+		bot.processScheduledGames(time.Now())
+		time.Sleep(ScheduleWorkerInterval)
+	}
+}
+
+func (bot *Bot) processScheduledGames(now time.Time) {
+	// start games before posting reminders; starting a session also marks it as reminded
+	due, err := storage.GetDueScheduledGames(bot.PostgresInterface.Pool, int32(now.Unix()))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	for _, game := range due {
+		bot.withScheduleLock(game, func() {
+			started, err := storage.MarkScheduledGameStarted(bot.PostgresInterface.Pool, game.ScheduleID)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			if !started {
+				return
+			}
+			if now.Sub(time.Unix(int64(game.StartTime), 0)) > ScheduleStartGrace {
+				log.Printf("Dropping scheduled session %d for guild %d; it was due too long ago\n", game.ScheduleID, game.GuildID)
+				bot.postScheduleNotStarted(game, &i18n.Message{
+					ID:    "commands.schedule.start.missed",
+					Other: "Session #{{.ID}} was due <t:{{.Start}}:R>, but I wasn't able to start it in time. Please start the game with `/new` {{.Mentions}}",
+				})
+				return
+			}
+			bot.startScheduledGame(game)
+		})
+	}
+
+	reminders, err := storage.GetDueReminders(bot.PostgresInterface.Pool, int32(now.Add(ScheduleReminderLead).Unix()))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	for _, game := range reminders {
+		bot.withScheduleLock(game, func() {
+			reminded, err := storage.MarkScheduledGameReminded(bot.PostgresInterface.Pool, game.ScheduleID)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			if reminded {
+				bot.postScheduleReminder(game)
+			}
+		})
+	}
+}
+
+// withScheduleLock runs f for a session if this shard is connected to its guild, and no other shard is handling it
+func (bot *Bot) withScheduleLock(game *storage.ScheduledGame, f func()) {
+	if _, err := bot.PrimarySession.State.Guild(fmt.Sprintf("%d", game.GuildID)); err != nil {
+		return
+	}
+	lock := bot.RedisInterface.LockSchedule(game.ScheduleID)
+	if lock == nil {
+		return
+	}
+	defer lock.Release(ctx)
+	f()
+}
+
+func (bot *Bot) postScheduleReminder(game *storage.ScheduledGame) {
+	guildID := fmt.Sprintf("%d", game.GuildID)
+	sett := bot.StorageInterface.GetGuildSettings(guildID)
+	rsvps, err := storage.GetScheduledGameRSVPs(bot.PostgresInterface.Pool, game.ScheduleID)
+	if err != nil {
+		log.Println(err)
+	}
+	content := sett.LocalizeMessage(&i18n.Message{
+		ID:    "commands.schedule.reminder",
+		Other: "Session #{{.ID}} starts <t:{{.Start}}:R>! {{.Mentions}}",
+	}, map[string]interface{}{
+		"ID":       game.ScheduleID,
+		"Start":    game.StartTime,
+		"Mentions": mentionUserIDs(rsvps),
+	})
+	_, err = bot.PrimarySession.ChannelMessageSendComplex(fmt.Sprintf("%d", game.TextChannelID), &discordgo.MessageSend{
+		Content:    content,
+		Embeds:     []*discordgo.MessageEmbed{scheduledGameEmbed(game, rsvps, sett)},
+		Components: scheduleRSVPComponents(game.ScheduleID, sett),
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// postScheduleNotStarted tells the session's text channel, and everyone that RSVP'd, that a due session wasn't started.
+// The message is given the session ID, its start time and the RSVP mentions
+func (bot *Bot) postScheduleNotStarted(game *storage.ScheduledGame, msg *i18n.Message) {
+	guildID := fmt.Sprintf("%d", game.GuildID)
+	sett := bot.StorageInterface.GetGuildSettings(guildID)
+	rsvps, err := storage.GetScheduledGameRSVPs(bot.PostgresInterface.Pool, game.ScheduleID)
+	if err != nil {
+		log.Println(err)
+	}
+	_, err = bot.PrimarySession.ChannelMessageSend(fmt.Sprintf("%d", game.TextChannelID), sett.LocalizeMessage(msg,
+		map[string]interface{}{
+			"ID":       game.ScheduleID,
+			"Start":    game.StartTime,
+			"Mentions": mentionUserIDs(rsvps),
+		}))
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// startScheduledGame creates the game for a session the same way `/new` would, led by whoever scheduled it, with
+// everyone that RSVP'd already added to the game
+func (bot *Bot) startScheduledGame(game *storage.ScheduledGame) {
+	guildID := fmt.Sprintf("%d", game.GuildID)
+	voiceChannelID := fmt.Sprintf("%d", game.VoiceChannelID)
+	textChannelID := fmt.Sprintf("%d", game.TextChannelID)
+	leaderID := fmt.Sprintf("%d", game.CreatorID)
+
+	g, err := bot.PrimarySession.State.Guild(guildID)
+	if err != nil || g == nil {
+		return
+	}
+	sett := bot.StorageInterface.GetGuildSettings(guildID)
+	rsvps, err := storage.GetScheduledGameRSVPs(bot.PostgresInterface.Pool, game.ScheduleID)
+	if err != nil {
+		log.Println(err)
+	}
+
+	prem := bot.getLeaderPremiumTier(guildID, leaderID) != premium.FreeTier
+	gameSett := bot.settingsForChannel(guildID, voiceChannelID, sett, prem)
+
+	gsr := GameStateRequest{
+		GuildID:      guildID,
+		TextChannel:  textChannelID,
+		VoiceChannel: voiceChannelID,
+	}
+	lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLockRetries(gsr, 5)
+	if lock == nil {
+		log.Printf("No lock could be obtained when starting scheduled session %d for guild %s\n", game.ScheduleID, guildID)
+		bot.postScheduleNotStarted(game, &i18n.Message{
+			ID:    "commands.schedule.start.locked",
+			Other: "Session #{{.ID}} is due, but I couldn't get the game state for this channel. Please start the game with `/new` {{.Mentions}}",
+		})
+		return
+	}
+	if dgs.GameStateMsg.Exists() {
+		// there's already a game here; don't replace it like `/new` would
+		bot.RedisInterface.SetDiscordGameState(nil, lock)
+		_, err = bot.PrimarySession.ChannelMessageSend(textChannelID, sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.schedule.start.exists",
+			Other: "Session #{{.ID}} is due, but there's already a game running here, so I didn't start a new one",
+		}, map[string]interface{}{
+			"ID": game.ScheduleID,
+		}))
+		if err != nil {
+			log.Println(err)
+		}
+		return
+	}
+
+	log.Printf("Starting scheduled session %d for guild %s\n", game.ScheduleID, guildID)
+	status, info := bot.startGame(dgs, lock, g, textChannelID, voiceChannelID, leaderID, "", sett, gameSett)
+	if status == command.NewSuccess {
+		bot.addScheduledPlayers(g, info.ConnectCode, rsvps)
+	}
+	intro := sett.LocalizeMessage(&i18n.Message{
+		ID:    "commands.schedule.start.success",
+		Other: "Session #{{.ID}} is starting in <#{{.Channel}}>! {{.Mentions}}",
+	}, map[string]interface{}{
+		"ID":       game.ScheduleID,
+		"Channel":  voiceChannelID,
+		"Mentions": mentionUserIDs(rsvps),
+	})
+	bot.postNewGameMessage(textChannelID, intro, status, info, sett)
+}
+
+// addScheduledPlayers adds the users that RSVP'd to a session to its game, even if they haven't joined the voice
+// channel yet, so they can be linked right away
+func (bot *Bot) addScheduledPlayers(g *discordgo.Guild, connectCode string, userIDs []uint64) {
+	if len(userIDs) == 0 {
+		return
+	}
+	lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLockRetries(GameStateRequest{
+		GuildID:     g.ID,
+		ConnectCode: connectCode,
+	}, 5)
+	if lock == nil {
+		log.Printf("No lock could be obtained when adding scheduled players for game %s\n", connectCode)
+		return
+	}
+	for _, id := range userIDs {
+		userID := fmt.Sprintf("%d", id)
+		if _, err := dgs.GetUser(userID); err != nil {
+			dgs.checkCacheAndAddUser(g, bot.PrimarySession, userID)
+		}
+	}
+	bot.RedisInterface.SetDiscordGameState(dgs, lock)
+}
+
+func scheduledGameEmbed(game *storage.ScheduledGame, rsvps []uint64, sett *settings.GuildSettings) *discordgo.MessageEmbed {
+	attending := mentionUserIDs(rsvps)
+	if attending == "" {
+		attending = "-"
+	}
+	return &discordgo.MessageEmbed{
+		Title: sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.schedule.embed.title",
+			Other: "Game Session #{{.ID}}",
+		}, map[string]interface{}{
+			"ID": game.ScheduleID,
+		}),
+		Description: fmt.Sprintf("<t:%d:F> (<t:%d:R>)", game.StartTime, game.StartTime),
+		Color:       15844367, // GOLD
+		Fields: []*discordgo.MessageEmbedField{
+			{
+				Name: sett.LocalizeMessage(&i18n.Message{
+					ID:    "commands.schedule.embed.voice",
+					Other: "Voice",
+				}),
+				Value:  fmt.Sprintf("<#%d>", game.VoiceChannelID),
+				Inline: true,
+			},
+			{
+				Name: sett.LocalizeMessage(&i18n.Message{
+					ID:    "commands.schedule.embed.host",
+					Other: "Host",
+				}),
+				Value:  fmt.Sprintf("<@%d>", game.CreatorID),
+				Inline: true,
+			},
+			{
+				Name: sett.LocalizeMessage(&i18n.Message{
+					ID:    "commands.schedule.embed.attending",
+					Other: "Attending ({{.Count}})",
+				}, map[string]interface{}{
+					"Count": len(rsvps),
+				}),
+				Value:  attending,
+				Inline: false,
+			},
+		},
+	}
+}
+
+func scheduleRSVPComponents(scheduleID int64, sett *settings.GuildSettings) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					CustomID: fmt.Sprintf("%s%d", scheduleRSVPPrefix, scheduleID),
+					Style:    discordgo.SuccessButton,
+					Label: sett.LocalizeMessage(&i18n.Message{
+						ID:    "commands.schedule.button.rsvp",
+						Other: "I'm in",
+					}),
+				},
+				discordgo.Button{
+					CustomID: fmt.Sprintf("%s%d", scheduleLeavePrefix, scheduleID),
+					Style:    discordgo.SecondaryButton,
+					Label: sett.LocalizeMessage(&i18n.Message{
+						ID:    "commands.schedule.button.leave",
+						Other: "Can't make it",
+					}),
+				},
+			},
+		},
+	}
+}
+
+func scheduleNotFoundMessage(scheduleID int64, sett *settings.GuildSettings) string {
+	return sett.LocalizeMessage(&i18n.Message{
+		ID:    "commands.schedule.notFound",
+		Other: "Session #{{.ID}} doesn't exist or has already started. See `/schedule list`",
+	}, map[string]interface{}{
+		"ID": scheduleID,
+	})
+}
+
+func schedulePrivateResponse(content string) *discordgo.InteractionResponse {
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:   1 << 6, //private message
+			Content: content,
+		},
+	}
+}
+
+func mentionUserIDs(userIDs []uint64) string {
+	mentions := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		mentions = append(mentions, fmt.Sprintf("<@%d>", id))
+	}
+	return strings.Join(mentions, " ")
+}
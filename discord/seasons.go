@@ -0,0 +1,200 @@
+package discord
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/automuteus/automuteus/discord/command"
+	"github.com/automuteus/automuteus/storage"
+	"github.com/automuteus/utils/pkg/premium"
+	"github.com/automuteus/utils/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+const (
+	// SeasonWorkerInterval is how often each shard checks for seasons that ended
+	SeasonWorkerInterval = time.Minute
+
+	// MaxListedSeasons is how many of a guild's seasons `/stats season list` shows
+	MaxListedSeasons = 15
+)
+
+func (bot *Bot) HandleStatsSeasonCommand(guildID string, params command.SeasonParams, isAdmin bool, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Stats.Name, err, sett)
+	}
+	switch params.Action {
+	case command.SeasonCreate:
+		if !isAdmin {
+			return command.InsufficientPermissionsResponse(sett)
+		}
+		start, end, err := command.ParseSeason(params.Name, params.Start, params.End)
+		if err != nil {
+			return command.PrivateResponse(err.Error())
+		}
+		season := &storage.Season{
+			GuildID:   gid,
+			Name:      params.Name,
+			StartTime: int32(start.Unix()),
+			EndTime:   int32(end.Unix()),
+		}
+		season.SeasonID, err = storage.AddSeason(bot.PostgresInterface.Pool, season)
+		if err != nil {
+			log.Println(err)
+			return command.PrivateErrorResponse(command.Stats.Name, err, sett)
+		}
+		if season.SeasonID == 0 {
+			return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+				ID:    "commands.stats.season.create.overlap",
+				Other: "That season would overlap another season. Check `/stats season list`",
+			}))
+		}
+		return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.stats.season.create.success",
+			Other: "Created season #{{.ID}} **{{.Name}}**, from <t:{{.Start}}:F> to <t:{{.End}}:F>. Its final leaderboard will be posted in the match summary channel when it ends",
+		}, map[string]interface{}{
+			"ID":    season.SeasonID,
+			"Name":  season.Name,
+			"Start": season.StartTime,
+			"End":   season.EndTime,
+		}))
+
+	case command.SeasonDelete:
+		if !isAdmin {
+			return command.InsufficientPermissionsResponse(sett)
+		}
+		deleted, err := storage.DeleteSeason(bot.PostgresInterface.Pool, gid, params.ID)
+		if err != nil {
+			log.Println(err)
+			return command.PrivateErrorResponse(command.Stats.Name, err, sett)
+		}
+		if !deleted {
+			return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+				ID:    "commands.stats.season.notFound",
+				Other: "There's no season #{{.ID}}",
+			}, map[string]interface{}{
+				"ID": params.ID,
+			}))
+		}
+		return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.stats.season.delete.success",
+			Other: "Deleted season #{{.ID}}. Games played during it still count toward all-time stats",
+		}, map[string]interface{}{
+			"ID": params.ID,
+		}))
+	}
+
+	seasons, err := storage.GetSeasons(bot.PostgresInterface.Pool, gid, MaxListedSeasons)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Stats.Name, err, sett)
+	}
+	if len(seasons) == 0 {
+		return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.stats.season.list.none",
+			Other: "This server doesn't have any seasons. Admins can create one with `/stats season create`",
+		}))
+	}
+	lines := make([]string, 0, len(seasons))
+	for _, season := range seasons {
+		lines = append(lines, fmt.Sprintf("**#%d** %s · <t:%d:d> - <t:%d:d>", season.SeasonID, season.Name, season.StartTime, season.EndTime))
+	}
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: 1 << 6, //private message
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title: sett.LocalizeMessage(&i18n.Message{
+						ID:    "commands.stats.season.list.title",
+						Other: "Seasons",
+					}),
+					Description: strings.Join(lines, "\n"),
+					Color:       15844367, // GOLD
+				},
+			},
+		},
+	}
+}
+
+// seasonWorker posts the final leaderboard of seasons that ended. Like the scheduleWorker, every shard runs it, but each
+// only handles the guilds it's connected to
+func (bot *Bot) seasonWorker() {
+	for {
+		bot.processEndedSeasons(time.Now())
+		time.Sleep(SeasonWorkerInterval)
+	}
+}
+
+func (bot *Bot) processEndedSeasons(now time.Time) {
+	seasons, err := storage.GetEndedSeasons(bot.PostgresInterface.Pool, int32(now.Unix()))
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	for _, season := range seasons {
+		guildID := fmt.Sprintf("%d", season.GuildID)
+		if _, err := bot.PrimarySession.State.Guild(guildID); err != nil {
+			continue
+		}
+		lock := bot.RedisInterface.LockSeason(season.SeasonID)
+		if lock == nil {
+			continue
+		}
+		finished, err := storage.MarkSeasonFinished(bot.PostgresInterface.Pool, season.SeasonID)
+		if err != nil {
+			log.Println(err)
+		} else if finished {
+			bot.postSeasonLeaderboard(guildID, season)
+		}
+		lock.Release(ctx)
+	}
+}
+
+func (bot *Bot) postSeasonLeaderboard(guildID string, season *storage.Season) {
+	sett := bot.StorageInterface.GetGuildSettings(guildID)
+	channelID := sett.GetMatchSummaryChannelID()
+	if channelID == "" {
+		return
+	}
+	prem := true
+	tier, days, err := bot.PostgresInterface.GetGuildOrUserPremiumStatus(bot.official, bot.TopGGClient, guildID, "")
+	if err != nil {
+		log.Println(err)
+	}
+	if premium.IsExpired(tier, days) {
+		prem = false
+	}
+	embed := bot.GuildPeriodStatsEmbed(guildID, seasonStatsPeriod(season, sett), sett, prem)
+	if embed == nil {
+		return
+	}
+	// the embed only has the leaderboards with premium, so don't promise standings without it
+	content := sett.LocalizeMessage(&i18n.Message{
+		ID:    "commands.stats.season.ended",
+		Other: "Season **{{.Name}}** is over! Here are the final standings:",
+	}, map[string]interface{}{
+		"Name": season.Name,
+	})
+	if !prem {
+		content = sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.stats.season.endedNoPremium",
+			Other: "Season **{{.Name}}** is over! Here's how it went. The final standings are only available for AutoMuteUs Premium users; type `/premium` to learn more",
+		}, map[string]interface{}{
+			"Name": season.Name,
+		})
+	}
+	_, err = bot.PrimarySession.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content: content,
+		Embeds:  []*discordgo.MessageEmbed{embed},
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
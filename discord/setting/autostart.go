@@ -0,0 +1,64 @@
+package setting
+
+import (
+	"encoding/json"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	AutoStart       = "auto-start"
+	AutoStartSet    = "set"
+	AutoStartRemove = "remove"
+
+	VoiceChannel = "voice-channel"
+	TextChannel  = "text-channel"
+	Members      = "members"
+)
+
+var (
+	MinAutoStartMembers = float64(2)
+	MaxAutoStartMembers = float64(15)
+)
+
+// AutoStartConfig is how a voice channel starts games on its own; once Members people are in it, a game is created
+// in TextChannelID
+type AutoStartConfig struct {
+	TextChannelID string `json:"textChannelID"`
+	Members       int    `json:"members"`
+}
+
+// ParseAutoStartConfigs decodes the auto-start configs stored for a guild, keyed by voice channel ID. Configs that
+// can't be decoded are skipped
+func ParseAutoStartConfigs(data map[string]string) map[string]AutoStartConfig {
+	configs := make(map[string]AutoStartConfig, len(data))
+	for channelID, v := range data {
+		config := AutoStartConfig{}
+		if err := json.Unmarshal([]byte(v), &config); err == nil && config.IsValid() {
+			configs[channelID] = config
+		}
+	}
+	return configs
+}
+
+func (config AutoStartConfig) IsValid() bool {
+	return config.TextChannelID != "" &&
+		float64(config.Members) >= MinAutoStartMembers && float64(config.Members) <= MaxAutoStartMembers
+}
+
+// ShouldStart reports if a voice channel that has this many members after someone joined it should start a game on its
+// own. Only the join that fills the channel up to the threshold does, so a game ended with /end isn't started again
+// by the next person to join a channel that's still full
+func (config AutoStartConfig) ShouldStart(members int) bool {
+	return config.IsValid() && members == config.Members
+}
+
+func autoStartVoiceOption(required bool) *discordgo.ApplicationCommandOption {
+	return &discordgo.ApplicationCommandOption{
+		Type:         discordgo.ApplicationCommandOptionChannel,
+		Name:         VoiceChannel,
+		Description:  "Voice channel to watch",
+		ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+		Required:     required,
+	}
+}
@@ -0,0 +1,25 @@
+package setting
+
+import "testing"
+
+func TestAutoStartConfig(t *testing.T) {
+	configs := ParseAutoStartConfigs(map[string]string{
+		"1": `{"textChannelID":"10","members":4}`,
+		"2": `{"textChannelID":"","members":4}`,
+		"3": `{"textChannelID":"30","members":1}`,
+		"4": `not json`,
+	})
+	if len(configs) != 1 {
+		t.Fatalf("Expected only the valid config to be parsed, got %v", configs)
+	}
+	config := configs["1"]
+	if config.ShouldStart(3) {
+		t.Error("Expected no start below the member threshold")
+	}
+	if !config.ShouldStart(4) {
+		t.Error("Expected a start when the member threshold is reached")
+	}
+	if config.ShouldStart(5) {
+		t.Error("Expected no start when someone joins a channel that's already over the threshold")
+	}
+}
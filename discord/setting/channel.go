@@ -0,0 +1,110 @@
+package setting
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/automuteus/utils/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+)
+
+// ChannelSettings are the settings that can be overridden for a single voice channel, plus the commands to view and
+// reset those overrides
+var ChannelSettings = map[string]bool{
+	VoiceRules:     true,
+	Delays:         true,
+	UnmuteDead:     true,
+	MuteSpectators: true,
+	Show:           true,
+	Reset:          true,
+}
+
+var channelOption = &discordgo.ApplicationCommandOption{
+	Type:         discordgo.ApplicationCommandOptionChannel,
+	Name:         Channel,
+	Description:  "Voice channel to use instead of the server defaults",
+	ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+}
+
+// ApplyChannelOverrides layers the overrides for a voice channel on top of a copy of the guild settings. Settings
+// that aren't overridden keep the guild's value
+func ApplyChannelOverrides(data []byte, sett *settings.GuildSettings, prem bool) (*settings.GuildSettings, error) {
+	if len(data) == 0 {
+		return CopyGuildSettings(sett)
+	}
+	overridden, _, err := ImportSettings(data, sett, prem)
+	return overridden, err
+}
+
+// MergeChannelOverrides computes the overrides to store for a channel after one of its settings was changed. Any
+// setting that was already overridden stays overridden (even if it now matches the guild), as does any setting where
+// the channel differs from the guild. It returns nil if nothing is overridden
+func MergeChannelOverrides(existing []byte, guild, channel *settings.GuildSettings) ([]byte, error) {
+	keys, err := OverriddenSettings(existing)
+	if err != nil {
+		return nil, err
+	}
+	guildFlat, err := exportedFields(guild)
+	if err != nil {
+		return nil, err
+	}
+	channelFlat, err := exportedFields(channel)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[string]json.RawMessage)
+	for _, k := range keys {
+		overrides[k] = channelFlat[k]
+	}
+	for k, v := range channelFlat {
+		if !bytes.Equal(v, guildFlat[k]) {
+			overrides[k] = v
+		}
+	}
+	if len(overrides) == 0 {
+		return nil, nil
+	}
+	settBytes, err := json.Marshal(overrides)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(SettingsExport{
+		Version:  SettingsExportVersion,
+		Settings: settBytes,
+	})
+}
+
+// OverriddenSettings returns the sorted names of the settings present in a channel's overrides
+func OverriddenSettings(data []byte) ([]string, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	export := SettingsExport{}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(export.Settings, &fields); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// exportedFields returns the JSON of each top-level exported setting. Maps are marshalled with sorted keys, so the
+// values can be compared byte for byte
+func exportedFields(sett *settings.GuildSettings) (map[string]json.RawMessage, error) {
+	jBytes, err := json.Marshal(toExported(sett))
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]json.RawMessage)
+	err = json.Unmarshal(jBytes, &fields)
+	return fields, err
+}
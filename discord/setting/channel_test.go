@@ -0,0 +1,63 @@
+package setting
+
+import (
+	"testing"
+
+	"github.com/automuteus/utils/pkg/game"
+	"github.com/automuteus/utils/pkg/settings"
+)
+
+func TestChannelOverrides(t *testing.T) {
+	guild := settings.MakeGuildSettings()
+
+	channel, err := ApplyChannelOverrides(nil, guild, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if channel == guild {
+		t.Fatal("Expected a copy of the guild settings")
+	}
+	channel.SetDelay(game.LOBBY, game.TASKS, 2)
+
+	overrides, err := MergeChannelOverrides(nil, guild, channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := OverriddenSettings(overrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0] != "delays" {
+		t.Fatalf("Expected only delays to be overridden, got %v", keys)
+	}
+
+	// guild changes show through for anything the channel doesn't override
+	guild.SetUnmuteDeadDuringTasks(true)
+	guild.SetDelay(game.LOBBY, game.TASKS, 7)
+	channel, err = ApplyChannelOverrides(overrides, guild, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !channel.GetUnmuteDeadDuringTasks() {
+		t.Error("Expected the channel to follow the guild's unmute-dead setting")
+	}
+	if channel.GetDelay(game.LOBBY, game.TASKS) != 2 {
+		t.Error("Expected the channel to keep its own delay")
+	}
+
+	// an override that's changed back to match the guild stays overridden
+	channel.SetDelay(game.LOBBY, game.TASKS, 7)
+	overrides, err = MergeChannelOverrides(overrides, guild, channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, _ = OverriddenSettings(overrides)
+	if len(keys) != 1 || keys[0] != "delays" {
+		t.Errorf("Expected delays to stay overridden, got %v", keys)
+	}
+
+	overrides, err = MergeChannelOverrides(nil, guild, guild)
+	if err != nil || overrides != nil {
+		t.Errorf("Expected no overrides when the channel matches the guild, got %s", overrides)
+	}
+}
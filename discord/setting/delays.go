@@ -1,47 +1,179 @@
 package setting
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/automuteus/utils/pkg/game"
 	"github.com/automuteus/utils/pkg/settings"
+	"github.com/bwmarrin/discordgo"
 	"github.com/nicksnyder/go-i18n/v2/i18n"
-	"strconv"
 )
 
+const (
+	// GameOverPhaseName is missing from game.PhaseNames, but games still transition through it
+	GameOverPhaseName game.PhaseNameString = "GAMEOVER"
+
+	// KilledDelayName and ExiledDelayName aren't phases; they're the delay before applying the voice state of a
+	// player who was killed or exiled, while in the origin phase
+	KilledDelayName game.PhaseNameString = "KILLED"
+	ExiledDelayName game.PhaseNameString = "EXILED"
+)
+
+// DelayOrigins are the phases that delays can be set from, in the order they're displayed
+var DelayOrigins = []game.PhaseNameString{
+	game.PhaseNames[game.LOBBY], game.PhaseNames[game.TASKS], game.PhaseNames[game.DISCUSS], game.PhaseNames[game.MENU],
+	GameOverPhaseName,
+}
+
+// DelayDestinations are the phases (and player reveals) that delays can be set to, in the order they're displayed
+var DelayDestinations = append(append([]game.PhaseNameString{}, DelayOrigins...), KilledDelayName, ExiledDelayName)
+
+func delayChoices(names []game.PhaseNameString) []*discordgo.ApplicationCommandOptionChoice {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(names))
+	for _, v := range names {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  string(v),
+			Value: string(v),
+		})
+	}
+	return choices
+}
+
+// PhaseName is like game.Phase.ToString, but also names the GAMEOVER phase
+func PhaseName(phase game.Phase) game.PhaseNameString {
+	if phase == game.GAMEOVER {
+		return GameOverPhaseName
+	}
+	return game.PhaseNames[phase]
+}
+
+// GetDelay returns the delay in seconds for a transition between two phases. Transitions to GAMEOVER that haven't
+// been set use the delay to LOBBY, because that's how a game over was always treated
+func GetDelay(sett *settings.GuildSettings, origin, dest game.Phase) int {
+	if delay, ok := lookupDelay(sett, PhaseName(origin), PhaseName(dest)); ok {
+		return delay
+	}
+	if dest == game.GAMEOVER {
+		delay, _ := lookupDelay(sett, PhaseName(origin), game.PhaseNames[game.LOBBY])
+		return delay
+	}
+	return 0
+}
+
+// GetRevealDelay returns the delay in seconds before applying the voice state of a player who was killed or exiled
+// during the provided phase
+func GetRevealDelay(sett *settings.GuildSettings, phase game.Phase, action game.PlayerAction) int {
+	var dest game.PhaseNameString
+	switch action {
+	case game.DIED:
+		dest = KilledDelayName
+	case game.EXILED:
+		dest = ExiledDelayName
+	default:
+		return 0
+	}
+	delay, _ := lookupDelay(sett, PhaseName(phase), dest)
+	return delay
+}
+
+func lookupDelay(sett *settings.GuildSettings, origin, dest game.PhaseNameString) (int, bool) {
+	if origin == "" || dest == "" {
+		return 0, false
+	}
+	delay, ok := sett.Delays.Delays[origin][dest]
+	return delay, ok
+}
+
+func setDelay(sett *settings.GuildSettings, origin, dest game.PhaseNameString, delay int) {
+	if sett.Delays.Delays == nil {
+		sett.Delays.Delays = make(map[game.PhaseNameString]map[game.PhaseNameString]int)
+	}
+	if sett.Delays.Delays[origin] == nil {
+		sett.Delays.Delays[origin] = make(map[game.PhaseNameString]int)
+	}
+	sett.Delays.Delays[origin][dest] = delay
+}
+
+// parseDelayName accepts the same phase names as game.GetPhaseFromString, plus the phases and reveals it doesn't know
+func parseDelayName(input string, names []game.PhaseNameString) game.PhaseNameString {
+	if phase := game.GetPhaseFromString(input); phase != game.UNINITIALIZED {
+		return game.PhaseNames[phase]
+	}
+	for _, v := range names {
+		if strings.EqualFold(input, string(v)) {
+			return v
+		}
+	}
+	switch strings.ToLower(input) {
+	case "m":
+		return game.PhaseNames[game.MENU]
+	case "gameover", "game-over", "over":
+		return GameOverPhaseName
+	}
+	return ""
+}
+
+// DelaysTable formats every delay as a table, with a row for each origin phase and a column for each destination
+func DelaysTable(sett *settings.GuildSettings) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%-11s", ""))
+	for _, dest := range DelayDestinations {
+		sb.WriteString(fmt.Sprintf("%11s", dest))
+	}
+	for _, origin := range DelayOrigins {
+		sb.WriteString(fmt.Sprintf("\n%-11s", origin))
+		for _, dest := range DelayDestinations {
+			delay, ok := lookupDelay(sett, origin, dest)
+			if !ok && dest == GameOverPhaseName {
+				delay, _ = lookupDelay(sett, origin, game.PhaseNames[game.LOBBY])
+			}
+			sb.WriteString(fmt.Sprintf("%11d", delay))
+		}
+	}
+	return sb.String()
+}
+
 func FnDelays(sett *settings.GuildSettings, args []string) (interface{}, bool) {
 	if sett == nil {
 		return nil, false
 	}
 	// User passes phase name, phase name and new delay value
 	if len(args) < 2 {
-		// User didn't pass 2 phases, tell them the list of game phases
+		// User didn't pass 2 phases, show them every delay
 		return sett.LocalizeMessage(&i18n.Message{
-			ID: "settings.SettingDelays.missingPhases",
-			Other: "The list of game phases are `Lobby`, `Tasks` and `Discussion`.\n" +
-				"You need to type both phases the game is transitioning from and to to change the delay.",
-		}), false // find a better wording for this at some point
+			ID: "settings.SettingDelays.table",
+			Other: "These are the delays (in seconds) when passing from each phase (rows) to the next (columns). " +
+				"`KILLED` and `EXILED` are the delays before a player who died in that phase is muted or unmuted.\n" +
+				"```\n{{.Table}}\n```\nType both phases the game is transitioning from and to to change a delay.",
+		},
+			map[string]interface{}{
+				"Table": DelaysTable(sett),
+			}), false
 	}
 	// now to find the actual game state from the string they passed
-	var gamePhase1 = game.GetPhaseFromString(args[0])
-	var gamePhase2 = game.GetPhaseFromString(args[1])
-	if gamePhase1 == game.UNINITIALIZED {
+	var gamePhase1 = parseDelayName(args[0], DelayOrigins)
+	var gamePhase2 = parseDelayName(args[1], DelayDestinations)
+	if gamePhase1 == "" {
 		return sett.LocalizeMessage(&i18n.Message{
 			ID:    "settings.SettingDelays.Phase.UNINITIALIZED",
-			Other: "I don't know what `{{.PhaseName}}` is. The list of game phases are `Lobby`, `Tasks` and `Discussion`.",
+			Other: "I don't know what `{{.PhaseName}}` is. The list of game phases are `Lobby`, `Tasks`, `Discussion`, `Menu` and `Gameover`.",
 		},
 			map[string]interface{}{
 				"PhaseName": args[0],
 			}), false
-	} else if gamePhase2 == game.UNINITIALIZED {
+	} else if gamePhase2 == "" {
 		return sett.LocalizeMessage(&i18n.Message{
 			ID:    "settings.SettingDelays.Phase.UNINITIALIZED",
-			Other: "I don't know what `{{.PhaseName}}` is. The list of game phases are `Lobby`, `Tasks` and `Discussion`.",
+			Other: "I don't know what `{{.PhaseName}}` is. The list of game phases are `Lobby`, `Tasks`, `Discussion`, `Menu` and `Gameover`.",
 		},
 			map[string]interface{}{
 				"PhaseName": args[1],
 			}), false
 	}
 
-	oldDelay := sett.GetDelay(gamePhase1, gamePhase2)
+	oldDelay, _ := lookupDelay(sett, gamePhase1, gamePhase2)
 	if len(args) == 2 {
 		// no number was passed, User was querying the delay
 		return sett.LocalizeMessage(&i18n.Message{
@@ -66,7 +198,7 @@ func FnDelays(sett *settings.GuildSettings, args []string) (interface{}, bool) {
 			}), false
 	}
 
-	sett.SetDelay(gamePhase1, gamePhase2, newDelay)
+	setDelay(sett, gamePhase1, gamePhase2, newDelay)
 	return sett.LocalizeMessage(&i18n.Message{
 		ID:    "settings.SettingDelays.setDelayBetweenPhases",
 		Other: "The delay when passing from `{{.PhaseA}}` to `{{.PhaseB}}` changed from {{.OldDelay}} to {{.NewDelay}}.",
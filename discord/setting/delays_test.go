@@ -2,6 +2,8 @@ package setting
 
 import (
 	"github.com/automuteus/utils/pkg/game"
+	"github.com/automuteus/utils/pkg/settings"
+	"strings"
 	"testing"
 )
 
@@ -34,3 +36,39 @@ func TestFnDelays(t *testing.T) {
 		t.Error("Delay was not set properly")
 	}
 }
+
+func TestFnDelaysExtendedPhases(t *testing.T) {
+	sett := settings.MakeGuildSettings()
+
+	// game over uses the lobby delay until it's set itself
+	if GetDelay(sett, game.DISCUSS, game.GAMEOVER) != GetDelay(sett, game.DISCUSS, game.LOBBY) {
+		t.Error("Expected the GAMEOVER delay to default to the LOBBY delay")
+	}
+	_, valid := FnDelays(sett, []string{"discussion", "gameover", "3"})
+	if !valid {
+		t.Error("Sending valid args should result in valid settings change")
+	}
+	if GetDelay(sett, game.DISCUSS, game.GAMEOVER) != 3 || GetDelay(sett, game.DISCUSS, game.LOBBY) == 3 {
+		t.Error("GAMEOVER delay was not set properly")
+	}
+
+	_, valid = FnDelays(sett, []string{"tasks", "menu", "2"})
+	if !valid || GetDelay(sett, game.TASKS, game.MENU) != 2 {
+		t.Error("MENU delay was not set properly")
+	}
+
+	_, valid = FnDelays(sett, []string{"tasks", "killed", "4"})
+	if !valid || GetRevealDelay(sett, game.TASKS, game.DIED) != 4 || GetRevealDelay(sett, game.TASKS, game.EXILED) != 0 {
+		t.Error("KILLED delay was not set properly")
+	}
+
+	_, valid = FnDelays(sett, []string{"killed", "tasks", "4"})
+	if valid {
+		t.Error("KILLED should only be valid as the end phase")
+	}
+
+	msg, _ := FnDelays(sett, []string{})
+	if !strings.Contains(msg.(string), string(ExiledDelayName)) {
+		t.Error("Expected the delays table to include every column")
+	}
+}
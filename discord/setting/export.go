@@ -0,0 +1,356 @@
+package setting
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/automuteus/utils/pkg/game"
+	"github.com/automuteus/utils/pkg/locale"
+	"github.com/automuteus/utils/pkg/settings"
+)
+
+const (
+	// SettingsExportVersion is the current version of the export format. Files without a version are treated as
+	// version 0; the raw settings JSON shown by `/settings show`
+	SettingsExportVersion = 1
+	SettingsSchemaURL     = "https://github.com/automuteus/automuteus/blob/master/discord/setting/settings.schema.json?raw=true"
+
+	// MaxSettingsImportBytes is far larger than any valid export, but keeps us from reading arbitrarily large files
+	MaxSettingsImportBytes = 64 * 1024
+)
+
+//go:embed settings.schema.json
+var SettingsSchema []byte
+
+// SettingsExport is the versioned envelope for exported settings
+type SettingsExport struct {
+	Schema   string          `json:"$schema,omitempty"`
+	Version  int             `json:"version"`
+	Settings json.RawMessage `json:"settings"`
+}
+
+// exportedSettings are the settings that can be shared between guilds. Anything specific to one guild (admins,
+// operator roles, the match summary channel) is left out of exports, and kept as-is on import
+type exportedSettings struct {
+	Language                 string          `json:"language"`
+	VoiceRules               game.VoiceRules `json:"voiceRules"`
+	MapVersion               string          `json:"mapVersion"`
+	Delays                   game.GameDelays `json:"delays"`
+	DeleteGameSummaryMinutes int             `json:"deleteGameSummary"`
+	UnmuteDeadDuringTasks    bool            `json:"unmuteDeadDuringTasks"`
+	AutoRefresh              bool            `json:"autoRefresh"`
+	LeaderboardMention       bool            `json:"leaderboardMention"`
+	LeaderboardSize          int             `json:"leaderboardSize"`
+	LeaderboardMin           int             `json:"leaderboardMin"`
+	MuteSpectator            bool            `json:"muteSpectator"`
+	DisplayRoomCode          string          `json:"displayRoomCode"`
+}
+
+// premiumExportKeys are the exported settings that are only available to premium guilds
+var premiumExportKeys = []string{
+	"deleteGameSummary", "autoRefresh", "leaderboardMention", "leaderboardSize", "leaderboardMin", "muteSpectator",
+	"displayRoomCode",
+}
+
+// settingsMigrations upgrade the settings JSON of version i to version i+1
+var settingsMigrations = []func(json.RawMessage) (json.RawMessage, error){
+	migrateSettingsV0,
+}
+
+func ExportSettings(sett *settings.GuildSettings) ([]byte, error) {
+	settBytes, err := json.Marshal(toExported(sett))
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(SettingsExport{
+		Schema:   SettingsSchemaURL,
+		Version:  SettingsExportVersion,
+		Settings: settBytes,
+	}, "", "  ")
+}
+
+// ImportSettings parses an exported settings file, migrating it to the current version if necessary, and applies it
+// on top of a copy of the current settings. Premium settings are only applied if prem is true; otherwise the names of
+// any that would have changed are returned as skipped
+func ImportSettings(data []byte, current *settings.GuildSettings, prem bool) (*settings.GuildSettings, []string, error) {
+	if len(data) > MaxSettingsImportBytes {
+		return nil, nil, errors.New("the settings file is too large")
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, nil, fmt.Errorf("the settings file isn't valid JSON: %w", err)
+	}
+
+	version := 0
+	settBytes := json.RawMessage(data)
+	if v, ok := raw["version"]; ok {
+		export := SettingsExport{}
+		if err := json.Unmarshal(data, &export); err != nil {
+			return nil, nil, fmt.Errorf("the settings file doesn't match the schema: %w", err)
+		}
+		if export.Version > SettingsExportVersion {
+			return nil, nil, fmt.Errorf("the settings file is version %s, but I only understand up to version %d",
+				v, SettingsExportVersion)
+		}
+		if export.Version < 1 || len(export.Settings) == 0 {
+			return nil, nil, errors.New("the settings file has an invalid version or no settings")
+		}
+		version = export.Version
+		settBytes = export.Settings
+	}
+	for ; version < SettingsExportVersion; version++ {
+		var err error
+		settBytes, err = settingsMigrations[version](settBytes)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// start from the current settings, so any settings missing from the file are left unchanged
+	imported, err := copyExported(toExported(current))
+	if err != nil {
+		return nil, nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(settBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&imported); err != nil {
+		return nil, nil, fmt.Errorf("the settings file doesn't match the schema: %w", err)
+	}
+	if err := validateExported(imported); err != nil {
+		return nil, nil, err
+	}
+
+	var skipped []string
+	if !prem {
+		skipped = revertPremium(&imported, toExported(current))
+	}
+
+	result, err := CopyGuildSettings(current)
+	if err != nil {
+		return nil, nil, err
+	}
+	fromExported(result, imported)
+	return result, skipped, nil
+}
+
+// DiffSettings lists the settings that differ between two settings objects, as "key: old → new" lines sorted by key
+func DiffSettings(before, after *settings.GuildSettings) []string {
+	return diffFlattened(flattenSettings(before), flattenSettings(after))
+}
+
+func diffFlattened(beforeFlat, afterFlat map[string]interface{}) []string {
+	var diffs []string
+	for k, v := range afterFlat {
+		if old, ok := beforeFlat[k]; !ok || !reflect.DeepEqual(old, v) {
+			diffs = append(diffs, fmt.Sprintf("%s: %s → %s", k, jsonString(beforeFlat[k]), jsonString(v)))
+		}
+	}
+	for k, v := range beforeFlat {
+		if _, ok := afterFlat[k]; !ok {
+			diffs = append(diffs, fmt.Sprintf("%s: %s → null", k, jsonString(v)))
+		}
+	}
+	sort.Strings(diffs)
+	return diffs
+}
+
+// migrateSettingsV0 converts the raw guild settings JSON into the version 1 format by dropping guild-specific fields
+func migrateSettingsV0(data json.RawMessage) (json.RawMessage, error) {
+	legacy := settings.MakeGuildSettings()
+	if err := json.Unmarshal(data, legacy); err != nil {
+		return nil, fmt.Errorf("the settings file doesn't match the schema: %w", err)
+	}
+	return json.Marshal(toExported(legacy))
+}
+
+func toExported(sett *settings.GuildSettings) exportedSettings {
+	mapVersion := "simple"
+	if sett.GetMapDetailed() {
+		mapVersion = "detailed"
+	}
+	return exportedSettings{
+		Language:                 sett.Language,
+		VoiceRules:               sett.VoiceRules,
+		MapVersion:               mapVersion,
+		Delays:                   sett.Delays,
+		DeleteGameSummaryMinutes: sett.DeleteGameSummaryMinutes,
+		UnmuteDeadDuringTasks:    sett.UnmuteDeadDuringTasks,
+		AutoRefresh:              sett.AutoRefresh,
+		LeaderboardMention:       sett.LeaderboardMention,
+		LeaderboardSize:          sett.GetLeaderboardSize(),
+		LeaderboardMin:           sett.GetLeaderboardMin(),
+		MuteSpectator:            sett.MuteSpectator,
+		DisplayRoomCode:          sett.GetDisplayRoomCode(),
+	}
+}
+
+func fromExported(sett *settings.GuildSettings, e exportedSettings) {
+	sett.Language = e.Language
+	sett.VoiceRules = e.VoiceRules
+	sett.MapVersion = e.MapVersion
+	sett.Delays = e.Delays
+	sett.DeleteGameSummaryMinutes = e.DeleteGameSummaryMinutes
+	sett.UnmuteDeadDuringTasks = e.UnmuteDeadDuringTasks
+	sett.AutoRefresh = e.AutoRefresh
+	sett.LeaderboardMention = e.LeaderboardMention
+	sett.LeaderboardSize = e.LeaderboardSize
+	sett.LeaderboardMin = e.LeaderboardMin
+	sett.MuteSpectator = e.MuteSpectator
+	sett.DisplayRoomCode = e.DisplayRoomCode
+}
+
+func revertPremium(imported *exportedSettings, current exportedSettings) []string {
+	importedFlat := flattenJSON(imported)
+	currentFlat := flattenJSON(current)
+	var skipped []string
+	for _, k := range premiumExportKeys {
+		if !reflect.DeepEqual(importedFlat[k], currentFlat[k]) {
+			skipped = append(skipped, k)
+		}
+	}
+	imported.DeleteGameSummaryMinutes = current.DeleteGameSummaryMinutes
+	imported.AutoRefresh = current.AutoRefresh
+	imported.LeaderboardMention = current.LeaderboardMention
+	imported.LeaderboardSize = current.LeaderboardSize
+	imported.LeaderboardMin = current.LeaderboardMin
+	imported.MuteSpectator = current.MuteSpectator
+	imported.DisplayRoomCode = current.DisplayRoomCode
+	return skipped
+}
+
+func validateExported(e exportedSettings) error {
+	if langs := locale.GetLanguages(); len(langs) > 0 {
+		if _, ok := langs[e.Language]; !ok {
+			return fmt.Errorf("unknown language `%s`", e.Language)
+		}
+	}
+	if e.MapVersion != "simple" && e.MapVersion != "detailed" {
+		return fmt.Errorf("mapVersion must be `simple` or `detailed`, not `%s`", e.MapVersion)
+	}
+	if e.DisplayRoomCode != "always" && e.DisplayRoomCode != "spoiler" && e.DisplayRoomCode != "never" {
+		return fmt.Errorf("displayRoomCode must be `always`, `spoiler` or `never`, not `%s`", e.DisplayRoomCode)
+	}
+	if float64(e.DeleteGameSummaryMinutes) < MinMatchSummaryDelete || float64(e.DeleteGameSummaryMinutes) > MaxMatchSummaryDelete {
+		return fmt.Errorf("deleteGameSummary must be between %d and %d", int(MinMatchSummaryDelete), int(MaxMatchSummaryDelete))
+	}
+	if float64(e.LeaderboardSize) < MinLeaderBoardSize || float64(e.LeaderboardSize) > MaxLeaderBoardSize {
+		return fmt.Errorf("leaderboardSize must be between %d and %d", int(MinLeaderBoardSize), int(MaxLeaderBoardSize))
+	}
+	if float64(e.LeaderboardMin) < MinLeaderBoardMin || float64(e.LeaderboardMin) > MaxLeaderBoardMin {
+		return fmt.Errorf("leaderboardMin must be between %d and %d", int(MinLeaderBoardMin), int(MaxLeaderBoardMin))
+	}
+	for start, ends := range e.Delays.Delays {
+		if !isDelayName(start, DelayOrigins) {
+			return fmt.Errorf("unknown phase `%s` in delays", start)
+		}
+		for end, v := range ends {
+			if !isDelayName(end, DelayDestinations) {
+				return fmt.Errorf("unknown phase `%s` in delays", end)
+			}
+			if float64(v) < MinDelay || v > MaxDelay {
+				return fmt.Errorf("delay from %s to %s must be between %d and %d", start, end, int(MinDelay), MaxDelay)
+			}
+		}
+	}
+	for _, rules := range []map[game.PhaseNameString]map[string]bool{e.VoiceRules.MuteRules, e.VoiceRules.DeafRules} {
+		for phase, states := range rules {
+			if !isPhaseName(string(phase)) {
+				return fmt.Errorf("unknown phase `%s` in voiceRules", phase)
+			}
+			for state := range states {
+				if state != "alive" && state != "dead" {
+					return fmt.Errorf("voiceRules states must be `alive` or `dead`, not `%s`", state)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func isDelayName(name game.PhaseNameString, names []game.PhaseNameString) bool {
+	for _, v := range names {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isPhaseName(name string) bool {
+	for _, v := range game.PhaseNames {
+		if string(v) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func copyExported(e exportedSettings) (exportedSettings, error) {
+	c := exportedSettings{}
+	jBytes, err := json.Marshal(e)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(jBytes, &c)
+	return c, err
+}
+
+// CopyGuildSettings returns a deep copy of the settings, so changes to the copy don't affect the original
+func CopyGuildSettings(sett *settings.GuildSettings) (*settings.GuildSettings, error) {
+	c := settings.GuildSettings{}
+	jBytes, err := json.Marshal(sett)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(jBytes, &c)
+	return &c, err
+}
+
+func flattenSettings(sett *settings.GuildSettings) map[string]interface{} {
+	return flattenJSON(sett)
+}
+
+// flattenJSON converts any JSON-serializable value into a map of dotted key paths to leaf values
+func flattenJSON(v interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+	jBytes, err := json.Marshal(v)
+	if err != nil {
+		return flat
+	}
+	var generic interface{}
+	if err := json.Unmarshal(jBytes, &generic); err != nil {
+		return flat
+	}
+	flatten("", generic, flat)
+	return flat
+}
+
+func flatten(prefix string, v interface{}, flat map[string]interface{}) {
+	if m, ok := v.(map[string]interface{}); ok && len(m) > 0 {
+		for k, child := range m {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			flatten(key, child, flat)
+		}
+		return
+	}
+	flat[prefix] = v
+}
+
+func jsonString(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+	jBytes, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(jBytes)
+}
@@ -0,0 +1,95 @@
+package setting
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/automuteus/utils/pkg/game"
+	"github.com/automuteus/utils/pkg/settings"
+)
+
+func TestExportImportSettings(t *testing.T) {
+	sett := settings.MakeGuildSettings()
+	sett.SetDelay(game.LOBBY, game.TASKS, 3)
+	sett.SetMuteSpectator(true)
+	sett.SetAdminUserIDs([]string{"1234"})
+
+	data, err := ExportSettings(sett)
+	if err != nil {
+		t.Fatal(err)
+	}
+	export := SettingsExport{}
+	if err := json.Unmarshal(data, &export); err != nil {
+		t.Fatal(err)
+	}
+	if export.Version != SettingsExportVersion {
+		t.Errorf("Expected export version %d, got %d", SettingsExportVersion, export.Version)
+	}
+
+	other := settings.MakeGuildSettings()
+	other.SetAdminUserIDs([]string{"5678"})
+	imported, skipped, err := ImportSettings(data, other, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) > 0 {
+		t.Error("Premium imports shouldn't skip any settings")
+	}
+	if imported.GetDelay(game.LOBBY, game.TASKS) != 3 || !imported.GetMuteSpectator() {
+		t.Error("Imported settings weren't applied")
+	}
+	if len(imported.AdminUserIDs) != 1 || imported.AdminUserIDs[0] != "5678" {
+		t.Error("Guild-specific settings should never be overwritten by an import")
+	}
+	if other.GetDelay(game.LOBBY, game.TASKS) == 3 {
+		t.Error("Importing should never modify the current settings")
+	}
+
+	imported, skipped, err = ImportSettings(data, other, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.GetMuteSpectator() || len(skipped) != 1 || skipped[0] != "muteSpectator" {
+		t.Error("Premium settings should be skipped for non-premium guilds")
+	}
+
+	diffs := DiffSettings(other, imported)
+	if len(diffs) != 1 || diffs[0] != "delays.delays.LOBBY.TASKS: 7 → 3" {
+		t.Errorf("Unexpected diff: %v", diffs)
+	}
+}
+
+func TestImportSettingsMigration(t *testing.T) {
+	// version 0 is the raw settings JSON, as shown by /settings show
+	legacy := settings.MakeGuildSettings()
+	legacy.SetLeaderboardSize(5)
+	data, err := json.Marshal(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, _, err := ImportSettings(data, settings.MakeGuildSettings(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.GetLeaderboardSize() != 5 {
+		t.Error("Legacy settings weren't migrated")
+	}
+}
+
+func TestImportSettingsInvalid(t *testing.T) {
+	current := settings.MakeGuildSettings()
+	invalid := []string{
+		`not json`,
+		`{"version": 99, "settings": {}}`,
+		`{"version": 1, "settings": {"unknownSetting": true}}`,
+		`{"version": 1, "settings": {"leaderboardSize": 500}}`,
+		`{"version": 1, "settings": {"delays": {"delays": {"LOBBY": {"TASKS": 11}}}}}`,
+		`{"version": 1, "settings": {"displayRoomCode": "sometimes"}}`,
+	}
+	for _, v := range invalid {
+		_, _, err := ImportSettings([]byte(v), current, true)
+		if err == nil {
+			t.Errorf("Expected an error importing %s", v)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package setting
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/automuteus/utils/pkg/settings"
+)
+
+const (
+	History  = "history"
+	Rollback = "rollback"
+
+	// HistorySize is how many changes `/settings history` lists
+	HistorySize = 10
+)
+
+// ChangedSettings returns JSON objects holding the old and new values of every setting that differs between before
+// and after, keyed by the dotted path to the changed value (e.g. "voiceRules.MuteRules.TASKS.alive"), so a single voice
+// rule or delay can be reverted without touching the rest. A nil value means the path didn't exist on that side. Both
+// are nil if nothing changed
+func ChangedSettings(before, after *settings.GuildSettings) ([]byte, []byte, error) {
+	beforeFlat := flattenJSON(before)
+	afterFlat := flattenJSON(after)
+
+	oldValues := make(map[string]interface{})
+	newValues := make(map[string]interface{})
+	for k, v := range afterFlat {
+		if old, ok := beforeFlat[k]; !ok || !reflect.DeepEqual(old, v) {
+			oldValues[k] = old
+			newValues[k] = v
+		}
+	}
+	for k, v := range beforeFlat {
+		if _, ok := afterFlat[k]; !ok {
+			oldValues[k] = v
+			newValues[k] = nil
+		}
+	}
+	if len(newValues) == 0 {
+		return nil, nil, nil
+	}
+	oldBytes, err := json.Marshal(oldValues)
+	if err != nil {
+		return nil, nil, err
+	}
+	newBytes, err := json.Marshal(newValues)
+	return oldBytes, newBytes, err
+}
+
+// RevertSettings returns a copy of the current settings with the old values from a recorded change put back. Only the
+// recorded paths are touched, so later changes elsewhere in the same map (such as other voice rules) are kept
+func RevertSettings(current *settings.GuildSettings, oldValues []byte) (*settings.GuildSettings, error) {
+	jBytes, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{})
+	if err := json.Unmarshal(jBytes, &fields); err != nil {
+		return nil, err
+	}
+	var old map[string]interface{}
+	if err := json.Unmarshal(oldValues, &old); err != nil {
+		return nil, err
+	}
+	// apply parent paths before the paths beneath them, so a map that was emptied is removed before its old values are
+	// put back
+	paths := make([]string, 0, len(old))
+	for path := range old {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		setPath(fields, strings.Split(path, "."), old[path])
+	}
+	jBytes, err = json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	reverted := settings.GuildSettings{}
+	err = json.Unmarshal(jBytes, &reverted)
+	return &reverted, err
+}
+
+// setPath sets the value at a path of nested maps, creating any missing maps along the way. A nil value removes the
+// path instead
+func setPath(m map[string]interface{}, path []string, v interface{}) {
+	if len(path) == 1 {
+		if v == nil {
+			delete(m, path[0])
+		} else {
+			m[path[0]] = v
+		}
+		return
+	}
+	child, ok := m[path[0]].(map[string]interface{})
+	if !ok {
+		if v == nil {
+			return
+		}
+		child = make(map[string]interface{})
+		m[path[0]] = child
+	}
+	setPath(child, path[1:], v)
+}
+
+// DiffSettingValues lists the differences between the old and new values of a recorded change, in the same
+// "key: old → new" format as DiffSettings
+func DiffSettingValues(oldValues, newValues []byte) []string {
+	return diffFlattened(flattenJSON(json.RawMessage(oldValues)), flattenJSON(json.RawMessage(newValues)))
+}
@@ -0,0 +1,104 @@
+package setting
+
+import (
+	"testing"
+
+	"github.com/automuteus/utils/pkg/game"
+	"github.com/automuteus/utils/pkg/settings"
+)
+
+func TestChangedAndRevertSettings(t *testing.T) {
+	before := settings.MakeGuildSettings()
+	after, err := CopyGuildSettings(before)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldValues, newValues, err := ChangedSettings(before, after)
+	if err != nil || oldValues != nil || newValues != nil {
+		t.Fatalf("Expected no changes, got %s → %s (%v)", oldValues, newValues, err)
+	}
+
+	after.SetDelay(game.LOBBY, game.TASKS, 9)
+	after.SetLanguage("fr")
+	oldValues, newValues, err = ChangedSettings(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffs := DiffSettingValues(oldValues, newValues)
+	if len(diffs) != 2 {
+		t.Fatalf("Expected 2 diffs, got %v", diffs)
+	}
+
+	// a later change to something else isn't undone by reverting the first change
+	after.SetUnmuteDeadDuringTasks(true)
+	reverted, err := RevertSettings(after, oldValues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reverted.GetDelay(game.LOBBY, game.TASKS) != before.GetDelay(game.LOBBY, game.TASKS) {
+		t.Error("Expected the delay to be reverted")
+	}
+	if reverted.GetLanguage() != before.GetLanguage() {
+		t.Error("Expected the language to be reverted")
+	}
+	if !reverted.GetUnmuteDeadDuringTasks() {
+		t.Error("Expected the unrelated change to be kept")
+	}
+}
+
+func TestRevertSettingsInterleavedVoiceRules(t *testing.T) {
+	original := settings.MakeGuildSettings()
+
+	// a rogue change to one voice rule...
+	rogue, err := CopyGuildSettings(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rogue.SetVoiceRule(true, game.TASKS, "alive", false)
+	rogueOld, _, err := ChangedSettings(original, rogue)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ...followed by a legitimate change to another rule in the same map
+	current, err := CopyGuildSettings(rogue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current.SetVoiceRule(false, game.DISCUSS, "dead", true)
+	laterOld, laterNew, err := ChangedSettings(rogue, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs := DiffSettingValues(laterOld, laterNew); len(diffs) != 1 {
+		t.Fatalf("Expected a single voice rule to be recorded, got %v", diffs)
+	}
+
+	reverted, err := RevertSettings(current, rogueOld)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reverted.GetVoiceRule(true, game.TASKS, "alive") {
+		t.Error("Expected the rogue voice rule to be reverted")
+	}
+	if !reverted.GetVoiceRule(false, game.DISCUSS, "dead") {
+		t.Error("Expected the later voice rule change to be kept")
+	}
+}
+
+func TestRevertSettingsTopLevelRecord(t *testing.T) {
+	current := settings.MakeGuildSettings()
+	current.SetDelay(game.LOBBY, game.TASKS, 9)
+
+	// changes recorded before values were tracked by path only hold top-level keys
+	reverted, err := RevertSettings(current, []byte(`{"language":"fr"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reverted.GetLanguage() != "fr" {
+		t.Errorf("Expected the language to be reverted to fr, got %s", reverted.GetLanguage())
+	}
+	if reverted.GetDelay(game.LOBBY, game.TASKS) != 9 {
+		t.Error("Expected the delay to be kept")
+	}
+}
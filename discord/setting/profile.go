@@ -0,0 +1,12 @@
+package setting
+
+import "regexp"
+
+// MaxProfiles is the most settings profiles a guild can save
+const MaxProfiles = 10
+
+var profileNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,32}$`)
+
+func IsValidProfileName(name string) bool {
+	return profileNameRegex.MatchString(name)
+}
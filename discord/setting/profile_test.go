@@ -0,0 +1,16 @@
+package setting
+
+import "testing"
+
+func TestIsValidProfileName(t *testing.T) {
+	for _, v := range []string{"casual", "Competitive_2", "no-voice"} {
+		if !IsValidProfileName(v) {
+			t.Errorf("Expected %s to be a valid profile name", v)
+		}
+	}
+	for _, v := range []string{"", "has space", "emoji😀", "waaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaytoolong"} {
+		if IsValidProfileName(v) {
+			t.Errorf("Expected %s to be an invalid profile name", v)
+		}
+	}
+}
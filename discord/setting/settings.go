@@ -53,6 +53,18 @@ const (
 	Show                = "show"
 	List                = "list"
 	Reset               = "reset"
+	Export              = "export"
+	Import              = "import"
+	Profile             = "profile"
+	ProfileUse          = "use"
+	ProfileSave         = "save"
+	ProfileDelete       = "delete"
+	Channel             = "channel"
+	Permissions         = "permissions"
+	PermissionsGrant    = "grant"
+	PermissionsRevoke   = "revoke"
+	RotateCode          = "rotate-code"
+	Unpin               = "unpin"
 )
 
 func GetSettingByName(name string) *Setting {
@@ -80,11 +92,25 @@ func ToString(option *discordgo.ApplicationCommandInteractionDataOption) string
 		return option.ChannelValue(nil).Mention()
 	case discordgo.ApplicationCommandOptionSubCommand:
 		return option.Name
+	case discordgo.ApplicationCommandOptionAttachment:
+		// the value is the ID of the attachment, which can be looked up in the resolved data of the interaction
+		return fmt.Sprintf("%v", option.Value)
 	default:
 		return ""
 	}
 }
 
+// ManagementSettings are the subcommands that manage settings as a whole, rather than changing a single setting
+var ManagementSettings = map[string]bool{
+	List:    true,
+	Show:    true,
+	Reset:   true,
+	Export:  true,
+	Import:  true,
+	Profile: true,
+	History: true,
+}
+
 type Setting struct {
 	Name      string
 	ShortDesc string
@@ -107,6 +133,45 @@ var phaseChoices = []*discordgo.ApplicationCommandOptionChoice{
 	},
 }
 
+var profileNameOption = &discordgo.ApplicationCommandOption{
+	Type:        discordgo.ApplicationCommandOptionString,
+	Name:        "name",
+	Description: "Profile name",
+	Required:    true,
+}
+
+// PermissionCommands are the commands that access can be granted to with `/settings permissions`
+var PermissionCommands = []string{"new", "pause", "end", "link", "unlink", "transfer", "schedule", "settings", "download"}
+
+func permissionCommandOptions(required bool) []*discordgo.ApplicationCommandOption {
+	choices := make([]*discordgo.ApplicationCommandOptionChoice, 0, len(PermissionCommands))
+	for _, v := range PermissionCommands {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  v,
+			Value: v,
+		})
+	}
+	return []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "command",
+			Description: "command",
+			Choices:     choices,
+			Required:    required,
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionRole,
+			Name:        Role,
+			Description: "role",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        User,
+			Description: "user",
+		},
+	}
+}
+
 // TODO parse these from JSON so the web UI can use the same file
 var AllSettings = []Setting{
 	{
@@ -175,6 +240,7 @@ var AllSettings = []Setting{
 				Name:        "value",
 				Description: "value",
 			},
+			channelOption,
 		},
 		Premium: false,
 	},
@@ -238,6 +304,98 @@ var AllSettings = []Setting{
 		},
 		Premium: false,
 	},
+	{
+		Name:      Permissions,
+		ShortDesc: "Who Can Use Each Command",
+		Arguments: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        View,
+				Description: "View who can use each command",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options:     permissionCommandOptions(false)[:1],
+			},
+			{
+				Name:        PermissionsGrant,
+				Description: "Allow a role or user to use a command",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options:     permissionCommandOptions(true),
+			},
+			{
+				Name:        PermissionsRevoke,
+				Description: "Stop a role or user from using a command",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options:     permissionCommandOptions(true),
+			},
+			{
+				Name:        Reset,
+				Description: "Go back to using the admin and operator settings for a command",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options:     permissionCommandOptions(true)[:1],
+			},
+		},
+		Premium: false,
+	},
+	{
+		Name:      AutoStart,
+		ShortDesc: "Start Games When A Voice Channel Fills Up",
+		Arguments: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        View,
+				Description: "View the voice channels that start games on their own",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        AutoStartSet,
+				Description: "Start a game when enough members join a voice channel",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					autoStartVoiceOption(true),
+					{
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Name:         TextChannel,
+						Description:  "Text channel to post the game in",
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+						Required:     true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        Members,
+						Description: "Members in the voice channel needed to start a game",
+						MinValue:    &MinAutoStartMembers,
+						MaxValue:    MaxAutoStartMembers,
+						Required:    true,
+					},
+				},
+			},
+			{
+				Name:        AutoStartRemove,
+				Description: "Stop a voice channel from starting games on its own",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					autoStartVoiceOption(true),
+				},
+			},
+		},
+		Premium: false,
+	},
+	{
+		Name:      RotateCode,
+		ShortDesc: "Pin A New Connect Code",
+		Arguments: []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionChannel,
+				Name:         VoiceChannel,
+				Description:  "Voice channel to pin the code to, instead of the whole server",
+				ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        Unpin,
+				Description: "Go back to a random code for every game",
+			},
+		},
+		Premium: false,
+	},
 	{
 		Name:      UnmuteDead,
 		ShortDesc: "Bot unmutes deaths immediately",
@@ -247,6 +405,7 @@ var AllSettings = []Setting{
 				Name:        "unmute",
 				Description: "unmute",
 			},
+			channelOption,
 		},
 		Premium: false,
 	},
@@ -270,15 +429,13 @@ var AllSettings = []Setting{
 				Type:        discordgo.ApplicationCommandOptionString,
 				Name:        "start-phase",
 				Description: "start-phase",
-				Choices:     phaseChoices,
-				Required:    true,
+				Choices:     delayChoices(DelayOrigins),
 			},
 			{
 				Type:        discordgo.ApplicationCommandOptionString,
 				Name:        "end-phase",
 				Description: "end-phase",
-				Choices:     phaseChoices,
-				Required:    true,
+				Choices:     delayChoices(DelayDestinations),
 			},
 			{
 				Type:        discordgo.ApplicationCommandOptionInteger,
@@ -287,6 +444,7 @@ var AllSettings = []Setting{
 				MinValue:    &MinDelay,
 				MaxValue:    MaxDelay,
 			},
+			channelOption,
 		},
 		Premium: false,
 	},
@@ -378,6 +536,7 @@ var AllSettings = []Setting{
 				Name:        "mute",
 				Description: "mute",
 			},
+			channelOption,
 		},
 		Premium: true,
 	},
@@ -408,16 +567,100 @@ var AllSettings = []Setting{
 		Premium: true,
 	},
 	{
-		Name:      Show,
-		ShortDesc: "Show All Current Settings",
+		Name:      Export,
+		ShortDesc: "Export Settings to a File",
 		Arguments: []*discordgo.ApplicationCommandOption{},
 		Premium:   false,
 	},
+	{
+		Name:      Import,
+		ShortDesc: "Import Settings from a File",
+		Arguments: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionAttachment,
+				Name:        "file",
+				Description: "file",
+				Required:    true,
+			},
+		},
+		Premium: false,
+	},
+	{
+		Name:      Profile,
+		ShortDesc: "Named Settings Profiles",
+		Arguments: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        ProfileUse,
+				Description: "Apply a saved profile to the server settings",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					profileNameOption,
+				},
+			},
+			{
+				Name:        ProfileSave,
+				Description: "Save the current settings as a profile",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					profileNameOption,
+				},
+			},
+			{
+				Name:        List,
+				Description: "List saved profiles",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        ProfileDelete,
+				Description: "Delete a saved profile",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					profileNameOption,
+				},
+			},
+		},
+		Premium: false,
+	},
+	{
+		Name:      History,
+		ShortDesc: "Recent Settings Changes",
+		Arguments: []*discordgo.ApplicationCommandOption{
+			{
+				Name:        View,
+				Description: "View recent settings changes",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+			},
+			{
+				Name:        Rollback,
+				Description: "Undo a settings change",
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "id",
+						Description: "ID of the change, from /settings history view",
+						Required:    true,
+					},
+				},
+			},
+		},
+		Premium: false,
+	},
+	{
+		Name:      Show,
+		ShortDesc: "Show All Current Settings",
+		Arguments: []*discordgo.ApplicationCommandOption{
+			channelOption,
+		},
+		Premium: false,
+	},
 	{
 		Name:      Reset,
 		ShortDesc: "Reset Bot Settings",
-		Arguments: []*discordgo.ApplicationCommandOption{},
-		Premium:   false,
+		Arguments: []*discordgo.ApplicationCommandOption{
+			channelOption,
+		},
+		Premium: false,
 	},
 }
 
@@ -8,8 +8,15 @@ import (
 	"log"
 )
 
-func (bot *Bot) HandleSettingsCommand(guildID string, sett *settings.GuildSettings, settType string, args []string, prem bool) interface{} {
+func (bot *Bot) HandleSettingsCommand(guildID, userID string, sett *settings.GuildSettings, settType string, args []string, prem bool) interface{} {
 	var sendMsg interface{}
+	// the settings may be shared through the settings cache, so only ever change a copy
+	before := sett
+	sett, err := setting.CopyGuildSettings(before)
+	if err != nil {
+		log.Println(err)
+		return err.Error()
+	}
 	// if command invalid, no need to reapply changes to json file
 	isValid := false
 
@@ -80,6 +87,12 @@ func (bot *Bot) HandleSettingsCommand(guildID string, sett *settings.GuildSettin
 		sett = settings.MakeGuildSettings()
 		sendMsg = "Resetting guild settings to default values"
 		isValid = true
+	case setting.History:
+		// the rollback subcommand passes the ID of the change; the view subcommand passes its name
+		if len(args) > 0 && args[0] != setting.View {
+			return bot.rollbackSettingsChange(guildID, userID, sett, args)
+		}
+		return bot.settingsHistoryResponse(guildID, sett)
 	case setting.List:
 		fallthrough
 	default:
@@ -90,6 +103,8 @@ func (bot *Bot) HandleSettingsCommand(guildID string, sett *settings.GuildSettin
 		err := bot.StorageInterface.SetGuildSettings(guildID, sett)
 		if err != nil {
 			log.Println(err)
+		} else {
+			bot.recordSettingsChange(guildID, userID, settType, before, sett)
 		}
 	}
 	return sendMsg
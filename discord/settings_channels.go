@@ -0,0 +1,123 @@
+package discord
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/automuteus/automuteus/discord/setting"
+	"github.com/automuteus/utils/pkg/settings"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// HandleChannelSettingsCommand views or changes the settings for a single voice channel. Changes are stored as
+// overrides on top of the guild settings, so the channel still follows the guild for everything it doesn't override
+func (bot *Bot) HandleChannelSettingsCommand(guildID, channelID string, sett *settings.GuildSettings, settType string, args []string, prem bool) interface{} {
+	overrides, err := bot.StorageInterface.GetChannelSettings(guildID, channelID)
+	if err != nil {
+		log.Println(err)
+		return err.Error()
+	}
+	channelSett, err := setting.ApplyChannelOverrides(overrides, sett, prem)
+	if err != nil {
+		log.Println(err)
+		return err.Error()
+	}
+
+	var sendMsg interface{}
+	isValid := false
+
+	switch settType {
+	case setting.UnmuteDead:
+		sendMsg, isValid = setting.FnUnmuteDeadDuringTasks(channelSett, args)
+	case setting.Delays:
+		sendMsg, isValid = setting.FnDelays(channelSett, args)
+	case setting.VoiceRules:
+		sendMsg, isValid = setting.FnVoiceRules(channelSett, args)
+	case setting.MuteSpectators:
+		if !prem {
+			return nonPremiumSettingResponse(sett)
+		}
+		sendMsg, isValid = setting.FnMuteSpectators(channelSett, args)
+	case setting.Show:
+		jBytes, err := json.MarshalIndent(channelSett, "", "  ")
+		if err != nil {
+			log.Println(err)
+			return err
+		}
+		keys, err := setting.OverriddenSettings(overrides)
+		if err != nil {
+			log.Println(err)
+		}
+		overridden := sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.channel.noOverrides",
+			Other: "<#{{.Channel}}> uses the server settings",
+		}, map[string]interface{}{
+			"Channel": channelID,
+		})
+		if len(keys) > 0 {
+			overridden = sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.channel.overrides",
+				Other: "<#{{.Channel}}> overrides these server settings: {{.Settings}}",
+			}, map[string]interface{}{
+				"Channel":  channelID,
+				"Settings": "`" + strings.Join(keys, "`, `") + "`",
+			})
+		}
+		return fmt.Sprintf("%s\n```JSON\n%s\n```", overridden, jBytes)
+	case setting.Reset:
+		_, err := bot.StorageInterface.DeleteChannelSettings(guildID, channelID)
+		if err != nil {
+			log.Println(err)
+			return err.Error()
+		}
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.channel.reset",
+			Other: "<#{{.Channel}}> now uses the server settings",
+		}, map[string]interface{}{
+			"Channel": channelID,
+		})
+	default:
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.channel.unsupported",
+			Other: "`{{.Setting}}` can't be changed for a single voice channel",
+		}, map[string]interface{}{
+			"Setting": settType,
+		})
+	}
+
+	if isValid {
+		merged, err := setting.MergeChannelOverrides(overrides, sett, channelSett)
+		if err == nil {
+			if merged == nil {
+				_, err = bot.StorageInterface.DeleteChannelSettings(guildID, channelID)
+			} else {
+				err = bot.StorageInterface.SetChannelSettings(guildID, channelID, merged)
+			}
+		}
+		if err != nil {
+			log.Println(err)
+		}
+	}
+	return sendMsg
+}
+
+// settingsForChannel layers the overrides for a voice channel on top of the provided settings. If the channel has no
+// overrides (or they can't be loaded), the provided settings are returned as-is
+func (bot *Bot) settingsForChannel(guildID, channelID string, sett *settings.GuildSettings, prem bool) *settings.GuildSettings {
+	overrides, err := bot.StorageInterface.GetChannelSettings(guildID, channelID)
+	if err != nil {
+		log.Println(err)
+		return sett
+	}
+	if overrides == nil {
+		return sett
+	}
+	channelSett, err := setting.ApplyChannelOverrides(overrides, sett, prem)
+	if err != nil {
+		log.Println(err)
+		return sett
+	}
+	return channelSett
+}
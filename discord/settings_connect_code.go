@@ -0,0 +1,67 @@
+package discord
+
+import (
+	"log"
+
+	"github.com/automuteus/automuteus/discord/command"
+	"github.com/automuteus/automuteus/storage"
+	"github.com/automuteus/utils/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// HandleRotateCodeCommand pins a new connect code to a voice channel (or the whole guild), replacing any code that was
+// pinned before. The response includes the capture link, so it's only shown to the user that ran the command
+func (bot *Bot) HandleRotateCodeCommand(guildID, voiceChannelID string, unpin bool, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	scope := voiceChannelID
+	target := "<#" + voiceChannelID + ">"
+	if scope == "" {
+		scope = storage.PinnedCodeGuildScope
+		target = sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.rotatecode.server",
+			Other: "this server",
+		})
+	}
+
+	var content string
+	if unpin {
+		deleted, err := bot.StorageInterface.DeletePinnedConnectCode(guildID, scope)
+		if err != nil {
+			log.Println(err)
+			return command.PrivateResponse(err.Error())
+		}
+		if !deleted {
+			content = sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.rotatecode.notPinned",
+				Other: "There's no connect code pinned to {{.Target}}",
+			}, map[string]interface{}{
+				"Target": target,
+			})
+		} else {
+			content = sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.rotatecode.unpinned",
+				Other: "Games in {{.Target}} will use a random connect code again",
+			}, map[string]interface{}{
+				"Target": target,
+			})
+		}
+	} else {
+		code := generateConnectCode(guildID)
+		err := bot.StorageInterface.SetPinnedConnectCode(guildID, scope, code)
+		if err != nil {
+			log.Println(err)
+			return command.PrivateResponse(err.Error())
+		}
+		hyperlink, _ := formCaptureURL(bot.url, code)
+		content = sett.LocalizeMessage(&i18n.Message{
+			ID: "settings.rotatecode.pinned",
+			Other: "New games in {{.Target}} will use the connect code `{{.Code}}`, so this capture link keeps working:\n<{{.Hyperlink}}>\n\n" +
+				"Games that are already running keep their old code. Use `/settings rotate-code` again if the link leaks",
+		}, map[string]interface{}{
+			"Target":    target,
+			"Code":      code,
+			"Hyperlink": hyperlink,
+		})
+	}
+	return command.PrivateResponse(content)
+}
@@ -0,0 +1,157 @@
+package discord
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/automuteus/automuteus/discord/setting"
+	"github.com/automuteus/automuteus/storage"
+	"github.com/automuteus/utils/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// maxHistoryDiffLines is how many changed values are shown for each change in `/settings history`
+const maxHistoryDiffLines = 3
+
+// recordSettingsChange logs a change to the guild's settings in Postgres, so it can be reviewed and rolled back later.
+// Failing to record a change is logged, but doesn't undo the change
+func (bot *Bot) recordSettingsChange(guildID, userID, settingName string, before, after *settings.GuildSettings) {
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	uid, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	oldValue, newValue, err := setting.ChangedSettings(before, after)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if oldValue == nil {
+		// nothing actually changed
+		return
+	}
+	_, err = storage.AddSettingsChange(bot.PostgresInterface.Pool, &storage.SettingsChange{
+		GuildID:    gid,
+		UserID:     uid,
+		Setting:    settingName,
+		OldValue:   oldValue,
+		NewValue:   newValue,
+		ChangeTime: int32(time.Now().Unix()),
+	})
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (bot *Bot) settingsHistoryResponse(guildID string, sett *settings.GuildSettings) interface{} {
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return err.Error()
+	}
+	changes, err := storage.GetSettingsHistory(bot.PostgresInterface.Pool, gid, setting.HistorySize)
+	if err != nil {
+		log.Println(err)
+		return err.Error()
+	}
+	if len(changes) == 0 {
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.history.none",
+			Other: "No settings changes have been recorded yet",
+		})
+	}
+
+	fields := make([]*discordgo.MessageEmbedField, 0, len(changes))
+	for _, change := range changes {
+		diffs := setting.DiffSettingValues(change.OldValue, change.NewValue)
+		if len(diffs) > maxHistoryDiffLines {
+			diffs = append(diffs[:maxHistoryDiffLines], fmt.Sprintf("... and %d more", len(diffs)-maxHistoryDiffLines))
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name: fmt.Sprintf("#%d · %s", change.ChangeID, change.Setting),
+			Value: fmt.Sprintf("<@%d> <t:%d:R>\n```\n%s\n```", change.UserID, change.ChangeTime,
+				truncateDiff(diffs)),
+			Inline: false,
+		})
+	}
+	return &discordgo.MessageEmbed{
+		Title: sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.history.title",
+			Other: "Settings History",
+		}),
+		Description: sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.history.description",
+			Other: "Use `/settings history rollback <id>` to undo a change",
+		}),
+		Color:  15844367, // GOLD
+		Fields: fields,
+	}
+}
+
+// rollbackSettingsChange puts back the old values from a recorded change. Settings changed since then that weren't
+// part of that change are left alone. The rollback is recorded like any other change, so it can be undone too
+func (bot *Bot) rollbackSettingsChange(guildID, userID string, sett *settings.GuildSettings, args []string) interface{} {
+	if len(args) == 0 {
+		return bot.settingsHistoryResponse(guildID, sett)
+	}
+	changeID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return err.Error()
+	}
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return err.Error()
+	}
+	change, err := storage.GetSettingsChange(bot.PostgresInterface.Pool, gid, changeID)
+	if err != nil {
+		log.Println(err)
+		return err.Error()
+	}
+	if change == nil {
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.rollback.notFound",
+			Other: "I couldn't find a settings change with ID {{.ID}}. See `/settings history view`",
+		}, map[string]interface{}{
+			"ID": changeID,
+		})
+	}
+
+	reverted, err := setting.RevertSettings(sett, change.OldValue)
+	if err != nil {
+		log.Println(err)
+		return err.Error()
+	}
+	diffs := setting.DiffSettings(sett, reverted)
+	if len(diffs) == 0 {
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.rollback.noChanges",
+			Other: "The settings from change {{.ID}} are already in place",
+		}, map[string]interface{}{
+			"ID": changeID,
+		})
+	}
+	err = bot.StorageInterface.SetGuildSettings(guildID, reverted)
+	if err != nil {
+		log.Println(err)
+		return err.Error()
+	}
+	bot.recordSettingsChange(guildID, userID, setting.Rollback, sett, reverted)
+
+	return reverted.LocalizeMessage(&i18n.Message{
+		ID:    "settings.rollback.success",
+		Other: "Rolled back change {{.ID}}:\n```\n{{.Diff}}\n```",
+	}, map[string]interface{}{
+		"ID":   changeID,
+		"Diff": strings.TrimSpace(truncateDiff(diffs)),
+	})
+}
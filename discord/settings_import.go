@@ -0,0 +1,184 @@
+package discord
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/automuteus/automuteus/discord/command"
+	"github.com/automuteus/automuteus/discord/setting"
+	"github.com/automuteus/utils/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+var attachmentClient = http.Client{Timeout: time.Second * 10}
+
+func settingsExportResponse(sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	jBytes, err := setting.ExportSettings(sett)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse("settings-export", err, sett)
+	}
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: 1 << 6, //private message
+			Content: sett.LocalizeMessage(&i18n.Message{
+				ID:    "commands.settings.export.success",
+				Other: "Here are your settings! Use `/settings import` to load them in any server",
+			}),
+			Files: []*discordgo.File{
+				{
+					Name:        "automuteus-settings.json",
+					ContentType: "application/json",
+					Reader:      bytes.NewReader(jBytes),
+				},
+			},
+		},
+	}
+}
+
+// settingsImportPreviewResponse parses an uploaded settings file, and shows the admin what would change before
+// they confirm the import. The uploaded file is held in Redis until they confirm or cancel
+func (bot *Bot) settingsImportPreviewResponse(i *discordgo.InteractionCreate, attachmentID string, sett *settings.GuildSettings, prem bool) *discordgo.InteractionResponse {
+	var attachment *discordgo.MessageAttachment
+	if resolved := i.ApplicationCommandData().Resolved; resolved != nil {
+		attachment = resolved.Attachments[attachmentID]
+	}
+	if attachment == nil {
+		return command.PrivateErrorResponse("settings-import", errors.New("no file was attached"), sett)
+	}
+	data, err := downloadAttachment(attachment)
+	if err != nil {
+		return settingsImportErrorResponse(err, sett)
+	}
+	imported, skipped, err := setting.ImportSettings(data, sett, prem)
+	if err != nil {
+		return settingsImportErrorResponse(err, sett)
+	}
+
+	diffs := setting.DiffSettings(sett, imported)
+	if len(diffs) == 0 {
+		return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.settings.import.noChanges",
+			Other: "That file doesn't change any of your settings",
+		}))
+	}
+	err = bot.StorageInterface.SetPendingSettingsImport(i.GuildID, i.Member.User.ID, data)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse("settings-import", err, sett)
+	}
+
+	content := sett.LocalizeMessage(&i18n.Message{
+		ID:    "commands.settings.import.preview",
+		Other: "Importing this file will make the following changes:\n```\n{{.Diff}}\n```",
+	}, map[string]interface{}{
+		"Diff": truncateDiff(diffs),
+	})
+	if len(skipped) > 0 {
+		content += "\n" + sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.settings.import.skippedPremium",
+			Other: "💎 These premium settings will not be imported: {{.Settings}}",
+		}, map[string]interface{}{
+			"Settings": strings.Join(skipped, ", "),
+		})
+	}
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:      1 << 6, //private message
+			Content:    content,
+			Components: confirmationComponents(settingsImportConfirmedID, settingsImportCanceledID, sett),
+		},
+	}
+}
+
+// settingsImportConfirmResponse applies the pending import on top of the guild's current settings, so anything changed
+// since the preview (that the file doesn't set itself) is kept
+func (bot *Bot) settingsImportConfirmResponse(guildID, userID string, sett *settings.GuildSettings, prem bool) *discordgo.InteractionResponse {
+	var content string
+	data, err := bot.StorageInterface.GetPendingSettingsImport(guildID, userID)
+	switch {
+	case err != nil:
+		log.Println(err)
+		content = err.Error()
+	case data == nil:
+		content = sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.settings.import.expired",
+			Other: "That import has expired. Please upload the file again with `/settings import`",
+		})
+	default:
+		var imported *settings.GuildSettings
+		imported, _, err = setting.ImportSettings(data, sett, prem)
+		if err == nil {
+			err = bot.StorageInterface.SetGuildSettings(guildID, imported)
+		}
+		if err != nil {
+			log.Println(err)
+			content = err.Error()
+		} else {
+			bot.recordSettingsChange(guildID, userID, setting.Import, sett, imported)
+			content = imported.LocalizeMessage(&i18n.Message{
+				ID:    "commands.settings.import.success",
+				Other: "Settings imported successfully!",
+			})
+		}
+		err = bot.StorageInterface.DeletePendingSettingsImport(guildID, userID)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Flags:      1 << 6, //private message
+			Content:    content,
+			Components: []discordgo.MessageComponent{},
+		},
+	}
+}
+
+func settingsImportErrorResponse(err error, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+		ID:    "commands.settings.import.invalid",
+		Other: "I couldn't import that file: {{.Error}}",
+	}, map[string]interface{}{
+		"Error": err.Error(),
+	}))
+}
+
+func downloadAttachment(attachment *discordgo.MessageAttachment) ([]byte, error) {
+	if attachment.Size > setting.MaxSettingsImportBytes {
+		return nil, errors.New("the settings file is too large")
+	}
+	resp, err := attachmentClient.Get(attachment.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading the file failed with status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, setting.MaxSettingsImportBytes+1))
+}
+
+// truncateDiff keeps the diff within Discord's message length limit
+func truncateDiff(diffs []string) string {
+	const maxLen = 1500
+	var sb strings.Builder
+	for idx, v := range diffs {
+		if sb.Len()+len(v) > maxLen {
+			sb.WriteString(fmt.Sprintf("... and %d more", len(diffs)-idx))
+			break
+		}
+		sb.WriteString(v + "\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,133 @@
+package discord
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+
+	"github.com/automuteus/automuteus/discord/command"
+	"github.com/automuteus/automuteus/discord/setting"
+	"github.com/automuteus/utils/pkg/discord"
+	"github.com/automuteus/utils/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// getGuildPermissions returns the per-command grants configured for a guild. If they can't be loaded, no grants are
+// returned, so every command falls back to the admin and operator settings
+func (bot *Bot) getGuildPermissions(guildID string) command.GuildPermissions {
+	perms := command.GuildPermissions{}
+	data, err := bot.StorageInterface.GetCommandPermissions(guildID)
+	if err != nil {
+		log.Println(err)
+		return perms
+	}
+	if data != nil {
+		err = json.Unmarshal(data, &perms)
+		if err != nil {
+			log.Println(err)
+			return command.GuildPermissions{}
+		}
+	}
+	return perms
+}
+
+func (bot *Bot) HandleSettingsPermissionsCommand(guildID string, sett *settings.GuildSettings, action, commandName, roleID, userID string) interface{} {
+	perms := bot.getGuildPermissions(guildID)
+	if action == setting.View {
+		return permissionsEmbed(perms, commandName, sett)
+	}
+
+	restricted := false
+	switch action {
+	case setting.PermissionsGrant, setting.PermissionsRevoke:
+		if roleID == "" && userID == "" {
+			return sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.permissions.noTarget",
+				Other: "Please provide a role or a user",
+			})
+		}
+		if action == setting.PermissionsGrant {
+			restricted = perms.Grant(commandName, roleID, userID, sett)
+		} else {
+			perms.Revoke(commandName, roleID, userID, sett)
+		}
+	case setting.Reset:
+		perms.Reset(commandName)
+	}
+
+	data, err := json.Marshal(perms)
+	if err == nil {
+		err = bot.StorageInterface.SetCommandPermissions(guildID, data)
+	}
+	if err != nil {
+		log.Println(err)
+		return err.Error()
+	}
+	embed := permissionsEmbed(perms, commandName, sett)
+	if restricted {
+		embed.Description = sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.permissions.restricted",
+			Other: "⚠️ Everyone could use `/{{.Command}}` before. Now only the roles and users below (and the server owner) can use it",
+		}, map[string]interface{}{
+			"Command": commandName,
+		}) + "\n\n" + embed.Description
+	}
+	return embed
+}
+
+func permissionsEmbed(perms command.GuildPermissions, commandName string, sett *settings.GuildSettings) *discordgo.MessageEmbed {
+	commands := setting.PermissionCommands
+	if commandName != "" {
+		commands = []string{commandName}
+	}
+	fields := make([]*discordgo.MessageEmbedField, 0, len(commands))
+	for _, v := range commands {
+		grants, configured := perms.GrantsFor(v, sett)
+		var value string
+		switch {
+		case grants.IsEmpty() && configured:
+			value = sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.permissions.ownerOnly",
+				Other: "Only the server owner",
+			})
+		case grants.IsEmpty():
+			value = sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.permissions.everyone",
+				Other: "Everyone",
+			})
+		default:
+			mentions := make([]string, 0, len(grants.Roles)+len(grants.Users))
+			for _, role := range grants.Roles {
+				mentions = append(mentions, "<@&"+role+">")
+			}
+			for _, user := range grants.Users {
+				mentions = append(mentions, discord.MentionByUserID(user))
+			}
+			value = strings.Join(mentions, " ")
+		}
+		if !configured {
+			value += "\n" + sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.permissions.legacy",
+				Other: "*(from the admin and operator settings)*",
+			})
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "/" + v,
+			Value:  value,
+			Inline: true,
+		})
+	}
+	return &discordgo.MessageEmbed{
+		Title: sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.permissions.title",
+			Other: "Command Permissions",
+		}),
+		Description: sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.permissions.description",
+			Other: "The server owner can always use every command. Use `/settings permissions grant` to give a role or user access to a command",
+		}),
+		Color:  15844367, // GOLD
+		Fields: fields,
+	}
+}
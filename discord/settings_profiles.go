@@ -0,0 +1,148 @@
+package discord
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/automuteus/automuteus/discord/setting"
+	"github.com/automuteus/utils/pkg/settings"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+func (bot *Bot) HandleSettingsProfileCommand(guildID, userID string, sett *settings.GuildSettings, action, name string, prem bool) interface{} {
+	if action != setting.List && !setting.IsValidProfileName(name) {
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.profile.invalidName",
+			Other: "Profile names can only contain letters, numbers, `-` and `_`, and must be at most 32 characters long",
+		})
+	}
+
+	switch action {
+	case setting.ProfileSave:
+		names, err := bot.StorageInterface.ListSettingsProfiles(guildID)
+		if err != nil {
+			log.Println(err)
+			return err.Error()
+		}
+		exists := false
+		for _, v := range names {
+			if v == name {
+				exists = true
+				break
+			}
+		}
+		if !exists && len(names) >= setting.MaxProfiles {
+			return sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.profile.tooMany",
+				Other: "You can only save {{.Max}} profiles. Delete one with `/settings profile delete` first",
+			}, map[string]interface{}{
+				"Max": setting.MaxProfiles,
+			})
+		}
+		data, err := setting.ExportSettings(sett)
+		if err == nil {
+			err = bot.StorageInterface.SetSettingsProfile(guildID, name, data)
+		}
+		if err != nil {
+			log.Println(err)
+			return err.Error()
+		}
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.profile.saved",
+			Other: "Saved the current settings as profile `{{.Profile}}`",
+		}, map[string]interface{}{
+			"Profile": name,
+		})
+
+	case setting.ProfileUse:
+		profileSett, skipped, err := bot.loadSettingsProfile(guildID, name, sett, prem)
+		if err != nil {
+			log.Println(err)
+			return err.Error()
+		}
+		if profileSett == nil {
+			return profileNotFoundResponse(name, sett)
+		}
+		err = bot.StorageInterface.SetGuildSettings(guildID, profileSett)
+		if err != nil {
+			log.Println(err)
+			return err.Error()
+		}
+		bot.recordSettingsChange(guildID, userID, setting.Profile, sett, profileSett)
+		msg := profileSett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.profile.used",
+			Other: "Now using the settings from profile `{{.Profile}}`",
+		}, map[string]interface{}{
+			"Profile": name,
+		})
+		if len(skipped) > 0 {
+			msg += "\n" + nonPremiumSettingResponse(sett)
+		}
+		return msg
+
+	case setting.ProfileDelete:
+		deleted, err := bot.StorageInterface.DeleteSettingsProfile(guildID, name)
+		if err != nil {
+			log.Println(err)
+			return err.Error()
+		}
+		if !deleted {
+			return profileNotFoundResponse(name, sett)
+		}
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.profile.deleted",
+			Other: "Deleted profile `{{.Profile}}`",
+		}, map[string]interface{}{
+			"Profile": name,
+		})
+
+	default:
+		names, err := bot.StorageInterface.ListSettingsProfiles(guildID)
+		if err != nil {
+			log.Println(err)
+			return err.Error()
+		}
+		if len(names) == 0 {
+			return sett.LocalizeMessage(&i18n.Message{
+				ID:    "settings.profile.none",
+				Other: "No profiles have been saved yet. Save the current settings with `/settings profile save`",
+			})
+		}
+		sort.Strings(names)
+		return sett.LocalizeMessage(&i18n.Message{
+			ID:    "settings.profile.list",
+			Other: "Saved profiles: {{.Profiles}}",
+		}, map[string]interface{}{
+			"Profiles": "`" + strings.Join(names, "`, `") + "`",
+		})
+	}
+}
+
+// loadSettingsProfile applies a saved profile on top of the provided settings. It returns nil settings if there is no
+// profile by that name
+func (bot *Bot) loadSettingsProfile(guildID, name string, sett *settings.GuildSettings, prem bool) (*settings.GuildSettings, []string, error) {
+	data, err := bot.StorageInterface.GetSettingsProfile(guildID, name)
+	if err != nil || data == nil {
+		return nil, nil, err
+	}
+	return setting.ImportSettings(data, sett, prem)
+}
+
+// settingsForGame returns the settings snapshotted into the game by `/new`, or the guild settings for games started
+// without a profile or voice channel overrides
+func (bot *Bot) settingsForGame(dgs *GameState) *settings.GuildSettings {
+	if dgs.Settings != nil {
+		return dgs.Settings
+	}
+	return bot.StorageInterface.GetGuildSettings(dgs.GuildID)
+}
+
+func profileNotFoundResponse(name string, sett *settings.GuildSettings) string {
+	return sett.LocalizeMessage(&i18n.Message{
+		ID:    "settings.profile.notFound",
+		Other: "I couldn't find a settings profile named `{{.Profile}}`. See `/settings profile list`",
+	}, map[string]interface{}{
+		"Profile": name,
+	})
+}
@@ -2,6 +2,7 @@ package discord
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/automuteus/utils/pkg/storage"
 	"log"
@@ -48,6 +49,24 @@ const (
 	downloadGamesConfirmedID      = "download-games-confirmed"
 	downloadGameEventsConfirmedID = "download-game-events-confirmed"
 	downloadCanceledID            = "download-canceled"
+	settingsImportConfirmedID     = "settings-import-confirmed"
+	settingsImportCanceledID      = "settings-import-canceled"
+
+	// the scheduled session's ID follows these prefixes
+	scheduleRSVPPrefix  = "schedule-rsvp:"
+	scheduleLeavePrefix = "schedule-leave:"
+
+	// the match's CODE:ID follows these prefixes, and the timeline's page follows that
+	matchTimelinePrefix = "match-timeline:"
+	matchSummaryPrefix  = "match-summary:"
+	matchExportPrefix   = "match-export:"
+
+	// the leaderboard's METRIC:ORDER:PAGE follows this prefix
+	leaderboardPagePrefix = "leaderboard-page:"
+
+	// the claim's ID follows these prefixes
+	claimApprovePrefix = "claim-approve:"
+	claimDenyPrefix    = "claim-deny:"
 )
 
 func (bot *Bot) handleInteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -76,7 +95,7 @@ func (bot *Bot) handleInteractionCreate(s *discordgo.Session, i *discordgo.Inter
 				if err != nil {
 					log.Println("err issuing wait response ", err)
 				}
-				followUpMsg, err = s.FollowupMessageCreate(s.State.User.ID, i.Interaction, true, &discordgo.WebhookParams{
+				followUpMsg, err = s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
 					Content: Hourglass,
 				})
 				if err != nil {
@@ -92,10 +111,11 @@ func (bot *Bot) handleInteractionCreate(s *discordgo.Session, i *discordgo.Inter
 					if content == "" {
 						content = "\u200b"
 					}
-					followUpMsg, err = s.FollowupMessageEdit(s.State.User.ID, i.Interaction, followUpMsg.ID, &discordgo.WebhookEdit{
-						Content:    content,
-						Components: resp.Data.Components,
-						Embeds:     resp.Data.Embeds,
+					followUpMsg, err = s.FollowupMessageEdit(i.Interaction, followUpMsg.ID, &discordgo.WebhookEdit{
+						Content:    &content,
+						Components: &resp.Data.Components,
+						Embeds:     &resp.Data.Embeds,
+						Files:      resp.Data.Files,
 					})
 				} else {
 					//TODO if this shows up in logs regularly, print more context
@@ -164,23 +184,14 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
 		return command.ReinviteMeResponse(missingPerms, i.ChannelID, sett)
 	}
 
-	isAdmin, isPermissioned := false, false
-	if g.OwnerID == i.Member.User.ID || (len(sett.AdminUserIDs) == 0 && len(sett.PermissionRoleIDs) == 0) {
-		// the guild owner should always have both permissions
-		// or if both permissions are still empty, everyone gets both
-		isAdmin = true
-		isPermissioned = true
-	} else {
-		// if we have no admins, then we MUST have mods as per the check above. So ensure this user is a mod
-		if len(sett.AdminUserIDs) == 0 {
-			isAdmin = sett.HasRolePerms(i.Member)
-		} else {
-			// we have admins; make sure user is one
-			isAdmin = sett.HasAdminPerms(i.Member.User)
-		}
-		// even if we have admins, we can grant mod if the moderators role is empty; it is lesser permissions
-		isPermissioned = len(sett.PermissionRoleIDs) == 0 || sett.HasRolePerms(i.Member)
+	// the owner can always use every command; everyone else needs a grant for commands that have any (see
+	// /settings permissions), which default to the admin and operator settings
+	perms := bot.getGuildPermissions(i.GuildID)
+	canUse := func(commandName string) bool {
+		return perms.CanUse(commandName, g.OwnerID, i.Member, sett)
 	}
+	// admins (those who can change settings) can also manage other users' data, such as clearing their stats
+	isAdmin := canUse(command.Settings.Name)
 
 	// common gsr, but not necessarily used by all commands
 	gsr := GameStateRequest{
@@ -208,7 +219,7 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
 			return command.InfoResponse(botInfo, i.GuildID, sett)
 
 		case command.Link.Name:
-			if !isPermissioned {
+			if !canUse(command.Link.Name) {
 				return command.InsufficientPermissionsResponse(sett)
 			}
 			userID, color := command.GetLinkParams(s, i.ApplicationCommandData().Options)
@@ -229,7 +240,7 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
 			return resp
 
 		case command.Unlink.Name:
-			if !isPermissioned {
+			if !canUse(command.Unlink.Name) {
 				return command.InsufficientPermissionsResponse(sett)
 			}
 			userID := command.GetUnlinkParams(s, i.ApplicationCommandData().Options)
@@ -250,19 +261,48 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
 			return resp
 
 		case command.Settings.Name:
-			if !isAdmin {
+			if !canUse(command.Settings.Name) {
 				return command.InsufficientPermissionsResponse(sett)
 			}
 			premStatus, days, err := bot.PostgresInterface.GetGuildOrUserPremiumStatus(bot.official, bot.TopGGClient, i.GuildID, i.Member.User.ID)
 			if err != nil {
 				log.Println("Err in /settings get premium:", err)
 			}
-			setting, args := command.GetSettingsParams(i.ApplicationCommandData().Options)
-			msg := bot.HandleSettingsCommand(i.GuildID, sett, setting, args, !premium.IsExpired(premStatus, days))
+			channelID, options := command.GetSettingsChannelParam(i.ApplicationCommandData().Options)
+			settingName, args := command.GetSettingsParams(options)
+			if channelID != "" {
+				msg := bot.HandleChannelSettingsCommand(i.GuildID, channelID, sett, settingName, args, !premium.IsExpired(premStatus, days))
+				return command.SettingsResponse(msg)
+			}
+			switch settingName {
+			case setting.Permissions:
+				action, commandName, roleID, userID := command.GetSettingsPermissionsParams(i.ApplicationCommandData().Options)
+				msg := bot.HandleSettingsPermissionsCommand(i.GuildID, sett, action, commandName, roleID, userID)
+				return command.SettingsResponse(msg)
+			case setting.RotateCode:
+				voiceChannelID, unpin := command.GetSettingsRotateCodeParams(i.ApplicationCommandData().Options)
+				return bot.HandleRotateCodeCommand(i.GuildID, voiceChannelID, unpin, sett)
+			case setting.AutoStart:
+				action, voiceChannelID, textChannelID, members := command.GetSettingsAutoStartParams(i.ApplicationCommandData().Options)
+				msg := bot.HandleSettingsAutoStartCommand(i.GuildID, sett, action, voiceChannelID, textChannelID, members)
+				return command.SettingsResponse(msg)
+			case setting.Profile:
+				action, name := command.GetSettingsProfileParams(i.ApplicationCommandData().Options)
+				msg := bot.HandleSettingsProfileCommand(i.GuildID, i.Member.User.ID, sett, action, name, !premium.IsExpired(premStatus, days))
+				return command.SettingsResponse(msg)
+			case setting.Export:
+				return settingsExportResponse(sett)
+			case setting.Import:
+				if len(args) == 0 {
+					return command.PrivateErrorResponse("settings-import", errors.New("no file was attached"), sett)
+				}
+				return bot.settingsImportPreviewResponse(i, args[0], sett, !premium.IsExpired(premStatus, days))
+			}
+			msg := bot.HandleSettingsCommand(i.GuildID, i.Member.User.ID, sett, settingName, args, !premium.IsExpired(premStatus, days))
 			return command.SettingsResponse(msg)
 
 		case command.New.Name:
-			if !isPermissioned {
+			if !canUse(command.New.Name) {
 				return command.InsufficientPermissionsResponse(sett)
 			}
 
@@ -277,100 +317,54 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
 				return command.ReinviteMeResponse(missingPerms, voiceChannelID, sett)
 			}
 
+			gameSett := sett
+			profile := command.GetNewParams(i.ApplicationCommandData().Options)
+			var profileSett *settings.GuildSettings
+			prem := bot.getLeaderPremiumTier(i.GuildID, i.Member.User.ID) != premium.FreeTier
+			if profile != "" {
+				profileSett, _, err = bot.loadSettingsProfile(i.GuildID, profile, sett, prem)
+				if err != nil {
+					log.Println(err)
+					return command.PrivateErrorResponse(command.New.Name, err, sett)
+				}
+				if profileSett == nil {
+					return command.NewResponse(command.NewProfileNotFound, command.NewInfo{Profile: profile}, sett)
+				}
+				gameSett = profileSett
+			}
+			// voice channel overrides apply on top of the profile (or guild settings) for this game
+			gameSett = bot.settingsForChannel(i.GuildID, voiceChannelID, gameSett, prem)
+
 			lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLockRetries(gsr, 5)
 			if lock == nil {
 				log.Printf("No lock could be obtained when making a new game for guild %s, channel %s\n", i.GuildID, i.ChannelID)
 				return command.DeadlockGameStateResponse(command.New.Name, sett)
 			}
 
-			status, activeGames := bot.newGame(dgs)
-			if status == command.NewSuccess {
-				// release the lock
-				bot.RedisInterface.SetDiscordGameState(dgs, lock)
-
-				bot.RedisInterface.RefreshActiveGame(dgs.GuildID, dgs.ConnectCode)
-
-				killChan := make(chan EndGameMessage)
-
-				go bot.SubscribeToGameByConnectCode(i.GuildID, dgs.ConnectCode, killChan)
-
-				bot.ChannelsMapLock.Lock()
-				bot.EndGameChannels[dgs.ConnectCode] = killChan
-				bot.ChannelsMapLock.Unlock()
-
-				hyperlink, minimalURL := formCaptureURL(bot.url, dgs.ConnectCode)
-
-				bot.handleGameStartMessage(i.GuildID, i.ChannelID, voiceChannelID, i.Member.User.ID, sett, g, dgs.ConnectCode)
-
-				return command.NewResponse(status, command.NewInfo{
-					Hyperlink:   hyperlink,
-					MinimalURL:  minimalURL,
-					ConnectCode: dgs.ConnectCode,
-					ActiveGames: activeGames, // not actually needed for Success messages
-				}, sett)
-			} else {
-				// release the lock
-				bot.RedisInterface.SetDiscordGameState(nil, lock)
-				return command.NewResponse(status, command.NewInfo{
-					ActiveGames: activeGames, // only field we need for success messages
-				}, sett)
-			}
+			status, info := bot.startGame(dgs, lock, g, i.ChannelID, voiceChannelID, i.Member.User.ID, profile, sett, gameSett)
+			return command.NewResponse(status, info, sett)
 		case command.Refresh.Name:
-			if bot.RefreshGameStateMessage(gsr, sett) {
-				return command.PrivateResponse(ThumbsUp)
-			} else {
-				return command.NoGameResponse(sett)
-			}
+			return bot.refreshGame(gsr, sett)
 
 		case command.Pause.Name:
-			if !isPermissioned {
+			if !canUse(command.Pause.Name) {
 				return command.InsufficientPermissionsResponse(sett)
 			}
-			lock, dgs := bot.RedisInterface.GetDiscordGameStateAndLockRetries(gsr, 5)
-			if lock == nil {
-				log.Printf("No lock could be obtained when pausing game for guild %s, channel %s\n", i.GuildID, i.ChannelID)
-				return command.DeadlockGameStateResponse(command.Pause.Name, sett)
-			}
-			if !dgs.GameStateMsg.Exists() {
-				bot.RedisInterface.SetDiscordGameState(nil, lock)
-				return command.NoGameResponse(sett)
-			}
-
-			dgs.Running = !dgs.Running
-
-			bot.RedisInterface.SetDiscordGameState(dgs, lock)
-			// if we paused the game, unmute/undeafen all players
-			if !dgs.Running {
-				err = bot.applyToAll(dgs, false, false)
-			}
-			bot.DispatchRefreshOrEdit(dgs, gsr, sett)
-			if err != nil {
-				return command.PrivateErrorResponse(command.Pause.Name, err, sett)
-			}
-			return command.PrivateResponse(ThumbsUp)
+			return bot.pauseOrResumeGame(gsr, sett)
 
 		case command.End.Name:
-			if !isPermissioned {
+			if !canUse(command.End.Name) {
 				return command.InsufficientPermissionsResponse(sett)
 			}
-			dgs := bot.RedisInterface.GetReadOnlyDiscordGameState(gsr)
-			if dgs != nil {
-				if !dgs.GameStateMsg.Exists() {
-					return command.NoGameResponse(sett)
-				}
+			return bot.endGame(gsr, sett)
 
-				if v, ok := bot.EndGameChannels[dgs.ConnectCode]; ok {
-					v <- true
-				}
-				delete(bot.EndGameChannels, dgs.ConnectCode)
+		case command.Schedule.Name:
+			params := command.GetScheduleParams(i.ApplicationCommandData().Options)
+			return bot.HandleScheduleCommand(i, params, canUse(command.Schedule.Name), sett)
 
-				err = bot.applyToAll(dgs, false, false)
-				if err != nil {
-					return command.PrivateErrorResponse(command.End.Name, err, sett)
-				}
-				return command.PrivateResponse(ThumbsUp)
-			}
-			return command.DeadlockGameStateResponse(command.End.Name, sett)
+		case command.Transfer.Name:
+			target := command.GetTransferParams(s, i.ApplicationCommandData().Options)
+			return bot.transferGame(gsr, i.Member.User.ID, canUse(command.Transfer.Name), target, sett)
 
 		case command.Privacy.Name:
 			privArg := command.GetPrivacyParam(i.ApplicationCommandData().Options)
@@ -379,15 +373,38 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
 				return command.PrivacyResponse(privArg, nil, nil, nil, sett)
 
 			case command.PrivacyOptOut:
+				// the links are how the user's unlinked games are found, so those go first
+				err = bot.ignoreUnlinkedNames(i.GuildID, i.Member.User.ID)
+				if err == nil {
+					err = bot.deleteUserUnlinkedClaims(i.Member.User.ID)
+				}
+				if err != nil {
+					return command.PrivacyResponse(privArg, nil, nil, err, sett)
+				}
 				err = bot.RedisInterface.DeleteLinksByUserID(i.GuildID, i.Member.User.ID)
 				if err != nil {
 					return command.PrivacyResponse(privArg, nil, nil, err, sett)
 				}
+				// opting out hides the profile page, too
+				err = bot.hideProfile(i.Member.User.ID)
+				if err != nil {
+					return command.PrivacyResponse(privArg, nil, nil, err, sett)
+				}
 				fallthrough
 			case command.PrivacyOptIn:
 				err = bot.PostgresInterface.OptUserByString(i.Member.User.ID, privArg == command.PrivacyOptIn)
+				// opting out deletes the user's games, so anything derived from them goes too
+				if err == nil && privArg == command.PrivacyOptOut {
+					err = bot.deleteUserRatings(i.Member.User.ID)
+					if err == nil {
+						err = bot.deleteUserAchievements(i.Member.User.ID)
+					}
+				}
 				return command.PrivacyResponse(privArg, nil, nil, err, sett)
 
+			case command.PrivacyProfileOn, command.PrivacyProfileOff:
+				return bot.PrivacyProfileResponse(i.Member.User.ID, privArg == command.PrivacyProfileOn, sett)
+
 			case command.PrivacyShowMe:
 				cached, _ := bot.RedisInterface.GetUsernameOrUserIDMappings(i.GuildID, i.Member.User.ID)
 				user, err := bot.PostgresInterface.GetUserByString(i.Member.User.ID)
@@ -410,20 +427,79 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
 			}
 			if action == setting.View {
 				var embed *discordgo.MessageEmbed
+				var components []discordgo.MessageComponent
+				var files []*discordgo.File
+				period := command.GetStatsPeriod(i.ApplicationCommandData().Options)
 				switch opType {
 				case command.User:
-					embed = bot.UserStatsEmbed(id, i.GuildID, sett, prem)
+					global := command.GetStatsScope(i.ApplicationCommandData().Options) == command.ScopeGlobal
+					if global {
+						if resp := bot.globalStatsUnavailableResponse(id, i.Member.User.ID, period, sett); resp != nil {
+							return resp
+						}
+					}
+					p, resp := bot.statsPeriodOrResponse(i.GuildID, period, sett)
+					if resp != nil {
+						return resp
+					}
+					if global {
+						embed = bot.GlobalUserStatsEmbed(id, p, sett, prem)
+						break
+					}
+					if period == command.PeriodAll {
+						embed = bot.UserStatsEmbed(id, i.GuildID, sett, prem)
+					} else {
+						embed = bot.UserPeriodStatsEmbed(id, i.GuildID, p, sett, prem)
+					}
+					if prem && embed != nil {
+						files = attachImage(embed, bot.UserStatsCard(id, i.GuildID, p.TimeRange, sett))
+					}
 				case command.Guild:
-					embed = bot.GuildStatsEmbed(i.GuildID, sett, prem)
+					p, resp := bot.statsPeriodOrResponse(i.GuildID, period, sett)
+					if resp != nil {
+						return resp
+					}
+					if period == command.PeriodAll {
+						embed = bot.GuildStatsEmbed(i.GuildID, sett, prem)
+					} else {
+						embed = bot.GuildPeriodStatsEmbed(i.GuildID, p, sett, prem)
+					}
+					if prem && embed != nil {
+						files = attachImage(embed, bot.GuildStatsChart(i.GuildID, p.TimeRange, sett))
+					}
 				case command.Match:
 					if MatchIDRegex.Match([]byte(id)) {
 						tokens := strings.Split(id, ":")
 						embed = bot.GameStatsEmbed(i.GuildID, tokens[1], tokens[0], prem, sett)
+						if prem {
+							components = matchStatsComponents(id, 0, 0, false, sett)
+						}
 					} else {
 						err := fmt.Errorf("invalid match code provided: %s, should resemble something like `1A2B3C4D:12345`", id)
 						return command.PrivateErrorResponse(command.Stats.Name+" "+command.Match, err, sett)
 					}
 				}
+				if embed != nil {
+					return &discordgo.InteractionResponse{
+						Type: discordgo.InteractionResponseChannelMessageWithSource,
+						Data: &discordgo.InteractionResponseData{
+							Embeds: []*discordgo.MessageEmbed{
+								embed,
+							},
+							Components: components,
+							Files:      files,
+						},
+					}
+				}
+			} else if action == command.Versus {
+				userID, opponentID := command.GetStatsVersusParams(bot.PrimarySession, i.ApplicationCommandData().Options)
+				if userID == opponentID {
+					return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+						ID:    "commands.stats.versus.same",
+						Other: "Pick two different players to compare",
+					}))
+				}
+				embed := bot.VersusStatsEmbed(userID, opponentID, i.GuildID, sett)
 				if embed != nil {
 					return &discordgo.InteractionResponse{
 						Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -434,6 +510,23 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
 						},
 					}
 				}
+			} else if action == command.Achievements {
+				subcommand, achievementID := command.GetStatsAchievementParams(i.ApplicationCommandData().Options)
+				return bot.HandleStatsAchievementsCommand(i.GuildID, subcommand, achievementID, isAdmin, sett)
+			} else if action == command.Unlinked {
+				return bot.HandleStatsUnlinkedCommand(i.GuildID, opType, isAdmin, sett)
+			} else if action == command.Claim {
+				return bot.HandleStatsClaimCommand(i.GuildID, i.Member.User.ID, id, sett)
+			} else if action == command.Season {
+				return bot.HandleStatsSeasonCommand(i.GuildID, command.GetStatsSeasonParams(i.ApplicationCommandData().Options), isAdmin, sett)
+			} else if action == command.Leaderboard {
+				if !prem {
+					return leaderboardNoPremiumResponse(sett)
+				}
+				order := command.GetStatsLeaderboardOrder(i.ApplicationCommandData().Options)
+				if resp := bot.LeaderboardResponse(i.GuildID, opType, order, 0, false, sett); resp != nil {
+					return resp
+				}
 			} else if action == setting.Clear {
 				// id mismatch applies to user ids AND guild ID (guildId *always* != author.id, therefore, must be admin)
 				if id != i.Member.User.ID && !isAdmin {
@@ -509,15 +602,10 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
 					return command.DebugResponse(setting.Clear, nil, nil, id, err, sett)
 				}
 			} else if action == command.UnmuteAll {
-				dgs := bot.RedisInterface.GetReadOnlyDiscordGameState(gsr)
-				err = bot.applyToAll(dgs, false, false)
-				if err != nil {
-					return command.PrivateErrorResponse(command.UnmuteAll, err, sett)
-				}
-				return command.PrivateResponse(ThumbsUp)
+				return bot.unmuteAll(gsr, sett)
 			}
 		case command.Download.Name:
-			if !isAdmin {
+			if !canUse(command.Download.Name) {
 				return command.InsufficientPermissionsResponse(sett)
 			}
 			// don't send the userid because downloading is restricted to Gold members
@@ -589,6 +677,20 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
 			log.Println(err)
 			// TODO report this properly
 		}
+		customID := i.MessageComponentData().CustomID
+		if strings.HasPrefix(customID, scheduleRSVPPrefix) || strings.HasPrefix(customID, scheduleLeavePrefix) {
+			return bot.handleScheduleRSVP(i, customID, sett)
+		}
+		if strings.HasPrefix(customID, matchTimelinePrefix) || strings.HasPrefix(customID, matchSummaryPrefix) ||
+			strings.HasPrefix(customID, matchExportPrefix) {
+			return bot.handleMatchStatsComponent(i, customID, sett)
+		}
+		if strings.HasPrefix(customID, leaderboardPagePrefix) {
+			return bot.handleLeaderboardPage(i.GuildID, i.Member.User.ID, customID, sett)
+		}
+		if strings.HasPrefix(customID, claimApprovePrefix) || strings.HasPrefix(customID, claimDenyPrefix) {
+			return bot.handleUnlinkedClaim(i.GuildID, customID, isAdmin, sett)
+		}
 		switch i.MessageComponentData().CustomID {
 		case colorSelectID:
 			if len(i.MessageComponentData().Values) > 0 {
@@ -612,6 +714,24 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
 				return resp
 			}
 
+		case pauseButtonID:
+			if !canUse(command.Pause.Name) {
+				return command.InsufficientPermissionsResponse(sett)
+			}
+			return bot.pauseOrResumeGame(gsr, sett)
+
+		case endButtonID:
+			if !canUse(command.End.Name) {
+				return command.InsufficientPermissionsResponse(sett)
+			}
+			return bot.endGame(gsr, sett)
+
+		case refreshButtonID:
+			return bot.refreshGame(gsr, sett)
+
+		case unmuteAllButtonID:
+			return bot.unmuteAll(gsr, sett)
+
 		case resetUserConfirmedID:
 			var content string
 			// i.Message.Mentions is the list of the mentions in the original message.
@@ -621,6 +741,15 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
 			if len(i.Message.Mentions) == 1 {
 				id := i.Message.Mentions[0].ID
 				err := bot.PostgresInterface.DeleteAllGamesForUser(id)
+				if err == nil {
+					err = bot.deleteUserRatings(id)
+				}
+				if err == nil {
+					err = bot.deleteUserAchievements(id)
+				}
+				if err == nil {
+					err = bot.deleteUserUnlinkedClaims(id)
+				}
 				if err != nil {
 					content = sett.LocalizeMessage(&i18n.Message{
 						ID:    "commands.stats.user.reset.error",
@@ -660,6 +789,15 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
 		case resetGuildConfirmedID:
 			var content string
 			err := bot.PostgresInterface.DeleteAllGamesForServer(i.GuildID)
+			if err == nil {
+				err = bot.deleteGuildRatings(gid)
+			}
+			if err == nil {
+				err = bot.deleteGuildAchievements(gid)
+			}
+			if err == nil {
+				err = bot.deleteGuildUnlinked(gid)
+			}
 			if err != nil {
 				content = sett.LocalizeMessage(&i18n.Message{
 					ID:    "commands.stats.guild.reset.error",
@@ -819,6 +957,21 @@ func (bot *Bot) slashCommandHandler(s *discordgo.Session, i *discordgo.Interacti
 					},
 				}
 			}
+		case settingsImportConfirmedID:
+			if !isAdmin {
+				return command.InsufficientPermissionsResponse(sett)
+			}
+			premStatus, days, err := bot.PostgresInterface.GetGuildOrUserPremiumStatus(bot.official, bot.TopGGClient, i.GuildID, i.Member.User.ID)
+			if err != nil {
+				log.Println("Err in /settings import get guild prem:", err)
+			}
+			return bot.settingsImportConfirmResponse(i.GuildID, i.Member.User.ID, sett, !premium.IsExpired(premStatus, days))
+		case settingsImportCanceledID:
+			err := bot.StorageInterface.DeletePendingSettingsImport(i.GuildID, i.Member.User.ID)
+			if err != nil {
+				log.Println(err)
+			}
+			return resetCancelResponse(sett)
 		case downloadCanceledID:
 			fallthrough
 		case resetUserCanceledID:
@@ -58,6 +58,12 @@ func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSetti
 		Inline: true,
 	}
 
+	fields = append(fields, bot.ratingFields(userID, guildID, sett)...)
+	fields = append(fields, bot.streakFields(userID, guildID, sett)...)
+	if field := bot.achievementsField(userID, guildID, sett); field != nil {
+		fields = append(fields, field)
+	}
+
 	extraDesc := sett.LocalizeMessage(&i18n.Message{
 		ID:    "responses.userStatsEmbed.NoPremium",
 		Other: "Detailed stats are only available for AutoMuteUs Premium users; type `/premium` to learn more",
@@ -112,28 +118,7 @@ func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSetti
 
 		guildsPlayedIn := bot.PostgresInterface.NumGuildsPlayedInByUser(userID)
 		if guildsPlayedIn > 0 {
-			val := sett.LocalizeMessage(&i18n.Message{
-				ID:    "responses.userStatsEmbed.ServersPlayedInValue",
-				Other: "{{.Servers}} Servers",
-			}, map[string]interface{}{
-				"Servers": guildsPlayedIn,
-			})
-			if guildsPlayedIn == 1 {
-				val = sett.LocalizeMessage(&i18n.Message{
-					ID:    "responses.userStatsEmbed.ServerPlayedInValue",
-					Other: "{{.Server}} Server",
-				}, map[string]interface{}{
-					"Server": guildsPlayedIn,
-				})
-			}
-			fields = append(fields, &discordgo.MessageEmbedField{
-				Name: sett.LocalizeMessage(&i18n.Message{
-					ID:    "responses.userStatsEmbed.ServersPlayedIn",
-					Other: "Played In",
-				}),
-				Value:  val,
-				Inline: true,
-			})
+			fields = append(fields, serversPlayedInField(guildsPlayedIn, sett))
 		} else {
 			fields = append(fields, &discordgo.MessageEmbedField{
 				Name:   "\u200b",
@@ -465,7 +450,7 @@ func (bot *Bot) UserStatsEmbed(userID, guildID string, sett *settings.GuildSetti
 		}
 	}
 
-	fields = TrimEmbedFields(fields)
+	fields = BalanceEmbedFields(TrimEmbedFields(fields))
 
 	var embed = discordgo.MessageEmbed{
 		URL:  "",
@@ -545,7 +530,7 @@ func (bot *Bot) GuildStatsEmbed(guildID string, sett *settings.GuildSettings, is
 		gname = guildID
 	} else {
 		gname = g.Name
-		avatarURL = g.IconURL()
+		avatarURL = g.IconURL("")
 	}
 
 	gamesPlayed := bot.PostgresInterface.NumGamesPlayedOnGuild(guildID)
@@ -929,6 +914,71 @@ func (bot *Bot) GameStatsEmbed(guildID, matchID, connectCode string, isPrem bool
 	return stats.ToDiscordEmbed(connectCode+":"+matchID, sett)
 }
 
+func serversPlayedInField(guildsPlayedIn int64, sett *settings.GuildSettings) *discordgo.MessageEmbedField {
+	val := sett.LocalizeMessage(&i18n.Message{
+		ID:    "responses.userStatsEmbed.ServersPlayedInValue",
+		Other: "{{.Servers}} Servers",
+	}, map[string]interface{}{
+		"Servers": guildsPlayedIn,
+	})
+	if guildsPlayedIn == 1 {
+		val = sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.userStatsEmbed.ServerPlayedInValue",
+			Other: "{{.Server}} Server",
+		}, map[string]interface{}{
+			"Server": guildsPlayedIn,
+		})
+	}
+	return &discordgo.MessageEmbedField{
+		Name: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.userStatsEmbed.ServersPlayedIn",
+			Other: "Played In",
+		}),
+		Value:  val,
+		Inline: true,
+	}
+}
+
+const (
+	// MaxEmbedFields is the most fields Discord allows in an embed
+	MaxEmbedFields = 25
+	// MaxEmbedFieldValue is the longest value Discord allows for an embed field
+	MaxEmbedFieldValue = 1024
+)
+
+// BalanceEmbedFields fits fields into the MaxEmbedFields Discord allows in an embed (it rejects the whole embed
+// otherwise). Spacer fields only line up the inline fields around them, so they're removed first, starting with the
+// last one; if that still isn't enough, the fields that don't fit are folded into the last field instead of dropped
+func BalanceEmbedFields(fields []*discordgo.MessageEmbedField) []*discordgo.MessageEmbedField {
+	for i := len(fields) - 1; i >= 0 && len(fields) > MaxEmbedFields; i-- {
+		if fields[i].Name == "\u200b" && fields[i].Value == "\u200b" {
+			fields = append(fields[:i], fields[i+1:]...)
+		}
+	}
+	if len(fields) <= MaxEmbedFields {
+		return fields
+	}
+
+	lines := make([]string, 0, len(fields)-MaxEmbedFields+1)
+	for _, v := range fields[MaxEmbedFields-1:] {
+		lines = append(lines, fmt.Sprintf("**%s**\n%s", v.Name, v.Value))
+	}
+	value := []rune(strings.Join(lines, "\n"))
+	if len(value) > MaxEmbedFieldValue {
+		value = append(value[:MaxEmbedFieldValue-1], '…')
+	}
+	fields[MaxEmbedFields-1] = &discordgo.MessageEmbedField{
+		Name:   "\u200b",
+		Value:  string(value),
+		Inline: false,
+	}
+	// prevent memory leak by erasing truncated values
+	for j := MaxEmbedFields; j < len(fields); j++ {
+		fields[j] = nil
+	}
+	return fields[:MaxEmbedFields]
+}
+
 func TrimEmbedFields(fields []*discordgo.MessageEmbedField) []*discordgo.MessageEmbedField {
 	i := 0
 	for _, v := range fields {
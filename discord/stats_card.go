@@ -0,0 +1,189 @@
+package discord
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/automuteus/automuteus/render"
+	"github.com/automuteus/automuteus/storage"
+	"github.com/automuteus/utils/pkg/game"
+	"github.com/automuteus/utils/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// RatingTrendGames is how many of a player's latest rated games are drawn on their card's rating trend
+const RatingTrendGames = 30
+
+func cardLabels(sett *settings.GuildSettings) render.CardLabels {
+	return render.CardLabels{
+		Games: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.stats.Games",
+			Other: "Games",
+		}),
+		Total: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.userStatsEmbed.Winrate",
+			Other: "Winrate",
+		}),
+		Crewmate: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.userStatsEmbed.CrewmateWins",
+			Other: "Crewmate Wins",
+		}),
+		Imposter: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.userStatsEmbed.ImposterWins",
+			Other: "Imposter Wins",
+		}),
+		FavoriteColors: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.userStatsEmbed.FavoriteColors",
+			Other: "Favorite Colors",
+		}),
+		Rating: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.statsCard.Rating",
+			Other: "Rating",
+		}),
+		NoRating: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.statsCard.NoRating",
+			Other: "No rated games yet",
+		}),
+	}
+}
+
+func (bot *Bot) guildName(guildID string) string {
+	if g, err := bot.PrimarySession.State.Guild(guildID); err == nil {
+		return g.Name
+	}
+	return guildID
+}
+
+// UserStatsCard draws a player's stats in a period as a PNG card, or returns nil if the card can't draw their name or
+// the guild's language
+func (bot *Bot) UserStatsCard(userID, guildID string, period storage.TimeRange, sett *settings.GuildSettings) *discordgo.File {
+	uid, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	stats, err := storage.GetUserStats(bot.PostgresInterface.Pool, gid, uid, period)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	card := &render.PlayerCard{
+		Name:          bot.displayName(userID, guildID),
+		Subtitle:      bot.guildName(guildID),
+		Games:         stats.Games,
+		Wins:          stats.Wins,
+		CrewmateGames: stats.CrewmateGames,
+		CrewmateWins:  stats.CrewmateWins,
+		ImposterGames: stats.ImposterGames,
+		ImposterWins:  stats.ImposterWins,
+		Labels:        cardLabels(sett),
+	}
+
+	colorRankings, err := storage.GetColorRankings(bot.PostgresInterface.Pool, gid, uid, period)
+	if err != nil {
+		log.Println(err)
+	}
+	total := int64(0)
+	for _, v := range colorRankings {
+		total += v.Count
+	}
+	for i := 0; i < len(colorRankings) && i < render.MaxCardColors; i++ {
+		card.Colors = append(card.Colors, render.ColorShare{
+			Color: int(colorRankings[i].Color),
+			Share: percent(colorRankings[i].Count, total),
+		})
+	}
+
+	card.CrewmateRatings, err = storage.GetRatingHistory(bot.PostgresInterface.Pool, gid, uid, int16(game.CrewmateRole), RatingTrendGames)
+	if err != nil {
+		log.Println(err)
+	}
+	card.ImposterRatings, err = storage.GetRatingHistory(bot.PostgresInterface.Pool, gid, uid, int16(game.ImposterRole), RatingTrendGames)
+	if err != nil {
+		log.Println(err)
+	}
+
+	// the text embed is sent on its own when the card can't show a name or translation
+	if !card.Drawable() {
+		return nil
+	}
+	buf, err := render.PNG(card.Render())
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	return &discordgo.File{
+		Name:        fmt.Sprintf("stats-%s.png", userID),
+		ContentType: "image/png",
+		Reader:      buf,
+	}
+}
+
+// GuildStatsChart draws the guild's winrate leaderboard for a period as a PNG bar chart, or returns nil if nobody
+// played enough games to qualify or the chart can't draw a name or the guild's language
+func (bot *Bot) GuildStatsChart(guildID string, period storage.TimeRange, sett *settings.GuildSettings) *discordgo.File {
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	leaderboardMin := sett.GetLeaderboardMin()
+	ranking, err := storage.GetPlayerRankings(bot.PostgresInterface.Pool, gid, storage.AnyRole, period, storage.OrderByWinRate, false,
+		leaderboardMin, sett.GetLeaderboardSize(), 0)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	if len(ranking) == 0 {
+		return nil
+	}
+	chart := &render.BarChart{
+		Title: bot.guildName(guildID),
+		Subtitle: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.guildStatsEmbed.TotalWinrate",
+			Other: "Total Winrate ({{.Min}}+ Games)",
+		}, map[string]interface{}{
+			"Min": leaderboardMin,
+		}),
+		Max: 100,
+	}
+	for _, v := range ranking {
+		chart.Bars = append(chart.Bars, render.Bar{
+			Label: bot.displayName(strconv.FormatUint(v.UserID, 10), guildID),
+			Value: v.WinRate,
+			Text:  fmt.Sprintf("%.0f%% | %d", v.WinRate, v.Games),
+		})
+	}
+
+	if !chart.Drawable() {
+		return nil
+	}
+	buf, err := render.PNG(chart.Render())
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	return &discordgo.File{
+		Name:        fmt.Sprintf("leaderboard-%s.png", guildID),
+		ContentType: "image/png",
+		Reader:      buf,
+	}
+}
+
+// attachImage shows an image file in the embed it's sent with
+func attachImage(embed *discordgo.MessageEmbed, file *discordgo.File) []*discordgo.File {
+	if embed == nil || file == nil {
+		return nil
+	}
+	embed.Image = &discordgo.MessageEmbedImage{
+		URL: "attachment://" + file.Name,
+	}
+	return []*discordgo.File{file}
+}
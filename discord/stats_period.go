@@ -0,0 +1,343 @@
+package discord
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/automuteus/automuteus/discord/command"
+	"github.com/automuteus/automuteus/storage"
+	"github.com/automuteus/utils/pkg/game"
+	"github.com/automuteus/utils/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+var (
+	errNoSeason       = errors.New("no season is running")
+	errSeasonNotFound = errors.New("no season with that ID")
+)
+
+// statsPeriod is the range of games a stats embed covers, and how it's described in the embed
+type statsPeriod struct {
+	storage.TimeRange
+	Label string
+}
+
+func (bot *Bot) resolveStatsPeriod(guildID, period string, now time.Time, sett *settings.GuildSettings) (statsPeriod, error) {
+	switch period {
+	case command.PeriodWeek, command.PeriodMonth:
+		days := 7
+		if period == command.PeriodMonth {
+			days = 30
+		}
+		return statsPeriod{
+			TimeRange: storage.TimeRange{Start: int32(now.AddDate(0, 0, -days).Unix()), End: storage.AllTime.End},
+			Label: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.stats.period.days",
+				Other: "Last {{.Days}} days",
+			}, map[string]interface{}{
+				"Days": days,
+			}),
+		}, nil
+	case command.PeriodSeason:
+		gid, err := strconv.ParseUint(guildID, 10, 64)
+		if err != nil {
+			return statsPeriod{}, err
+		}
+		season, err := storage.GetCurrentSeason(bot.PostgresInterface.Pool, gid, int32(now.Unix()))
+		if err != nil {
+			return statsPeriod{}, err
+		}
+		if season == nil {
+			return statsPeriod{}, errNoSeason
+		}
+		return seasonStatsPeriod(season, sett), nil
+	}
+	if seasonID, ok := command.ParseSeasonPeriod(period); ok {
+		gid, err := strconv.ParseUint(guildID, 10, 64)
+		if err != nil {
+			return statsPeriod{}, err
+		}
+		season, err := storage.GetSeason(bot.PostgresInterface.Pool, gid, seasonID)
+		if err != nil {
+			return statsPeriod{}, err
+		}
+		if season == nil {
+			return statsPeriod{}, errSeasonNotFound
+		}
+		return seasonStatsPeriod(season, sett), nil
+	}
+	return statsPeriod{TimeRange: storage.AllTime}, nil
+}
+
+func seasonStatsPeriod(season *storage.Season, sett *settings.GuildSettings) statsPeriod {
+	return statsPeriod{
+		TimeRange: season.TimeRange(),
+		Label: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.stats.period.season",
+			Other: "Season {{.Name}} (<t:{{.Start}}:d> - <t:{{.End}}:d>)",
+		}, map[string]interface{}{
+			"Name":  season.Name,
+			"Start": season.StartTime,
+			"End":   season.EndTime,
+		}),
+	}
+}
+
+// statsPeriodOrResponse resolves the period picked for `/stats view`, or returns the response explaining why it couldn't
+func (bot *Bot) statsPeriodOrResponse(guildID, period string, sett *settings.GuildSettings) (statsPeriod, *discordgo.InteractionResponse) {
+	p, err := bot.resolveStatsPeriod(guildID, period, time.Now(), sett)
+	if errors.Is(err, errNoSeason) {
+		return p, command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.stats.period.noSeason",
+			Other: "There's no season running right now. Admins can create one with `/stats season create`",
+		}))
+	} else if errors.Is(err, errSeasonNotFound) {
+		return p, command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.stats.period.seasonNotFound",
+			Other: "I couldn't find that season. See `/stats season list` for this server's seasons",
+		}))
+	} else if err != nil {
+		log.Println(err)
+		return p, command.PrivateErrorResponse(command.Stats.Name, err, sett)
+	}
+	return p, nil
+}
+
+// UserPeriodStatsEmbed is the counterpart of UserStatsEmbed for games played in a period
+func (bot *Bot) UserPeriodStatsEmbed(userID, guildID string, period statsPeriod, sett *settings.GuildSettings, isPrem bool) *discordgo.MessageEmbed {
+	uid, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	stats, err := storage.GetUserStats(bot.PostgresInterface.Pool, gid, uid, period.TimeRange)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+
+	fields := []*discordgo.MessageEmbedField{
+		{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.userStatsEmbed.GamesPlayed",
+				Other: "Games Played",
+			}),
+			Value:  fmt.Sprintf("%d", stats.Games),
+			Inline: true,
+		},
+		{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.userStatsEmbed.TotalWins",
+				Other: "Total Wins",
+			}),
+			Value:  fmt.Sprintf("%d", stats.Wins),
+			Inline: true,
+		},
+		{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.userStatsEmbed.Winrate",
+				Other: "Winrate",
+			}),
+			Value:  fmt.Sprintf("%d/%d | %.0f%%", stats.Wins, stats.Games, percent(stats.Wins, stats.Games)),
+			Inline: true,
+		},
+	}
+
+	extraDesc := sett.LocalizeMessage(&i18n.Message{
+		ID:    "responses.userStatsEmbed.NoPremium",
+		Other: "Detailed stats are only available for AutoMuteUs Premium users; type `/premium` to learn more",
+	})
+	if isPrem {
+		extraDesc = ""
+		games := sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.stats.Games",
+			Other: "Games",
+		})
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.userStatsEmbed.CrewmateWins",
+				Other: "Crewmate Wins",
+			}),
+			Value:  fmt.Sprintf("%d/%d %s | %.0f%%", stats.CrewmateWins, stats.CrewmateGames, games, percent(stats.CrewmateWins, stats.CrewmateGames)),
+			Inline: true,
+		}, &discordgo.MessageEmbedField{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.userStatsEmbed.ImposterWins",
+				Other: "Imposter Wins",
+			}),
+			Value:  fmt.Sprintf("%d/%d %s | %.0f%%", stats.ImposterWins, stats.ImposterGames, games, percent(stats.ImposterWins, stats.ImposterGames)),
+			Inline: true,
+		}, &discordgo.MessageEmbedField{
+			Name:   "\u200b",
+			Value:  "\u200b",
+			Inline: true,
+		})
+	}
+
+	return &discordgo.MessageEmbed{
+		Title: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.userStatsEmbed.Title",
+			Other: "User Stats",
+		}),
+		Description: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.userStatsEmbed.Desc",
+			Other: "User stats for {{.User}}",
+		}, map[string]interface{}{
+			"User": "<@!" + userID + ">",
+		}) + "\n**" + period.Label + "**\n\n" + extraDesc,
+		Color:  3066993, // GREEN
+		Fields: fields,
+	}
+}
+
+// GuildPeriodStatsEmbed is the counterpart of GuildStatsEmbed for games played in a period
+func (bot *Bot) GuildPeriodStatsEmbed(guildID string, period statsPeriod, sett *settings.GuildSettings, isPrem bool) *discordgo.MessageEmbed {
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	stats, err := storage.GetGuildStats(bot.PostgresInterface.Pool, gid, period.TimeRange)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+
+	fields := []*discordgo.MessageEmbedField{
+		{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.guildStatsEmbed.GamesPlayed",
+				Other: "Games Played",
+			}),
+			Value:  fmt.Sprintf("%d", stats.Games),
+			Inline: stats.Games > 0,
+		},
+	}
+	if stats.Games > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.guildStatsEmbed.GamesWonCrewmate",
+				Other: "Crewmate Winrate",
+			}),
+			Value:  fmt.Sprintf("%.0f%%", percent(stats.CrewmateWins, stats.Games)),
+			Inline: true,
+		}, &discordgo.MessageEmbedField{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.guildStatsEmbed.GamesWonImposter",
+				Other: "Imposter Winrate",
+			}),
+			Value:  fmt.Sprintf("%.0f%%", percent(stats.ImposterWins, stats.Games)),
+			Inline: true,
+		})
+	}
+
+	extraDesc := sett.LocalizeMessage(&i18n.Message{
+		ID:    "responses.guildStatsEmbed.NoPremium",
+		Other: "Detailed stats are only available for AutoMuteUs Premium users; type `/premium` to learn more",
+	})
+	if isPrem {
+		extraDesc = ""
+		leaderboardSize := sett.GetLeaderboardSize()
+		leaderboardMin := sett.GetLeaderboardMin()
+		rankings := []struct {
+			role  int16
+			order storage.RankingOrder
+			min   int
+			name  *i18n.Message
+		}{
+			{storage.AnyRole, storage.OrderByGames, 1, &i18n.Message{
+				ID:    "responses.guildStatsEmbed.MostGames",
+				Other: "Most Games",
+			}},
+			{storage.AnyRole, storage.OrderByWinRate, leaderboardMin, &i18n.Message{
+				ID:    "responses.guildStatsEmbed.TotalWinrate",
+				Other: "Total Winrate ({{.Min}}+ Games)",
+			}},
+			{int16(game.CrewmateRole), storage.OrderByWinRate, leaderboardMin, &i18n.Message{
+				ID:    "responses.guildStatsEmbed.CrewmateWins",
+				Other: "Crewmate Winrate ({{.Min}}+ Games)",
+			}},
+			{int16(game.ImposterRole), storage.OrderByWinRate, leaderboardMin, &i18n.Message{
+				ID:    "responses.guildStatsEmbed.ImposterWins",
+				Other: "Imposter Winrate ({{.Min}}+ Games)",
+			}},
+		}
+		for i, v := range rankings {
+			ranking, err := storage.GetPlayerRankings(bot.PostgresInterface.Pool, gid, v.role, period.TimeRange, v.order, false, v.min, leaderboardSize, 0)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+			if len(ranking) == 0 {
+				continue
+			}
+			buf := bytes.NewBuffer([]byte{})
+			for j, elem := range ranking {
+				if v.order == storage.OrderByGames {
+					buf.WriteString(fmt.Sprintf("%d", elem.Games))
+				} else {
+					buf.WriteString(fmt.Sprintf("%.0f%%", elem.WinRate))
+				}
+				buf.WriteString(" | " + bot.MentionWithCacheData(strconv.FormatUint(elem.UserID, 10), guildID, sett))
+				if j < len(ranking)-1 {
+					buf.WriteByte('\n')
+				}
+			}
+			if i == 2 {
+				fields = append(fields, &discordgo.MessageEmbedField{
+					Name:   "\u200b",
+					Value:  "\u200b",
+					Inline: false,
+				})
+			}
+			fields = append(fields, &discordgo.MessageEmbedField{
+				Name: sett.LocalizeMessage(v.name, map[string]interface{}{
+					"Min": leaderboardMin,
+				}),
+				Value:  buf.String(),
+				Inline: true,
+			})
+		}
+	}
+
+	gname := guildID
+	avatarURL := ""
+	if g, err := bot.PrimarySession.State.Guild(guildID); err == nil {
+		gname = g.Name
+		avatarURL = g.IconURL("")
+	}
+	return &discordgo.MessageEmbed{
+		Title: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.guildStatsEmbed.Title",
+			Other: "Guild Stats",
+		}),
+		Description: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.guildStatsEmbed.Desc",
+			Other: "Guild stats for {{.GuildName}}",
+		}, map[string]interface{}{
+			"GuildName": gname,
+		}) + "\n**" + period.Label + "**\n\n" + extraDesc,
+		Color: 3066993, // GREEN
+		Thumbnail: &discordgo.MessageEmbedThumbnail{
+			URL: avatarURL,
+		},
+		Fields: fields,
+	}
+}
+
+func percent(count, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return 100.0 * float64(count) / float64(total)
+}
@@ -0,0 +1,88 @@
+package discord
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func spacerField() *discordgo.MessageEmbedField {
+	return &discordgo.MessageEmbedField{Name: "\u200b", Value: "\u200b"}
+}
+
+func statField(i int) *discordgo.MessageEmbedField {
+	return &discordgo.MessageEmbedField{Name: fmt.Sprintf("Stat %d", i), Value: fmt.Sprintf("%d", i), Inline: true}
+}
+
+func TestBalanceEmbedFieldsRemovesSpacers(t *testing.T) {
+	var fields []*discordgo.MessageEmbedField
+	for i := 0; i < 20; i++ {
+		if i%3 == 0 {
+			fields = append(fields, spacerField())
+		}
+		fields = append(fields, statField(i))
+	}
+	if len(fields) != 27 {
+		t.Fatalf("Expected 27 fields to start with, got %d", len(fields))
+	}
+
+	fields = BalanceEmbedFields(fields)
+	if len(fields) != MaxEmbedFields {
+		t.Fatalf("Expected %d fields, got %d", MaxEmbedFields, len(fields))
+	}
+	stats := 0
+	for _, v := range fields {
+		if v.Name != "\u200b" {
+			if v.Name != fmt.Sprintf("Stat %d", stats) {
+				t.Errorf("Expected Stat %d, got %s", stats, v.Name)
+			}
+			stats++
+		}
+	}
+	if stats != 20 {
+		t.Errorf("Expected every stat to be kept, got %d of 20", stats)
+	}
+	// the spacers near the start are kept, since removing the last ones was enough
+	if fields[0].Name != "\u200b" || fields[4].Name != "\u200b" {
+		t.Error("Expected the first spacers to be kept")
+	}
+}
+
+func TestBalanceEmbedFieldsFoldsOverflow(t *testing.T) {
+	var fields []*discordgo.MessageEmbedField
+	for i := 0; i < 30; i++ {
+		fields = append(fields, statField(i))
+	}
+
+	fields = BalanceEmbedFields(fields)
+	if len(fields) != MaxEmbedFields {
+		t.Fatalf("Expected %d fields, got %d", MaxEmbedFields, len(fields))
+	}
+	if fields[MaxEmbedFields-2].Name != "Stat 23" {
+		t.Errorf("Expected the fields that fit to be unchanged, got %s", fields[MaxEmbedFields-2].Name)
+	}
+	last := fields[MaxEmbedFields-1].Value
+	for i := 24; i < 30; i++ {
+		if !strings.Contains(last, fmt.Sprintf("**Stat %d**\n%d", i, i)) {
+			t.Errorf("Expected Stat %d to be folded into the last field, got %q", i, last)
+		}
+	}
+
+	fields = []*discordgo.MessageEmbedField{}
+	for i := 0; i < 30; i++ {
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Long", Value: strings.Repeat("é", 500)})
+	}
+	fields = BalanceEmbedFields(fields)
+	if n := len([]rune(fields[MaxEmbedFields-1].Value)); n != MaxEmbedFieldValue {
+		t.Errorf("Expected the folded field to be cut to %d characters, got %d", MaxEmbedFieldValue, n)
+	}
+}
+
+func TestBalanceEmbedFieldsUnderLimit(t *testing.T) {
+	fields := []*discordgo.MessageEmbedField{statField(0), spacerField(), statField(1)}
+	if balanced := BalanceEmbedFields(fields); len(balanced) != 3 {
+		t.Errorf("Expected fields under the limit to be left alone, got %d", len(balanced))
+	}
+}
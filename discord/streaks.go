@@ -0,0 +1,100 @@
+package discord
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/automuteus/automuteus/storage"
+	"github.com/automuteus/utils/pkg/game"
+	"github.com/automuteus/utils/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// RecentFormGames is how many of a player's latest games are shown in their recent form
+const RecentFormGames = 10
+
+// streakFields returns the embed fields showing a user's win streaks and recent form, or nil if they haven't played
+func (bot *Bot) streakFields(userID, guildID string, sett *settings.GuildSettings) []*discordgo.MessageEmbedField {
+	uid, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return nil
+	}
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		return nil
+	}
+	form, err := storage.GetRecentForm(bot.PostgresInterface.Pool, gid, uid, RecentFormGames)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	if len(form) == 0 {
+		return nil
+	}
+
+	streaks := []struct {
+		role int16
+		name *i18n.Message
+	}{
+		{storage.AnyRole, &i18n.Message{
+			ID:    "responses.userStatsEmbed.Overall",
+			Other: "Overall",
+		}},
+		{int16(game.CrewmateRole), &i18n.Message{
+			ID:    "responses.stats.Crewmate",
+			Other: "Crewmate",
+		}},
+		{int16(game.ImposterRole), &i18n.Message{
+			ID:    "responses.stats.Imposter",
+			Other: "Imposter",
+		}},
+	}
+	lines := make([]string, 0, len(streaks))
+	for _, v := range streaks {
+		streak, err := storage.GetUserStreak(bot.PostgresInterface.Pool, gid, uid, v.role)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		lines = append(lines, sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.userStatsEmbed.Streak",
+			Other: "{{.Role}}: {{.Current}} now | {{.Best}} best",
+		}, map[string]interface{}{
+			"Role":    sett.LocalizeMessage(v.name),
+			"Current": streak.Current,
+			"Best":    streak.Best,
+		}))
+	}
+	fields := []*discordgo.MessageEmbedField{
+		{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.userStatsEmbed.WinStreaks",
+				Other: "Win Streaks",
+			}),
+			Value:  strings.Join(lines, "\n"),
+			Inline: true,
+		},
+	}
+
+	results := make([]string, len(form))
+	for i, won := range form {
+		results[i] = "L"
+		if won {
+			results[i] = "W"
+		}
+	}
+	fields = append(fields, &discordgo.MessageEmbedField{
+		Name: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.userStatsEmbed.RecentForm",
+			Other: "Last {{.Games}} Games (oldest to newest)",
+		}, map[string]interface{}{
+			"Games": len(form),
+		}),
+		Value:  fmt.Sprintf("`%s`", strings.Join(results, " ")),
+		Inline: true,
+	})
+	return fields
+}
@@ -0,0 +1,366 @@
+package discord
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/automuteus/automuteus/discord/command"
+	"github.com/automuteus/automuteus/storage"
+	"github.com/automuteus/utils/pkg/discord"
+	"github.com/automuteus/utils/pkg/game"
+	"github.com/automuteus/utils/pkg/settings"
+	storageutils "github.com/automuteus/utils/pkg/storage"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// UnlinkedListSize is how many in-game names `/stats unlinked list` shows
+const UnlinkedListSize = 20
+
+// recordUnlinkedPlayers records the games of the players that weren't linked to anyone, if the guild turned that on.
+// Names that are still linked to someone on the guild are skipped, since they're somebody's games that just weren't
+// linked this time
+func recordUnlinkedPlayers(dgs GameState, psql *storageutils.PsqlInterface, redis *RedisInterface, gameOver game.Gameover, userGames []*storageutils.PostgresUserGame) {
+	gid, err := strconv.ParseUint(dgs.GuildID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	enabled, err := storage.IsUnlinkedStatsEnabled(psql.Pool, gid)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if !enabled {
+		return
+	}
+
+	unlinked := make([]*storage.UnlinkedUserGame, 0)
+	for _, pi := range gameOver.PlayerInfos {
+		linked := false
+		for _, v := range userGames {
+			if strings.EqualFold(v.PlayerName, pi.Name) {
+				linked = true
+				break
+			}
+		}
+		if linked {
+			continue
+		}
+		inGameData, found := dgs.GameData.GetByName(pi.Name)
+		if !found {
+			continue
+		}
+		if links, err := redis.GetUsernameOrUserIDMappings(dgs.GuildID, inGameData.Name); err != nil || len(links) > 0 {
+			continue
+		}
+		role, won := playerResult(inGameData.Name, gameOver)
+		unlinked = append(unlinked, &storage.UnlinkedUserGame{
+			GuildID:     gid,
+			GameID:      dgs.MatchID,
+			PlayerName:  inGameData.Name,
+			PlayerColor: int16(inGameData.Color),
+			PlayerRole:  int16(role),
+			PlayerWon:   won,
+		})
+	}
+	if len(unlinked) == 0 {
+		return
+	}
+	err = storage.AddUnlinkedUserGames(psql.Pool, unlinked)
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (bot *Bot) deleteUserUnlinkedClaims(userID string) error {
+	uid, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		return err
+	}
+	return storage.DeleteUnlinkedClaimsForUser(bot.PostgresInterface.Pool, uid)
+}
+
+// ignoreUnlinkedNames deletes the unlinked games played under the in-game names linked to a user on a guild, and keeps
+// any more from being recorded under them. It has to run before the user's links are deleted
+func (bot *Bot) ignoreUnlinkedNames(guildID, userID string) error {
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		return err
+	}
+	links, err := bot.RedisInterface.GetUsernameOrUserIDMappings(guildID, userID)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(links))
+	for name := range links {
+		names = append(names, name)
+	}
+	return storage.IgnoreUnlinkedNames(bot.PostgresInterface.Pool, gid, names)
+}
+
+func (bot *Bot) deleteGuildUnlinked(guildID uint64) error {
+	return storage.DeleteUnlinkedForGuild(bot.PostgresInterface.Pool, guildID)
+}
+
+func (bot *Bot) HandleStatsUnlinkedCommand(guildID, action string, isAdmin bool, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Stats.Name, err, sett)
+	}
+	switch action {
+	case command.UnlinkedEnable, command.UnlinkedDisable:
+		if !isAdmin {
+			return command.InsufficientPermissionsResponse(sett)
+		}
+		enabled := action == command.UnlinkedEnable
+		err = storage.SetUnlinkedStatsEnabled(bot.PostgresInterface.Pool, gid, enabled)
+		if err != nil {
+			log.Println(err)
+			return command.PrivateErrorResponse(command.Stats.Name, err, sett)
+		}
+		if enabled {
+			return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+				ID:    "commands.stats.unlinked.enabled",
+				Other: "I'll record the games of unlinked players by their in-game name. Players can add them to their stats with `/stats claim`",
+			}))
+		}
+		return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.stats.unlinked.disabled",
+			Other: "I won't record the games of unlinked players anymore. The games already recorded can still be claimed",
+		}))
+	}
+
+	enabled, err := storage.IsUnlinkedStatsEnabled(bot.PostgresInterface.Pool, gid)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Stats.Name, err, sett)
+	}
+	players, err := storage.GetUnlinkedPlayers(bot.PostgresInterface.Pool, gid, UnlinkedListSize)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Stats.Name, err, sett)
+	}
+	var desc string
+	if enabled {
+		desc = sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.stats.unlinked.list.on",
+			Other: "Games of unlinked players are being recorded",
+		})
+	} else {
+		desc = sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.stats.unlinked.list.off",
+			Other: "Games of unlinked players aren't being recorded; an admin can turn it on with `/stats unlinked enable`",
+		})
+	}
+	lines := make([]string, 0, len(players))
+	for _, v := range players {
+		lines = append(lines, sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.stats.unlinked.list.player",
+			Other: "`{{.Name}}`: {{.Wins}}/{{.Games}} games won",
+		}, map[string]interface{}{
+			"Name":  v.PlayerName,
+			"Wins":  v.Wins,
+			"Games": v.Games,
+		}))
+	}
+	if len(lines) > 0 {
+		desc += "\n\n" + strings.Join(lines, "\n")
+	}
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: 1 << 6, //private message
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title: sett.LocalizeMessage(&i18n.Message{
+						ID:    "commands.stats.unlinked.list.title",
+						Other: "Unlinked Players",
+					}),
+					Description: desc,
+					Color:       10181046, // PURPLE
+				},
+			},
+		},
+	}
+}
+
+// HandleStatsClaimCommand asks the guild's admins to approve adding the unlinked games played under an in-game name to
+// a user's stats
+func (bot *Bot) HandleStatsClaimCommand(guildID, userID, name string, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Stats.Name, err, sett)
+	}
+	uid, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Stats.Name, err, sett)
+	}
+	if user, err := bot.PostgresInterface.GetUserByString(userID); err == nil && !user.Opt {
+		return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.stats.claim.optedOut",
+			Other: "You're opted out of data collection for game statistics; opt in with `/privacy opt-in` first",
+		}))
+	}
+	games, err := storage.CountUnlinkedGames(bot.PostgresInterface.Pool, gid, name)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Stats.Name, err, sett)
+	}
+	if games == 0 {
+		return command.PrivateResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.stats.claim.notFound",
+			Other: "There aren't any unlinked games played as `{{.Name}}` on this server",
+		}, map[string]interface{}{
+			"Name": name,
+		}))
+	}
+	claim := &storage.UnlinkedClaim{
+		GuildID:     gid,
+		UserID:      uid,
+		PlayerName:  name,
+		RequestTime: int32(time.Now().Unix()),
+	}
+	claim.ClaimID, err = storage.AddUnlinkedClaim(bot.PostgresInterface.Pool, claim)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Stats.Name, err, sett)
+	}
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: sett.LocalizeMessage(&i18n.Message{
+				ID:    "commands.stats.claim.pending",
+				Other: "{{.User}} wants to add {{.Games}} unlinked games played as `{{.Name}}` to their stats. An admin has to approve it",
+			}, map[string]interface{}{
+				"User":  discord.MentionByUserID(userID),
+				"Games": games,
+				"Name":  name,
+			}),
+			Components:      unlinkedClaimComponents(claim.ClaimID, sett),
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	}
+}
+
+func unlinkedClaimComponents(claimID int64, sett *settings.GuildSettings) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					CustomID: fmt.Sprintf("%s%d", claimApprovePrefix, claimID),
+					Style:    discordgo.SuccessButton,
+					Label: sett.LocalizeMessage(&i18n.Message{
+						ID:    "commands.stats.claim.button.approve",
+						Other: "Approve",
+					}),
+				},
+				discordgo.Button{
+					CustomID: fmt.Sprintf("%s%d", claimDenyPrefix, claimID),
+					Style:    discordgo.DangerButton,
+					Label: sett.LocalizeMessage(&i18n.Message{
+						ID:    "commands.stats.claim.button.deny",
+						Other: "Deny",
+					}),
+				},
+			},
+		},
+	}
+}
+
+// handleUnlinkedClaim approves or denies the claim in a button's ID. Only admins can answer claims
+func (bot *Bot) handleUnlinkedClaim(guildID, customID string, isAdmin bool, sett *settings.GuildSettings) *discordgo.InteractionResponse {
+	if !isAdmin {
+		return command.InsufficientPermissionsResponse(sett)
+	}
+	approve := strings.HasPrefix(customID, claimApprovePrefix)
+	claimID, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimPrefix(customID, claimApprovePrefix), claimDenyPrefix), 10, 64)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	claim, err := storage.GetUnlinkedClaim(bot.PostgresInterface.Pool, gid, claimID)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Stats.Name, err, sett)
+	}
+	// someone else answered it first
+	gone := claimAnsweredResponse(sett.LocalizeMessage(&i18n.Message{
+		ID:    "commands.stats.claim.gone",
+		Other: "This claim was already answered",
+	}))
+	if claim == nil {
+		return gone
+	}
+
+	mention := discord.MentionByUserID(strconv.FormatUint(claim.UserID, 10))
+	optedOut := false
+	if approve {
+		user, err := bot.PostgresInterface.GetUserByString(strconv.FormatUint(claim.UserID, 10))
+		optedOut = err == nil && !user.Opt
+	}
+	if approve && !optedOut {
+		moved, answered, err := storage.ApproveUnlinkedClaim(bot.PostgresInterface.Pool, claim)
+		if err != nil {
+			log.Println(err)
+			return command.PrivateErrorResponse(command.Stats.Name, err, sett)
+		}
+		if !answered {
+			return gone
+		}
+		return claimAnsweredResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.stats.claim.approved",
+			Other: "Added {{.Games}} games played as `{{.Name}}` to the stats for {{.User}}",
+		}, map[string]interface{}{
+			"Games": moved,
+			"Name":  claim.PlayerName,
+			"User":  mention,
+		}))
+	}
+
+	answered, err := storage.DeleteUnlinkedClaim(bot.PostgresInterface.Pool, claimID)
+	if err != nil {
+		log.Println(err)
+		return command.PrivateErrorResponse(command.Stats.Name, err, sett)
+	}
+	if !answered {
+		return gone
+	}
+	if optedOut {
+		return claimAnsweredResponse(sett.LocalizeMessage(&i18n.Message{
+			ID:    "commands.stats.claim.approvedOptedOut",
+			Other: "{{.User}} opted out of data collection for game statistics, so no games were added",
+		}, map[string]interface{}{
+			"User": mention,
+		}))
+	}
+	return claimAnsweredResponse(sett.LocalizeMessage(&i18n.Message{
+		ID:    "commands.stats.claim.denied",
+		Other: "The claim by {{.User}} on `{{.Name}}` was denied",
+	}, map[string]interface{}{
+		"User": mention,
+		"Name": claim.PlayerName,
+	}))
+}
+
+func claimAnsweredResponse(content string) *discordgo.InteractionResponse {
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:         content,
+			Components:      []discordgo.MessageComponent{},
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	}
+}
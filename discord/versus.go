@@ -0,0 +1,154 @@
+package discord
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/automuteus/automuteus/storage"
+	"github.com/automuteus/utils/pkg/settings"
+	"github.com/bwmarrin/discordgo"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+)
+
+// VersusStatsEmbed compares two players over the games they played together on a guild
+func (bot *Bot) VersusStatsEmbed(userID, opponentID, guildID string, sett *settings.GuildSettings) *discordgo.MessageEmbed {
+	uid, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	oid, err := strconv.ParseUint(opponentID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	gid, err := strconv.ParseUint(guildID, 10, 64)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	stats, err := storage.GetVersusStats(bot.PostgresInterface.Pool, gid, uid, oid)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+
+	a := bot.MentionWithCacheData(userID, guildID, sett)
+	b := bot.MentionWithCacheData(opponentID, guildID, sett)
+	embed := &discordgo.MessageEmbed{
+		Title: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.versusStatsEmbed.Title",
+			Other: "Head-to-Head",
+		}),
+		Description: sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.versusStatsEmbed.Desc",
+			Other: "{{.A}} vs {{.B}}",
+		}, map[string]interface{}{
+			"A": "<@!" + userID + ">",
+			"B": "<@!" + opponentID + ">",
+		}),
+		Color: 3066993, // GREEN
+	}
+	if stats.Games == 0 {
+		embed.Description += "\n\n" + sett.LocalizeMessage(&i18n.Message{
+			ID:    "responses.versusStatsEmbed.NoGames",
+			Other: "These players haven't played a game together on this server yet",
+		})
+		return embed
+	}
+	embed.Description += "\n\n" + sett.LocalizeMessage(&i18n.Message{
+		ID:    "responses.versusStatsEmbed.Note",
+		Other: "Deaths and exiles are counted for games the players were on opposite teams, since who killed or voted for whom isn't recorded",
+	})
+
+	games := sett.LocalizeMessage(&i18n.Message{
+		ID:    "responses.stats.Games",
+		Other: "Games",
+	})
+	won := sett.LocalizeMessage(&i18n.Message{
+		ID:    "responses.stats.Won",
+		Other: "Won",
+	})
+	opponentWins := stats.AImposterWins + (stats.BImposterGames - stats.BImposterWins)
+	embed.Fields = []*discordgo.MessageEmbedField{
+		{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.versusStatsEmbed.GamesTogether",
+				Other: "Games Together",
+			}),
+			Value:  fmt.Sprintf("%d", stats.Games),
+			Inline: true,
+		},
+		{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.versusStatsEmbed.Teammates",
+				Other: "As Teammates",
+			}),
+			Value:  fmt.Sprintf("%d/%d %s | %.0f%%", stats.TeammateWins, stats.TeammateGames, won, percent(stats.TeammateWins, stats.TeammateGames)),
+			Inline: true,
+		},
+		{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.versusStatsEmbed.Opponents",
+				Other: "As Opponents",
+			}),
+			Value: fmt.Sprintf("%s: %d\n%s: %d\n(%d %s)", a, opponentWins, b, stats.OpponentGames()-opponentWins,
+				stats.OpponentGames(), games),
+			Inline: true,
+		},
+		{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.versusStatsEmbed.AsImposter",
+				Other: "{{.Player}} as Imposter",
+			}, map[string]interface{}{
+				"Player": bot.displayName(userID, guildID),
+			}),
+			Value:  fmt.Sprintf("%d/%d %s | %.0f%%", stats.AImposterWins, stats.AImposterGames, won, percent(stats.AImposterWins, stats.AImposterGames)),
+			Inline: true,
+		},
+		{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.versusStatsEmbed.AsImposter",
+				Other: "{{.Player}} as Imposter",
+			}, map[string]interface{}{
+				"Player": bot.displayName(opponentID, guildID),
+			}),
+			Value:  fmt.Sprintf("%d/%d %s | %.0f%%", stats.BImposterWins, stats.BImposterGames, won, percent(stats.BImposterWins, stats.BImposterGames)),
+			Inline: true,
+		},
+		{
+			Name:   "\u200b",
+			Value:  "\u200b",
+			Inline: true,
+		},
+		{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.versusStatsEmbed.Killed",
+				Other: "Killed With the Other as Imposter",
+			}),
+			Value:  fmt.Sprintf("%s: %d\n%s: %d", a, stats.AKilled, b, stats.BKilled),
+			Inline: true,
+		},
+		{
+			Name: sett.LocalizeMessage(&i18n.Message{
+				ID:    "responses.versusStatsEmbed.Exiled",
+				Other: "Exiled as Opponents",
+			}),
+			Value:  fmt.Sprintf("%s: %d\n%s: %d", a, stats.AExiled, b, stats.BExiled),
+			Inline: true,
+		},
+	}
+	return embed
+}
+
+// displayName is the user's nickname or username, for places mentions don't render, like embed field names
+func (bot *Bot) displayName(userID, guildID string) string {
+	userName, nickname, _ := bot.CheckOrFetchCachedUserData(userID, guildID)
+	if nickname != "" {
+		return nickname
+	} else if userName != "" {
+		return userName
+	}
+	return userID
+}
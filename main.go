@@ -2,6 +2,7 @@ package main
 
 import (
 	"errors"
+	"flag"
 	"github.com/automuteus/automuteus/discord/command"
 	"github.com/automuteus/utils/pkg/locale"
 	storage2 "github.com/automuteus/utils/pkg/storage"
@@ -30,12 +31,30 @@ var (
 
 const DefaultURL = "http://localhost:8123"
 
-type registeredCommand struct {
-	GuildID            string
-	ApplicationCommand *discordgo.ApplicationCommand
-}
+var syncCommandsOnly = flag.Bool("sync-commands", false, "sync the slash commands with Discord, then exit without starting the bot")
+var backfillRatingsOnly = flag.Bool("backfill-ratings", false, "rebuild every player rating from the recorded games, then exit without starting the bot")
 
 func main() {
+	flag.Parse()
+	if *syncCommandsOnly {
+		err := syncCommandsMain()
+		if err != nil {
+			log.Println("Syncing commands exited with the following error:")
+			log.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *backfillRatingsOnly {
+		err := backfillRatingsMain()
+		if err != nil {
+			log.Println("Backfilling ratings exited with the following error:")
+			log.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// seed the rand generator (used for making connection codes)
 	rand.Seed(time.Now().Unix())
 	err := discordMainWrapper()
@@ -140,22 +159,7 @@ func discordMainWrapper() error {
 	locale.InitLang(os.Getenv("LOCALE_PATH"), os.Getenv("BOT_LANG"))
 
 	psql := storage2.PsqlInterface{}
-	pAddr := os.Getenv("POSTGRES_ADDR")
-	if pAddr == "" {
-		return errors.New("no POSTGRES_ADDR specified; exiting")
-	}
-
-	pUser := os.Getenv("POSTGRES_USER")
-	if pUser == "" {
-		return errors.New("no POSTGRES_USER specified; exiting")
-	}
-
-	pPass := os.Getenv("POSTGRES_PASS")
-	if pPass == "" {
-		return errors.New("no POSTGRES_PASS specified; exiting")
-	}
-
-	err = psql.Init(storage2.ConstructPsqlConnectURL(pAddr, pUser, pPass))
+	err = initPostgres(&psql)
 	if err != nil {
 		return err
 	}
@@ -180,57 +184,106 @@ func discordMainWrapper() error {
 		log.Fatal("bot failed to initialize; did you provide a valid Discord Bot Token?")
 	}
 
-	// empty string entry = global
-	slashCommandGuildIds := []string{""}
-	slashCommandGuildIdStr := strings.ReplaceAll(os.Getenv("SLASH_COMMAND_GUILD_IDS"), " ", "")
-	if slashCommandGuildIdStr != "" {
-		slashCommandGuildIds = strings.Split(slashCommandGuildIdStr, ",")
-	}
-
-	var registeredCommands []registeredCommand
 	if !isOfficial || shardID == 0 {
-		for _, guild := range slashCommandGuildIds {
-			for _, v := range command.All {
-				if guild == "" {
-					log.Printf("Registering command %s GLOBALLY\n", v.Name)
-				} else {
-					log.Printf("Registering command %s in guild %s\n", v.Name, guild)
-				}
-
-				id, err := bot.PrimarySession.ApplicationCommandCreate(bot.PrimarySession.State.User.ID, guild, v)
-				if err != nil {
-					log.Panicf("Cannot create command: %v", err)
-				} else {
-					registeredCommands = append(registeredCommands, registeredCommand{
-						GuildID:            guild,
-						ApplicationCommand: id,
-					})
-				}
-			}
+		localizeCommands()
+		err = command.SyncCommandsForGuilds(bot.PrimarySession, bot.PrimarySession.State.User.ID, slashCommandGuildIDs(), command.All)
+		if err != nil {
+			log.Panicf("Cannot sync commands: %v", err)
 		}
-		log.Println("Finishing registering all commands!")
+		log.Println("Finished syncing all commands!")
 	}
 
 	<-sc
 	log.Printf("Received Sigterm or Kill signal. Bot will terminate in 1 second")
 	time.Sleep(time.Second)
 
-	if !isOfficial {
-		log.Println("Deleting slash commands")
-		for _, v := range registeredCommands {
-			if v.GuildID == "" {
-				log.Printf("Deleting command %s GLOBALLY\n", v.ApplicationCommand.Name)
-			} else {
-				log.Printf("Deleting command %s on guild %s\n", v.ApplicationCommand.Name, v.GuildID)
-			}
-			err = bot.PrimarySession.ApplicationCommandDelete(v.ApplicationCommand.ApplicationID, v.GuildID, v.ApplicationCommand.ID)
-			if err != nil {
-				log.Println(err)
-			}
+	bot.Close()
+	return nil
+}
+
+// slashCommandGuildIDs returns the guilds commands should be registered in. An empty string entry means global
+func slashCommandGuildIDs() []string {
+	slashCommandGuildIDStr := strings.ReplaceAll(os.Getenv("SLASH_COMMAND_GUILD_IDS"), " ", "")
+	if slashCommandGuildIDStr != "" {
+		return strings.Split(slashCommandGuildIDStr, ",")
+	}
+	return []string{""}
+}
+
+func localizeCommands() {
+	langs := make([]string, 0, len(locale.GetLanguages()))
+	for lang := range locale.GetLanguages() {
+		langs = append(langs, lang)
+	}
+	command.LocalizeCommands(command.All, locale.GetBundle(), langs)
+}
+
+// syncCommandsMain only syncs the slash commands with Discord, without connecting to the gateway or any storage
+func syncCommandsMain() error {
+	discordToken := os.Getenv("DISCORD_BOT_TOKEN")
+	if discordToken == "" {
+		return errors.New("no DISCORD_BOT_TOKEN provided")
+	}
+	locale.InitLang(os.Getenv("LOCALE_PATH"), os.Getenv("BOT_LANG"))
+	localizeCommands()
+
+	s, err := discordgo.New("Bot " + discordToken)
+	if err != nil {
+		return err
+	}
+	app, err := s.Application("@me")
+	if err != nil {
+		return err
+	}
+	err = command.SyncCommandsForGuilds(s, app.ID, slashCommandGuildIDs(), command.All)
+	if err != nil {
+		return err
+	}
+	log.Println("Finished syncing all commands!")
+	return nil
+}
+
+func initPostgres(psql *storage2.PsqlInterface) error {
+	pAddr := os.Getenv("POSTGRES_ADDR")
+	if pAddr == "" {
+		return errors.New("no POSTGRES_ADDR specified; exiting")
+	}
+
+	pUser := os.Getenv("POSTGRES_USER")
+	if pUser == "" {
+		return errors.New("no POSTGRES_USER specified; exiting")
+	}
+
+	pPass := os.Getenv("POSTGRES_PASS")
+	if pPass == "" {
+		return errors.New("no POSTGRES_PASS specified; exiting")
+	}
+
+	return psql.Init(storage2.ConstructPsqlConnectURL(pAddr, pUser, pPass))
+}
+
+// backfillRatingsMain replays every finished game to rebuild the player ratings, for games recorded before ratings
+// existed (or after the rating math changes). Only Postgres is needed
+func backfillRatingsMain() error {
+	psql := storage2.PsqlInterface{}
+	err := initPostgres(&psql)
+	if err != nil {
+		return err
+	}
+	defer psql.Close()
+
+	if os.Getenv("AUTOMUTEUS_OFFICIAL") == "" {
+		err = psql.LoadAndExecFromFile("./storage/postgres.sql")
+		if err != nil {
+			return err
 		}
-		log.Println("Finished deleting all commands")
 	}
 
-	bot.Close()
+	start := time.Now()
+	games, err := storage.ReplayRatings(psql.Pool)
+	if err != nil {
+		return err
+	}
+	log.Printf("Finished rating %d games in %s\n", games, time.Since(start).Round(time.Millisecond))
 	return nil
 }
@@ -1,6 +1,10 @@
 package metrics
 
 import (
+	"encoding/json"
+	"github.com/automuteus/automuteus/discord/command"
+	"github.com/automuteus/automuteus/discord/setting"
+	"github.com/automuteus/utils/pkg/locale"
 	"github.com/gorilla/mux"
 	"log"
 	"net/http"
@@ -8,7 +12,9 @@ import (
 
 var GlobalReady = false
 
-func StartHealthCheckServer(port string) {
+// StartHealthCheckServer serves the health checks and website endpoints. profile serves the public profile pages at
+// /profile/{userID}, if it isn't nil
+func StartHealthCheckServer(port string, profile http.HandlerFunc) {
 	r := mux.NewRouter()
 
 	r.HandleFunc("/live", func(w http.ResponseWriter, r *http.Request) {
@@ -43,5 +49,36 @@ func StartHealthCheckServer(port string) {
 		}
 	})
 
+	// used by the website to list the current commands and settings
+	r.HandleFunc("/commands", func(w http.ResponseWriter, r *http.Request) {
+		lang := r.URL.Query().Get("Accept-Language")
+		if lang == "" {
+			lang = r.Header.Get("Accept-Language")
+		}
+		if lang == "" {
+			lang = locale.DefaultLang
+		}
+		jsonBytes, err := json.Marshal(command.NewCatalogue(locale.GetBundle(), lang))
+		if err != nil {
+			log.Println(err)
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(jsonBytes)
+	}).Methods("GET")
+
+	r.HandleFunc("/settings/schema", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/schema+json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(setting.SettingsSchema)
+	}).Methods("GET")
+
+	if profile != nil {
+		r.HandleFunc("/profile/{userID:[0-9]+}", profile).Methods("GET")
+	}
+
 	http.ListenAndServe(":"+port, r)
 }
@@ -10,6 +10,7 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"sync/atomic"
 )
 
 type EventType int
@@ -34,18 +35,31 @@ var MetricTypeStrings = []string{
 	"official_request", //must be the last request
 }
 
+// settingsCacheHits and settingsCacheMisses count lookups in this node's in-memory guild settings cache
+var (
+	settingsCacheHits   uint64
+	settingsCacheMisses uint64
+)
+
 type Collector struct {
-	counterDesc *prometheus.Desc
-	client      *redis.Client
-	commit      string
-	nodeID      string
+	counterDesc       *prometheus.Desc
+	settingsCacheDesc *prometheus.Desc
+	client            *redis.Client
+	commit            string
+	nodeID            string
 }
 
 func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.counterDesc
+	ch <- c.settingsCacheDesc
 }
 
 func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.settingsCacheDesc, prometheus.CounterValue,
+		float64(atomic.LoadUint64(&settingsCacheHits)), c.nodeID, "hit")
+	ch <- prometheus.MustNewConstMetric(c.settingsCacheDesc, prometheus.CounterValue,
+		float64(atomic.LoadUint64(&settingsCacheMisses)), c.nodeID, "miss")
+
 	official := int64(0)
 	for i, str := range MetricTypeStrings {
 		if i != int(OfficialRequest) {
@@ -93,11 +107,21 @@ func RecordDiscordRequests(client *redis.Client, requestType EventType, num int6
 	}
 }
 
+// RecordSettingsCache counts a guild settings lookup as a cache hit or miss
+func RecordSettingsCache(hit bool) {
+	if hit {
+		atomic.AddUint64(&settingsCacheHits, 1)
+	} else {
+		atomic.AddUint64(&settingsCacheMisses, 1)
+	}
+}
+
 func NewCollector(client *redis.Client, nodeID string) *Collector {
 	return &Collector{
-		counterDesc: prometheus.NewDesc("discord_requests_by_node_and_type", "Number of discord requests made, differentiated by node/type", []string{"nodeID", "type"}, nil),
-		client:      client,
-		nodeID:      nodeID,
+		counterDesc:       prometheus.NewDesc("discord_requests_by_node_and_type", "Number of discord requests made, differentiated by node/type", []string{"nodeID", "type"}, nil),
+		settingsCacheDesc: prometheus.NewDesc("settings_cache_requests_by_node_and_result", "Number of guild settings lookups, differentiated by node/cache hit or miss", []string{"nodeID", "result"}, nil),
+		client:            client,
+		nodeID:            nodeID,
 	}
 }
 
@@ -0,0 +1,88 @@
+package rating
+
+import (
+	"math"
+)
+
+const (
+	// InitialRating is the rating every player starts at, for each role
+	InitialRating = 1500.0
+
+	// K is how far a single game can move an established player's rating
+	K = 32.0
+
+	// ProvisionalK is used instead of K while a player has fewer than ProvisionalGames games in a role, so new players
+	// converge on their real skill quickly
+	ProvisionalK = 48.0
+
+	ProvisionalGames = 10
+)
+
+// Player is a single player's standing in the role they played, going into a game
+type Player struct {
+	ID     uint64
+	Role   int16
+	Won    bool
+	Rating float64
+	Games  int32
+}
+
+// Result is a player's rating after a game
+type Result struct {
+	ID     uint64
+	Role   int16
+	Before float64
+	After  float64
+}
+
+// Expected returns the probability of a side rated a beating a side rated b
+func Expected(a, b float64) float64 {
+	return 1.0 / (1.0 + math.Pow(10, (b-a)/400.0))
+}
+
+func kFactor(games int32) float64 {
+	if games < ProvisionalGames {
+		return ProvisionalK
+	}
+	return K
+}
+
+// Update rates a single game. Every player is compared as part of their team (all the players that share their role)
+// against the average rating of the opposing team, so a crewmate that beats a strong pair of impostors gains more than
+// one that beats a weak pair. Games that don't have both teams represented aren't rated, and return nil
+func Update(players []Player) []Result {
+	sums := map[int16]float64{}
+	counts := map[int16]int{}
+	for _, p := range players {
+		sums[p.Role] += p.Rating
+		counts[p.Role]++
+	}
+	if len(counts) != 2 {
+		return nil
+	}
+
+	results := make([]Result, 0, len(players))
+	for _, p := range players {
+		opposingSum, opposingCount := 0.0, 0
+		for role, sum := range sums {
+			if role != p.Role {
+				opposingSum += sum
+				opposingCount += counts[role]
+			}
+		}
+		teamAvg := sums[p.Role] / float64(counts[p.Role])
+		opposingAvg := opposingSum / float64(opposingCount)
+
+		score := 0.0
+		if p.Won {
+			score = 1.0
+		}
+		results = append(results, Result{
+			ID:     p.ID,
+			Role:   p.Role,
+			Before: p.Rating,
+			After:  p.Rating + kFactor(p.Games)*(score-Expected(teamAvg, opposingAvg)),
+		})
+	}
+	return results
+}
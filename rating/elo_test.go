@@ -0,0 +1,65 @@
+package rating
+
+import (
+	"math"
+	"testing"
+
+	"github.com/automuteus/utils/pkg/game"
+)
+
+func TestExpected(t *testing.T) {
+	if e := Expected(1500, 1500); e != 0.5 {
+		t.Errorf("Expected evenly rated sides to have a 0.5 expectation, got %f", e)
+	}
+	if e := Expected(1900, 1500); math.Abs(e-0.909) > 0.001 {
+		t.Errorf("Expected a 400 point favorite to have a ~0.909 expectation, got %f", e)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	players := []Player{
+		{ID: 1, Role: int16(game.CrewmateRole), Won: true, Rating: InitialRating, Games: 0},
+		{ID: 2, Role: int16(game.CrewmateRole), Won: true, Rating: InitialRating, Games: 20},
+		{ID: 3, Role: int16(game.ImposterRole), Won: false, Rating: InitialRating, Games: 20},
+	}
+	results := Update(players)
+	if len(results) != len(players) {
+		t.Fatalf("Expected %d results, got %d", len(players), len(results))
+	}
+	if results[0].After != InitialRating+ProvisionalK/2 {
+		t.Errorf("Expected a provisional player to gain %f, got %f", ProvisionalK/2, results[0].After-InitialRating)
+	}
+	if results[1].After != InitialRating+K/2 {
+		t.Errorf("Expected an established player to gain %f, got %f", K/2, results[1].After-InitialRating)
+	}
+	if results[2].After != InitialRating-K/2 {
+		t.Errorf("Expected the losing impostor to lose %f, got %f", K/2, InitialRating-results[2].After)
+	}
+	if results[2].Before != InitialRating {
+		t.Errorf("Expected the rating before the game to be kept, got %f", results[2].Before)
+	}
+}
+
+func TestUpdateUpset(t *testing.T) {
+	favorite := Update([]Player{
+		{ID: 1, Role: int16(game.CrewmateRole), Won: true, Rating: 1800, Games: 20},
+		{ID: 2, Role: int16(game.ImposterRole), Won: false, Rating: 1400, Games: 20},
+	})
+	underdog := Update([]Player{
+		{ID: 1, Role: int16(game.CrewmateRole), Won: false, Rating: 1800, Games: 20},
+		{ID: 2, Role: int16(game.ImposterRole), Won: true, Rating: 1400, Games: 20},
+	})
+	if favorite[1].Before-favorite[1].After >= underdog[1].After-underdog[1].Before {
+		t.Error("Expected an upset win to be worth more than an expected loss costs")
+	}
+}
+
+func TestUpdateOneSided(t *testing.T) {
+	results := Update([]Player{
+		{ID: 1, Role: int16(game.CrewmateRole), Won: true, Rating: InitialRating},
+		{ID: 2, Role: int16(game.CrewmateRole), Won: true, Rating: InitialRating},
+	})
+	if results != nil {
+		t.Errorf("Expected a game with only one team to not be rated, got %v", results)
+	}
+}
@@ -0,0 +1,187 @@
+package render
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+)
+
+const (
+	CardWidth  = 640
+	CardHeight = 360
+
+	// MaxCardColors is how many favorite colors fit on a player card
+	MaxCardColors = 3
+)
+
+// CardLabels are the (localized) words drawn on a player card
+type CardLabels struct {
+	Games          string
+	Total          string
+	Crewmate       string
+	Imposter       string
+	FavoriteColors string
+	Rating         string
+	NoRating       string
+}
+
+// ColorShare is how often a player played as a color
+type ColorShare struct {
+	Color int
+	Share float64
+}
+
+// PlayerCard is a player's stats, drawn as an image
+type PlayerCard struct {
+	Name     string
+	Subtitle string
+
+	Games         int64
+	Wins          int64
+	CrewmateGames int64
+	CrewmateWins  int64
+	ImposterGames int64
+	ImposterWins  int64
+
+	// Colors are the player's favorite colors, most played first
+	Colors []ColorShare
+
+	// CrewmateRatings and ImposterRatings are the player's rating after each game, oldest first
+	CrewmateRatings []float64
+	ImposterRatings []float64
+
+	Labels CardLabels
+}
+
+// Drawable is whether every word on the card can be drawn with the card's font
+func (c *PlayerCard) Drawable() bool {
+	return CanDraw(c.Name, c.Subtitle, c.Labels.Games, c.Labels.Total, c.Labels.Crewmate, c.Labels.Imposter,
+		c.Labels.FavoriteColors, c.Labels.Rating, c.Labels.NoRating)
+}
+
+func (c *PlayerCard) Render() image.Image {
+	img := newCanvas(CardWidth, CardHeight)
+	c.drawAvatar(img, image.Pt(72, 72), 48)
+
+	drawText(img, 136, 30, fitText(c.Name, CardWidth-160, 2), Text, 2)
+	drawText(img, 136, 62, fitText(c.Subtitle, CardWidth-160, 1), Muted, 1)
+	drawText(img, 136, 86, fmt.Sprintf("%s: %d", c.Labels.Games, c.Games), Text, 1)
+
+	rates := []struct {
+		label      string
+		wins, game int64
+		color      color.Color
+	}{
+		{c.Labels.Total, c.Wins, c.Games, Total},
+		{c.Labels.Crewmate, c.CrewmateWins, c.CrewmateGames, Crewmate},
+		{c.Labels.Imposter, c.ImposterWins, c.ImposterGames, Imposter},
+	}
+	for i, v := range rates {
+		drawWinRate(img, image.Rect(24, 136+i*40, 316, 166+i*40), v.label, v.wins, v.game, v.color)
+	}
+
+	drawText(img, 24, 262, c.Labels.FavoriteColors, Muted, 1)
+	for i, v := range c.Colors {
+		if i == MaxCardColors {
+			break
+		}
+		x := 24 + i*80
+		if emoji := ColorEmoji(v.Color, true); emoji != nil {
+			drawImage(img, image.Rect(x, 280, x+48, 328), emoji)
+		}
+		drawText(img, x+4, 334, fmt.Sprintf("%.0f%%", v.Share), Text, 1)
+	}
+
+	c.drawRatingTrend(img, image.Rect(340, 136, CardWidth-24, CardHeight-24))
+	return img
+}
+
+// drawAvatar draws a placeholder avatar: the player's favorite crewmate, or their initial if they don't have one
+func (c *PlayerCard) drawAvatar(img *image.RGBA, center image.Point, radius int) {
+	fillCircle(img, center, radius, Panel)
+	if len(c.Colors) > 0 {
+		if emoji := ColorEmoji(c.Colors[0].Color, true); emoji != nil {
+			inset := radius * 2 / 3
+			drawImage(img, image.Rect(center.X-inset, center.Y-inset, center.X+inset, center.Y+inset), emoji)
+			return
+		}
+	}
+	initial := "?"
+	if name := strings.TrimSpace(c.Name); name != "" {
+		initial = strings.ToUpper(string([]rune(name)[0]))
+	}
+	const scale = 4
+	drawText(img, center.X-textWidth(initial, scale)/2, center.Y-textHeight(scale)/2, initial, Text, scale)
+}
+
+func drawWinRate(img *image.RGBA, r image.Rectangle, label string, wins, games int64, c color.Color) {
+	rate := 0.0
+	if games > 0 {
+		rate = float64(wins) / float64(games)
+	}
+	drawText(img, r.Min.X, r.Min.Y, label, Text, 1)
+	value := fmt.Sprintf("%d/%d | %.0f%%", wins, games, 100*rate)
+	drawText(img, r.Max.X-textWidth(value, 1), r.Min.Y, value, Muted, 1)
+	bar := image.Rect(r.Min.X, r.Max.Y-10, r.Max.X, r.Max.Y)
+	fillRect(img, bar, Panel)
+	bar.Max.X = bar.Min.X + int(math.Round(float64(bar.Dx())*rate))
+	fillRect(img, bar, c)
+}
+
+func (c *PlayerCard) drawRatingTrend(img *image.RGBA, r image.Rectangle) {
+	fillRect(img, r, Panel)
+	drawText(img, r.Min.X+8, r.Min.Y+6, c.Labels.Rating, Text, 1)
+	legend := r.Max.X - 8
+	for _, v := range []struct {
+		label string
+		color color.Color
+	}{{c.Labels.Imposter, Imposter}, {c.Labels.Crewmate, Crewmate}} {
+		legend -= textWidth(v.label, 1)
+		drawText(img, legend, r.Min.Y+6, v.label, v.color, 1)
+		legend -= 12
+	}
+
+	plot := image.Rect(r.Min.X+44, r.Min.Y+28, r.Max.X-10, r.Max.Y-10)
+	if len(c.CrewmateRatings) == 0 && len(c.ImposterRatings) == 0 {
+		drawText(img, plot.Min.X, plot.Min.Y+plot.Dy()/2, fitText(c.Labels.NoRating, plot.Dx(), 1), Muted, 1)
+		return
+	}
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, series := range [][]float64{c.CrewmateRatings, c.ImposterRatings} {
+		for _, v := range series {
+			low = math.Min(low, v)
+			high = math.Max(high, v)
+		}
+	}
+	if high-low < 1 {
+		low -= 10
+		high += 10
+	}
+	drawText(img, r.Min.X+6, plot.Min.Y-6, fmt.Sprintf("%.0f", high), Muted, 1)
+	drawText(img, r.Min.X+6, plot.Max.Y-7, fmt.Sprintf("%.0f", low), Muted, 1)
+	drawLine(img, image.Pt(plot.Min.X, plot.Max.Y), image.Pt(plot.Max.X, plot.Max.Y), 1, Muted)
+
+	drawSeries(img, plot, c.CrewmateRatings, low, high, Crewmate)
+	drawSeries(img, plot, c.ImposterRatings, low, high, Imposter)
+}
+
+// drawSeries draws values as a line across the whole width of r, scaled so low is at the bottom and high at the top
+func drawSeries(img *image.RGBA, r image.Rectangle, values []float64, low, high float64, c color.Color) {
+	point := func(i int) image.Point {
+		x := r.Min.X
+		if len(values) > 1 {
+			x += i * r.Dx() / (len(values) - 1)
+		}
+		y := r.Max.Y - int(math.Round((values[i]-low)/(high-low)*float64(r.Dy())))
+		return image.Pt(x, y)
+	}
+	if len(values) == 1 {
+		fillCircle(img, point(0), 3, c)
+		return
+	}
+	for i := 1; i < len(values); i++ {
+		drawLine(img, point(i-1), point(i), 2, c)
+	}
+}
@@ -0,0 +1,78 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+const (
+	ChartWidth = 640
+
+	chartHeader  = 64
+	chartBarRow  = 28
+	chartPadding = 24
+)
+
+// Bar is one row of a BarChart. Text is drawn next to the bar, and defaults to nothing
+type Bar struct {
+	Label string
+	Value float64
+	Text  string
+}
+
+// BarChart is a horizontal bar chart, like a guild's leaderboard
+type BarChart struct {
+	Title    string
+	Subtitle string
+	Bars     []Bar
+
+	// Max is the value of a full bar. If it's 0, the largest value is used
+	Max   float64
+	Color color.Color
+}
+
+// Drawable is whether every word on the chart can be drawn with the chart's font
+func (c *BarChart) Drawable() bool {
+	if !CanDraw(c.Title, c.Subtitle) {
+		return false
+	}
+	for _, v := range c.Bars {
+		if !CanDraw(v.Label, v.Text) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *BarChart) Render() image.Image {
+	height := chartHeader + len(c.Bars)*chartBarRow + chartPadding
+	img := newCanvas(ChartWidth, height)
+	drawText(img, chartPadding, 16, fitText(c.Title, ChartWidth-2*chartPadding, 2), Text, 2)
+	drawText(img, chartPadding, 44, fitText(c.Subtitle, ChartWidth-2*chartPadding, 1), Muted, 1)
+
+	full := c.Max
+	if full <= 0 {
+		for _, v := range c.Bars {
+			full = math.Max(full, v.Value)
+		}
+	}
+	barColor := c.Color
+	if barColor == nil {
+		barColor = Total
+	}
+
+	const labelWidth, valueWidth = 160, 96
+	for i, v := range c.Bars {
+		y := chartHeader + i*chartBarRow
+		drawText(img, chartPadding, y+6, fitText(v.Label, labelWidth-8, 1), Text, 1)
+		bar := image.Rect(chartPadding+labelWidth, y+4, ChartWidth-chartPadding-valueWidth, y+chartBarRow-4)
+		fillRect(img, bar, Panel)
+		if full > 0 {
+			bar.Max.X = bar.Min.X + int(math.Round(float64(bar.Dx())*math.Min(v.Value/full, 1)))
+			fillRect(img, bar, barColor)
+		}
+		drawText(img, ChartWidth-chartPadding-valueWidth+8, y+6, v.Text, Muted, 1)
+	}
+	return img
+}
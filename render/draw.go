@@ -0,0 +1,242 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/automuteus/automuteus/assets"
+	"github.com/automuteus/utils/pkg/game"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// textFont is the Go font, which covers Latin, Greek and Cyrillic but not scripts like CJK, Arabic or Hebrew; anything
+// it doesn't cover would be drawn as a box, so check CanDraw first
+var textFont = mustParseFont(goregular.TTF)
+
+// textSize is the font size of text drawn at 1x, in pixels
+const textSize = 12
+
+func mustParseFont(ttf []byte) *opentype.Font {
+	f, err := opentype.Parse(ttf)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return f
+}
+
+// newFace returns the face to draw text at the given scale with. Faces aren't safe for concurrent use, unlike the font,
+// so every call gets its own
+func newFace(scale int) font.Face {
+	face, err := opentype.NewFace(textFont, &opentype.FaceOptions{
+		Size:    float64(textSize * scale),
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		// only happens for invalid options
+		log.Fatal(err)
+	}
+	return face
+}
+
+var (
+	Background = color.RGBA{R: 0x2f, G: 0x31, B: 0x36, A: 0xff}
+	Panel      = color.RGBA{R: 0x20, G: 0x22, B: 0x25, A: 0xff}
+	Text       = color.RGBA{R: 0xdc, G: 0xdd, B: 0xde, A: 0xff}
+	Muted      = color.RGBA{R: 0x8e, G: 0x92, B: 0x97, A: 0xff}
+	Crewmate   = color.RGBA{R: 0x3b, G: 0xa5, B: 0xdc, A: 0xff}
+	Imposter   = color.RGBA{R: 0xed, G: 0x42, B: 0x45, A: 0xff}
+	Total      = color.RGBA{R: 0x2e, G: 0xcc, B: 0x71, A: 0xff}
+)
+
+// WritePNG encodes an image rendered by this package
+func WritePNG(w io.Writer, img image.Image) error {
+	return png.Encode(w, img)
+}
+
+// PNG is WritePNG into a buffer, ready to be attached to a message
+func PNG(img image.Image) (*bytes.Buffer, error) {
+	buf := bytes.NewBuffer([]byte{})
+	err := WritePNG(buf, img)
+	return buf, err
+}
+
+func newCanvas(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fillRect(img, img.Bounds(), Background)
+	return img
+}
+
+func fillRect(dst draw.Image, r image.Rectangle, c color.Color) {
+	draw.Draw(dst, r, image.NewUniform(c), image.Point{}, draw.Over)
+}
+
+func fillCircle(dst draw.Image, center image.Point, radius int, c color.Color) {
+	for y := -radius; y <= radius; y++ {
+		for x := -radius; x <= radius; x++ {
+			if x*x+y*y <= radius*radius {
+				dst.Set(center.X+x, center.Y+y, c)
+			}
+		}
+	}
+}
+
+// drawLine draws a line of the given thickness between two points
+func drawLine(dst draw.Image, from, to image.Point, thickness int, c color.Color) {
+	dx, dy := abs(to.X-from.X), -abs(to.Y-from.Y)
+	sx, sy := 1, 1
+	if from.X > to.X {
+		sx = -1
+	}
+	if from.Y > to.Y {
+		sy = -1
+	}
+	half := thickness / 2
+	err := dx + dy
+	x, y := from.X, from.Y
+	for {
+		fillRect(dst, image.Rect(x-half, y-half, x-half+thickness, y-half+thickness), c)
+		if x == to.X && y == to.Y {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y += sy
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// CanDraw is whether the font has a glyph for every rune of every string. Names and translations it can't draw should
+// be shown as text instead
+func CanDraw(strs ...string) bool {
+	face := newFace(1)
+	defer face.Close()
+	for _, s := range strs {
+		for _, r := range s {
+			if _, ok := face.GlyphAdvance(r); !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// textWidth is how wide s is when drawn at the given scale
+func textWidth(s string, scale int) int {
+	face := newFace(scale)
+	defer face.Close()
+	return font.MeasureString(face, s).Ceil()
+}
+
+// textHeight is how tall a line of text is at the given scale
+func textHeight(scale int) int {
+	face := newFace(scale)
+	defer face.Close()
+	return face.Metrics().Height.Ceil()
+}
+
+// fitText cuts s short so it's at most width pixels wide at the given scale
+func fitText(s string, width, scale int) string {
+	if textWidth(s, scale) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && textWidth(string(runes)+"...", scale) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// drawText draws s with its top left corner at (x, y)
+func drawText(dst draw.Image, x, y int, s string, c color.Color, scale int) {
+	if s == "" {
+		return
+	}
+	face := newFace(scale)
+	defer face.Close()
+	d := font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(x, y+face.Metrics().Ascent.Ceil()),
+	}
+	d.DrawString(s)
+}
+
+// drawImage scales src to fit inside r, keeping its aspect ratio, and centers it there
+func drawImage(dst draw.Image, r image.Rectangle, src image.Image) {
+	b := src.Bounds()
+	w, h := r.Dx(), r.Dy()
+	if b.Dx()*h > b.Dy()*w {
+		h = b.Dy() * w / b.Dx()
+	} else {
+		w = b.Dx() * h / b.Dy()
+	}
+	x := r.Min.X + (r.Dx()-w)/2
+	y := r.Min.Y + (r.Dy()-h)/2
+	xdraw.CatmullRom.Scale(dst, image.Rect(x, y, x+w, y+h), src, b, draw.Over, nil)
+}
+
+type emojiKey struct {
+	color int
+	alive bool
+}
+
+var (
+	emojiLock  sync.Mutex
+	emojiCache = map[emojiKey]image.Image{}
+)
+
+// ColorEmoji is the crewmate image for an in-game color, or nil if there isn't one
+func ColorEmoji(colorID int, alive bool) image.Image {
+	key := emojiKey{color: colorID, alive: alive}
+	emojiLock.Lock()
+	defer emojiLock.Unlock()
+	if img, ok := emojiCache[key]; ok {
+		return img
+	}
+	name := game.GetColorStringForInt(colorID)
+	if name == "" {
+		return nil
+	}
+	path := fmt.Sprintf("emojis/au%s.png", name)
+	if !alive {
+		path = fmt.Sprintf("emojis/au%sdead.png", name)
+	}
+	f, err := assets.Emojis.Open(path)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		log.Println(err)
+		return nil
+	}
+	emojiCache[key] = img
+	return img
+}
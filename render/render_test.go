@@ -0,0 +1,93 @@
+package render
+
+import (
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/automuteus/utils/pkg/game"
+)
+
+func TestColorEmoji(t *testing.T) {
+	for _, c := range game.ColorStrings {
+		for _, alive := range []bool{true, false} {
+			if ColorEmoji(c, alive) == nil {
+				t.Errorf("Expected an emoji for color %d (alive: %v)", c, alive)
+			}
+		}
+	}
+	if ColorEmoji(-1, true) != nil {
+		t.Error("Expected no emoji for an unknown color")
+	}
+}
+
+func TestCanDraw(t *testing.T) {
+	if !CanDraw("Crewmate Wins", "Fröhlich", "Zwycięstwa", "Зелёный", "") {
+		t.Error("Expected Latin and Cyrillic to be drawable")
+	}
+	for _, s := range []string{"クルー", "الفوز", "Soup 🍜"} {
+		if CanDraw("Games", s) {
+			t.Errorf("Expected %q not to be drawable", s)
+		}
+	}
+	chart := &BarChart{Title: "Leaderboard", Bars: []Bar{{Label: "Alice"}, {Label: "小明"}}}
+	if chart.Drawable() {
+		t.Error("Expected a chart with a name it can't draw not to be drawable")
+	}
+	card := &PlayerCard{Name: "Alice", Labels: CardLabels{Games: "ゲーム"}}
+	if card.Drawable() {
+		t.Error("Expected a card with a label it can't draw not to be drawable")
+	}
+}
+
+func TestPlayerCard(t *testing.T) {
+	cards := []*PlayerCard{
+		{Name: "", Labels: CardLabels{NoRating: "No rated games yet"}},
+		{
+			Name:          "A name that is much too long to fit on the card, even at this size",
+			Subtitle:      "All time",
+			Games:         20,
+			Wins:          12,
+			CrewmateGames: 15,
+			CrewmateWins:  8,
+			ImposterGames: 5,
+			ImposterWins:  4,
+			Colors: []ColorShare{
+				{Color: game.Red, Share: 50}, {Color: game.Lime, Share: 30}, {Color: game.Cyan, Share: 15}, {Color: game.Tan, Share: 5},
+			},
+			CrewmateRatings: []float64{1500, 1520, 1490, 1530},
+			ImposterRatings: []float64{1500},
+		},
+	}
+	for _, card := range cards {
+		img := card.Render()
+		if img.Bounds() != image.Rect(0, 0, CardWidth, CardHeight) {
+			t.Errorf("Expected a %dx%d card, got %s", CardWidth, CardHeight, img.Bounds())
+		}
+		buf, err := PNG(img)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := png.Decode(buf); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestBarChart(t *testing.T) {
+	chart := &BarChart{Title: "Leaderboard"}
+	empty := chart.Render().Bounds().Dy()
+	chart.Bars = []Bar{
+		{Label: "Alice", Value: 75, Text: "75%"},
+		{Label: "Bob", Value: 50, Text: "50%"},
+		{Label: "Carol", Value: 0, Text: "0%"},
+	}
+	img := chart.Render()
+	if img.Bounds().Dx() != ChartWidth || img.Bounds().Dy() != empty+3*chartBarRow {
+		t.Errorf("Expected the chart to grow by a row per bar, got %s", img.Bounds())
+	}
+	// the first bar is the largest, so it should fill the whole width
+	if img.At(ChartWidth-chartPadding-97, chartHeader+chartBarRow/2) != Total {
+		t.Error("Expected the largest bar to be full")
+	}
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/automuteus/automuteus/achievement"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type UserAchievement struct {
+	UserID      uint64 `db:"user_id"`
+	GuildID     uint64 `db:"guild_id"`
+	Achievement string `db:"achievement"`
+	GameID      *int64 `db:"game_id"`
+	UnlockTime  int32  `db:"unlock_time"`
+}
+
+// GetAchievementProgress returns a player's stats on a guild that achievements are unlocked with
+func GetAchievementProgress(pool *pgxpool.Pool, guildID, userID uint64) (*achievement.Progress, error) {
+	var r achievement.Progress
+	err := pgxscan.Get(context.Background(), pool, &r,
+		"WITH played AS ("+
+			"SELECT users_games.player_role, users_games.player_won, "+
+			"ROW_NUMBER() OVER (PARTITION BY users_games.player_role ORDER BY games.end_time DESC, games.game_id DESC) AS n "+
+			"FROM users_games INNER JOIN games ON games.game_id = users_games.game_id "+
+			"WHERE users_games.guild_id = $1 AND users_games.user_id = $2) "+
+			"SELECT COUNT(*) AS games, "+
+			"COUNT(*) FILTER ( WHERE player_won ) AS wins, "+
+			"COUNT(*) FILTER ( WHERE player_role = 0 AND player_won ) AS crewmate_wins, "+
+			"COUNT(*) FILTER ( WHERE player_role = 1 AND player_won ) AS imposter_wins, "+
+			// the streak ends at the most recent imposter game they lost
+			"COALESCE(MIN(n) FILTER ( WHERE player_role = 1 AND NOT player_won ) - 1, COUNT(*) FILTER ( WHERE player_role = 1 )) AS imposter_win_streak "+
+			"FROM played;",
+		guildID, userID)
+	return &r, err
+}
+
+// UnlockAchievements records that a player unlocked achievements in a game, and returns the ones they didn't have yet
+func UnlockAchievements(pool *pgxpool.Pool, guildID, userID uint64, gameID int64, ids []string, unlockTime int32) ([]string, error) {
+	var r []string
+	err := pgxscan.Select(context.Background(), pool, &r,
+		"INSERT INTO user_achievements (user_id, guild_id, achievement, game_id, unlock_time) "+
+			"SELECT $1, $2, achievement, $4, $5 FROM unnest($3::varchar[]) AS achievement "+
+			"ON CONFLICT DO NOTHING RETURNING achievement;",
+		userID, guildID, ids, gameID, unlockTime)
+	return r, err
+}
+
+// GetUserAchievements returns the achievements a player unlocked on a guild, oldest first, skipping disabled ones
+func GetUserAchievements(pool *pgxpool.Pool, guildID, userID uint64) ([]*UserAchievement, error) {
+	var r []*UserAchievement
+	err := pgxscan.Select(context.Background(), pool, &r,
+		"SELECT * FROM user_achievements WHERE guild_id = $1 AND user_id = $2 AND NOT EXISTS ("+
+			"SELECT 1 FROM disabled_achievements WHERE disabled_achievements.guild_id = user_achievements.guild_id "+
+			"AND disabled_achievements.achievement = user_achievements.achievement) "+
+			"ORDER BY unlock_time, achievement;",
+		guildID, userID)
+	return r, err
+}
+
+// GetDisabledAchievements returns the IDs of the achievements turned off on a guild
+func GetDisabledAchievements(pool *pgxpool.Pool, guildID uint64) (map[string]bool, error) {
+	var ids []string
+	err := pgxscan.Select(context.Background(), pool, &ids,
+		"SELECT achievement FROM disabled_achievements WHERE guild_id = $1;", guildID)
+	if err != nil {
+		return nil, err
+	}
+	disabled := make(map[string]bool, len(ids))
+	for _, v := range ids {
+		disabled[v] = true
+	}
+	return disabled, nil
+}
+
+func SetAchievementEnabled(pool *pgxpool.Pool, guildID uint64, id string, enabled bool) error {
+	var err error
+	if enabled {
+		_, err = pool.Exec(context.Background(), "DELETE FROM disabled_achievements WHERE guild_id = $1 AND achievement = $2;", guildID, id)
+	} else {
+		_, err = pool.Exec(context.Background(), "INSERT INTO disabled_achievements VALUES ($1, $2) ON CONFLICT DO NOTHING;", guildID, id)
+	}
+	return err
+}
+
+func DeleteAchievementsForUser(pool *pgxpool.Pool, userID uint64) error {
+	_, err := pool.Exec(context.Background(), "DELETE FROM user_achievements WHERE user_id = $1;", userID)
+	return err
+}
+
+func DeleteAchievementsForGuild(pool *pgxpool.Pool, guildID uint64) error {
+	_, err := pool.Exec(context.Background(), "DELETE FROM user_achievements WHERE guild_id = $1;", guildID)
+	return err
+}
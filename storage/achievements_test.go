@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/automuteus/utils/pkg/game"
+)
+
+func TestGetAchievementProgress(t *testing.T) {
+	pool := testPool(t)
+	crewmate, imposter := int16(game.CrewmateRole), int16(game.ImposterRole)
+	for i, v := range []struct {
+		role int16
+		won  bool
+	}{{imposter, true}, {imposter, false}, {crewmate, true}, {imposter, true}, {crewmate, false}, {imposter, true}} {
+		gameID := addTestGame(t, pool, 1, int32(i*100), int32(i*100+50))
+		addTestPlayer(t, pool, 1, gameID, 1, v.role, v.won)
+	}
+
+	progress, err := GetAchievementProgress(pool, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// crewmate games don't break the imposter streak, but the imposter loss before it does
+	if progress.Games != 6 || progress.Wins != 4 || progress.CrewmateWins != 1 || progress.ImposterWins != 3 ||
+		progress.ImposterWinStreak != 2 {
+		t.Errorf("Unexpected progress %+v", progress)
+	}
+
+	progress, err = GetAchievementProgress(pool, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.Games != 0 || progress.ImposterWinStreak != 0 {
+		t.Errorf("Expected no progress for a user without games, got %+v", progress)
+	}
+}
+
+func TestUnlockAchievements(t *testing.T) {
+	pool := testPool(t)
+	gameID := addTestGame(t, pool, 1, 0, 50)
+
+	unlocked, err := UnlockAchievements(pool, 1, 1, gameID, []string{"first-win", "games-100"}, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unlocked) != 2 {
+		t.Errorf("Expected both achievements to be new, got %v", unlocked)
+	}
+	unlocked, err = UnlockAchievements(pool, 1, 1, gameID, []string{"first-win", "imposter-wins-25"}, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(unlocked, []string{"imposter-wins-25"}) {
+		t.Errorf("Expected only imposter-wins-25 to be new, got %v", unlocked)
+	}
+
+	if err := SetAchievementEnabled(pool, 1, "games-100", false); err != nil {
+		t.Fatal(err)
+	}
+	achievements, err := GetUserAchievements(pool, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, v := range achievements {
+		ids = append(ids, v.Achievement)
+	}
+	if !reflect.DeepEqual(ids, []string{"first-win", "imposter-wins-25"}) {
+		t.Errorf("Expected the disabled achievement to be hidden, got %v", ids)
+	}
+
+	if err := DeleteAchievementsForUser(pool, 1); err != nil {
+		t.Fatal(err)
+	}
+	if achievements, err := GetUserAchievements(pool, 1, 1); err != nil || len(achievements) != 0 {
+		t.Errorf("Expected every achievement to be deleted, got %v %v", achievements, err)
+	}
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/automuteus/utils/pkg/settings"
+)
+
+const (
+	// settingsCacheSize is how many guilds' settings are kept in memory
+	settingsCacheSize = 1024
+
+	// settingsCacheTTL bounds how stale an entry can get if an invalidation message is missed (such as while the
+	// pub/sub connection is reconnecting)
+	settingsCacheTTL = time.Minute * 5
+
+	// settingsInvalidateChannel is the Redis pub/sub channel used to tell every shard that a guild's settings changed
+	settingsInvalidateChannel = "automuteus:settings:invalidate"
+)
+
+type settingsCacheEntry struct {
+	key      string
+	settings *settings.GuildSettings
+	expires  time.Time
+}
+
+// settingsCache is a fixed-size LRU cache of guild settings, keyed by their Redis key
+type settingsCache struct {
+	lock    sync.Mutex
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+
+	// generation is incremented on every invalidation, so a value fetched from Redis before an invalidation isn't
+	// cached after it
+	generation uint64
+}
+
+func newSettingsCache(size int) *settingsCache {
+	return &settingsCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get returns the cached settings (or nil), along with the generation to pass to put if the settings had to be fetched
+func (cache *settingsCache) get(key string) (*settings.GuildSettings, uint64) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	if elem, ok := cache.entries[key]; ok {
+		entry := elem.Value.(*settingsCacheEntry)
+		if time.Now().Before(entry.expires) {
+			cache.order.MoveToFront(elem)
+			return entry.settings, cache.generation
+		}
+		cache.order.Remove(elem)
+		delete(cache.entries, key)
+	}
+	return nil, cache.generation
+}
+
+func (cache *settingsCache) put(key string, sett *settings.GuildSettings, generation uint64) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	if generation != cache.generation {
+		return
+	}
+	if elem, ok := cache.entries[key]; ok {
+		cache.order.Remove(elem)
+	}
+	cache.entries[key] = cache.order.PushFront(&settingsCacheEntry{
+		key:      key,
+		settings: sett,
+		expires:  time.Now().Add(settingsCacheTTL),
+	})
+	for cache.order.Len() > cache.size {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*settingsCacheEntry).key)
+	}
+}
+
+func (cache *settingsCache) invalidate(key string) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	cache.generation++
+	if elem, ok := cache.entries[key]; ok {
+		cache.order.Remove(elem)
+		delete(cache.entries, key)
+	}
+}
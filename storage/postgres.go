@@ -0,0 +1,245 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// The shared Postgres interface lives in the utils repo; these are queries against tables only the bot uses (see
+// postgres.sql), and take the pool from that interface
+
+type SettingsChange struct {
+	ChangeID   int64  `db:"change_id"`
+	GuildID    uint64 `db:"guild_id"`
+	UserID     uint64 `db:"user_id"`
+	Setting    string `db:"setting"`
+	OldValue   []byte `db:"old_value"`
+	NewValue   []byte `db:"new_value"`
+	ChangeTime int32  `db:"change_time"`
+}
+
+func AddSettingsChange(pool *pgxpool.Pool, change *SettingsChange) (int64, error) {
+	var id int64
+	err := pool.QueryRow(context.Background(),
+		"INSERT INTO settings_history VALUES (DEFAULT, $1, $2, $3, $4, $5, $6) RETURNING change_id;",
+		change.GuildID, change.UserID, change.Setting, string(change.OldValue), string(change.NewValue), change.ChangeTime,
+	).Scan(&id)
+	return id, err
+}
+
+// GetSettingsHistory returns the most recent settings changes for a guild, newest first
+func GetSettingsHistory(pool *pgxpool.Pool, guildID uint64, limit int) ([]*SettingsChange, error) {
+	var changes []*SettingsChange
+	err := pgxscan.Select(context.Background(), pool, &changes,
+		"SELECT * FROM settings_history WHERE guild_id = $1 ORDER BY change_id DESC LIMIT $2;", guildID, limit)
+	return changes, err
+}
+
+// GetSettingsChange returns a single change, or nil if the guild has no change with that ID
+func GetSettingsChange(pool *pgxpool.Pool, guildID uint64, changeID int64) (*SettingsChange, error) {
+	var change SettingsChange
+	err := pgxscan.Get(context.Background(), pool, &change,
+		"SELECT * FROM settings_history WHERE guild_id = $1 AND change_id = $2;", guildID, changeID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+type ScheduledGame struct {
+	ScheduleID     int64  `db:"schedule_id"`
+	GuildID        uint64 `db:"guild_id"`
+	VoiceChannelID uint64 `db:"voice_channel_id"`
+	TextChannelID  uint64 `db:"text_channel_id"`
+	CreatorID      uint64 `db:"creator_id"`
+	StartTime      int32  `db:"start_time"`
+	Reminded       bool   `db:"reminded"`
+	Started        bool   `db:"started"`
+}
+
+func AddScheduledGame(pool *pgxpool.Pool, game *ScheduledGame) (int64, error) {
+	var id int64
+	err := pool.QueryRow(context.Background(),
+		"INSERT INTO scheduled_games VALUES (DEFAULT, $1, $2, $3, $4, $5, false, false) RETURNING schedule_id;",
+		game.GuildID, game.VoiceChannelID, game.TextChannelID, game.CreatorID, game.StartTime,
+	).Scan(&id)
+	return id, err
+}
+
+// GetScheduledGames returns the sessions a guild has coming up, soonest first
+func GetScheduledGames(pool *pgxpool.Pool, guildID uint64, limit int) ([]*ScheduledGame, error) {
+	var games []*ScheduledGame
+	err := pgxscan.Select(context.Background(), pool, &games,
+		"SELECT * FROM scheduled_games WHERE guild_id = $1 AND started = false ORDER BY start_time ASC LIMIT $2;", guildID, limit)
+	return games, err
+}
+
+// GetScheduledGame returns a single session, or nil if there's no session with that ID
+func GetScheduledGame(pool *pgxpool.Pool, scheduleID int64) (*ScheduledGame, error) {
+	var game ScheduledGame
+	err := pgxscan.Get(context.Background(), pool, &game,
+		"SELECT * FROM scheduled_games WHERE schedule_id = $1;", scheduleID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &game, nil
+}
+
+// DeleteScheduledGame cancels a session that hasn't started yet. It returns false if the guild has no such session
+func DeleteScheduledGame(pool *pgxpool.Pool, guildID uint64, scheduleID int64) (bool, error) {
+	tag, err := pool.Exec(context.Background(),
+		"DELETE FROM scheduled_games WHERE guild_id = $1 AND schedule_id = $2 AND started = false;", guildID, scheduleID)
+	return tag.RowsAffected() > 0, err
+}
+
+// GetDueReminders returns the sessions starting before remindBefore that haven't had a reminder posted yet
+func GetDueReminders(pool *pgxpool.Pool, remindBefore int32) ([]*ScheduledGame, error) {
+	var games []*ScheduledGame
+	err := pgxscan.Select(context.Background(), pool, &games,
+		"SELECT * FROM scheduled_games WHERE reminded = false AND started = false AND start_time <= $1;", remindBefore)
+	return games, err
+}
+
+// GetDueScheduledGames returns the sessions that should have started by now, but haven't yet
+func GetDueScheduledGames(pool *pgxpool.Pool, now int32) ([]*ScheduledGame, error) {
+	var games []*ScheduledGame
+	err := pgxscan.Select(context.Background(), pool, &games,
+		"SELECT * FROM scheduled_games WHERE started = false AND start_time <= $1;", now)
+	return games, err
+}
+
+// MarkScheduledGameReminded records that the reminder for a session was posted. It returns false if another worker
+// already did, so each reminder is only posted once
+func MarkScheduledGameReminded(pool *pgxpool.Pool, scheduleID int64) (bool, error) {
+	tag, err := pool.Exec(context.Background(),
+		"UPDATE scheduled_games SET reminded = true WHERE schedule_id = $1 AND reminded = false;", scheduleID)
+	return tag.RowsAffected() > 0, err
+}
+
+// MarkScheduledGameStarted records that the game for a session was created. It returns false if another worker
+// already did, so each session only creates one game
+func MarkScheduledGameStarted(pool *pgxpool.Pool, scheduleID int64) (bool, error) {
+	tag, err := pool.Exec(context.Background(),
+		"UPDATE scheduled_games SET started = true, reminded = true WHERE schedule_id = $1 AND started = false;", scheduleID)
+	return tag.RowsAffected() > 0, err
+}
+
+// SetScheduledGameRSVP adds or removes a user's RSVP to a session
+func SetScheduledGameRSVP(pool *pgxpool.Pool, scheduleID int64, userID uint64, attending bool) error {
+	var err error
+	if attending {
+		_, err = pool.Exec(context.Background(),
+			"INSERT INTO scheduled_games_rsvps VALUES ($1, $2) ON CONFLICT DO NOTHING;", scheduleID, userID)
+	} else {
+		_, err = pool.Exec(context.Background(),
+			"DELETE FROM scheduled_games_rsvps WHERE schedule_id = $1 AND user_id = $2;", scheduleID, userID)
+	}
+	return err
+}
+
+func GetScheduledGameRSVPs(pool *pgxpool.Pool, scheduleID int64) ([]uint64, error) {
+	var users []uint64
+	err := pgxscan.Select(context.Background(), pool, &users,
+		"SELECT user_id FROM scheduled_games_rsvps WHERE schedule_id = $1 ORDER BY user_id;", scheduleID)
+	return users, err
+}
+
+type Season struct {
+	SeasonID  int64  `db:"season_id"`
+	GuildID   uint64 `db:"guild_id"`
+	Name      string `db:"name"`
+	StartTime int32  `db:"start_time"`
+	EndTime   int32  `db:"end_time"`
+	Finished  bool   `db:"finished"`
+}
+
+// TimeRange returns the period covered by the season
+func (s *Season) TimeRange() TimeRange {
+	return TimeRange{Start: s.StartTime, End: s.EndTime}
+}
+
+// AddSeason creates a season for a guild, unless it would overlap one of the guild's other seasons. The returned ID is
+// 0 if it overlaps
+func AddSeason(pool *pgxpool.Pool, season *Season) (int64, error) {
+	var id int64
+	err := pool.QueryRow(context.Background(),
+		"INSERT INTO seasons (guild_id, name, start_time, end_time) "+
+			"SELECT $1, $2, $3, $4 WHERE NOT EXISTS "+
+			"(SELECT 1 FROM seasons WHERE guild_id = $1 AND start_time < $4 AND end_time > $3) "+
+			"RETURNING season_id;",
+		season.GuildID, season.Name, season.StartTime, season.EndTime,
+	).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return id, err
+}
+
+// GetSeasons returns a guild's seasons, most recent first
+func GetSeasons(pool *pgxpool.Pool, guildID uint64, limit int) ([]*Season, error) {
+	var seasons []*Season
+	err := pgxscan.Select(context.Background(), pool, &seasons,
+		"SELECT * FROM seasons WHERE guild_id = $1 ORDER BY start_time DESC LIMIT $2;", guildID, limit)
+	return seasons, err
+}
+
+// GetCurrentSeason returns the guild's season that's running at the given time, or nil if there isn't one
+func GetCurrentSeason(pool *pgxpool.Pool, guildID uint64, now int32) (*Season, error) {
+	var season Season
+	err := pgxscan.Get(context.Background(), pool, &season,
+		"SELECT * FROM seasons WHERE guild_id = $1 AND start_time <= $2 AND end_time > $2;", guildID, now)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &season, nil
+}
+
+// GetSeason returns one of the guild's seasons by ID, or nil if the guild has no season with that ID
+func GetSeason(pool *pgxpool.Pool, guildID uint64, seasonID int64) (*Season, error) {
+	var season Season
+	err := pgxscan.Get(context.Background(), pool, &season,
+		"SELECT * FROM seasons WHERE guild_id = $1 AND season_id = $2;", guildID, seasonID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &season, nil
+}
+
+// DeleteSeason returns false if the guild has no season with that ID
+func DeleteSeason(pool *pgxpool.Pool, guildID uint64, seasonID int64) (bool, error) {
+	tag, err := pool.Exec(context.Background(),
+		"DELETE FROM seasons WHERE guild_id = $1 AND season_id = $2;", guildID, seasonID)
+	return tag.RowsAffected() > 0, err
+}
+
+// GetEndedSeasons returns the seasons that have ended, but haven't had their final leaderboard posted yet
+func GetEndedSeasons(pool *pgxpool.Pool, now int32) ([]*Season, error) {
+	var seasons []*Season
+	err := pgxscan.Select(context.Background(), pool, &seasons,
+		"SELECT * FROM seasons WHERE finished = false AND end_time <= $1;", now)
+	return seasons, err
+}
+
+// MarkSeasonFinished records that a season's final leaderboard was posted. It returns false if another worker already
+// did, so it's only posted once
+func MarkSeasonFinished(pool *pgxpool.Pool, seasonID int64) (bool, error) {
+	tag, err := pool.Exec(context.Background(),
+		"UPDATE seasons SET finished = true WHERE season_id = $1 AND finished = false;", seasonID)
+	return tag.RowsAffected() > 0, err
+}
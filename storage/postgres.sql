@@ -68,4 +68,150 @@ create index if not exists users_games_role_index ON users_games (player_role);
 create index if not exists users_games_won_index ON users_games (player_won); --query games by win status
 
 create index if not exists game_events_game_id_index on game_events (game_id); --query for game events by the game ID
-create index if not exists game_events_user_id_index on game_events (user_id); --query for game events by the user ID
\ No newline at end of file
+create index if not exists game_events_user_id_index on game_events (user_id); --query for game events by the user ID
+
+-- every change made to a guild's settings, so a bad change can be reviewed and rolled back
+create table if not exists settings_history
+(
+    change_id   bigserial PRIMARY KEY,
+    guild_id    numeric     NOT NULL, --not a literal reference; guilds that aren't in the guilds table can still change settings
+    user_id     numeric     NOT NULL,
+    setting     VARCHAR(32) NOT NULL,
+    old_value   jsonb       NOT NULL, --only the settings that changed, keyed by dotted path (e.g. voiceRules.MuteRules.TASKS.alive)
+    new_value   jsonb       NOT NULL,
+    change_time integer     NOT NULL  --2038 problem, but I do not care
+);
+
+create index if not exists settings_history_guild_id_index on settings_history (guild_id); --query changes by guild ID
+
+-- game sessions scheduled with /schedule, and the users that RSVP'd to them
+create table if not exists scheduled_games
+(
+    schedule_id      bigserial PRIMARY KEY,
+    guild_id         numeric     NOT NULL,
+    voice_channel_id numeric     NOT NULL,
+    text_channel_id  numeric     NOT NULL,
+    creator_id       numeric     NOT NULL,
+    start_time       integer     NOT NULL, --2038 problem, but I do not care
+    reminded         bool        NOT NULL DEFAULT false,
+    started          bool        NOT NULL DEFAULT false
+);
+
+create table if not exists scheduled_games_rsvps
+(
+    schedule_id bigint REFERENCES scheduled_games ON DELETE CASCADE, --if a session is canceled, delete its RSVPs
+    user_id     numeric NOT NULL,
+    PRIMARY KEY (schedule_id, user_id)
+);
+
+create index if not exists scheduled_games_guild_id_index on scheduled_games (guild_id); --query sessions by guild ID
+create index if not exists scheduled_games_start_time_index on scheduled_games (start_time); --query sessions that are due
+
+-- per-guild skill ratings, kept separately for each role. Updated when a game ends, and rebuilt by -backfill-ratings
+create table if not exists user_ratings
+(
+    user_id         numeric          NOT NULL,
+    guild_id        numeric          NOT NULL,
+    crewmate_rating double precision NOT NULL DEFAULT 1500,
+    crewmate_games  integer          NOT NULL DEFAULT 0,
+    imposter_rating double precision NOT NULL DEFAULT 1500,
+    imposter_games  integer          NOT NULL DEFAULT 0,
+    PRIMARY KEY (user_id, guild_id)
+);
+
+-- the rating change each player saw in each rated game
+create table if not exists rating_history
+(
+    game_id       bigint REFERENCES games ON DELETE CASCADE, --if a game is deleted, delete the rating changes from it
+    user_id       numeric          NOT NULL,
+    guild_id      numeric          NOT NULL,
+    player_role   smallint         NOT NULL,
+    rating_before double precision NOT NULL,
+    rating_after  double precision NOT NULL,
+    PRIMARY KEY (game_id, user_id)
+);
+
+create index if not exists user_ratings_guild_id_index on user_ratings (guild_id); --query leaderboards by guild ID
+create index if not exists rating_history_user_id_index on rating_history (user_id, guild_id); --query a user's rating trend
+
+-- stats seasons defined by guild admins. Games count toward a season if they started during it
+create table if not exists seasons
+(
+    season_id  bigserial PRIMARY KEY,
+    guild_id   numeric     NOT NULL,
+    name       VARCHAR(32) NOT NULL,
+    start_time integer     NOT NULL, --2038 problem, but I do not care
+    end_time   integer     NOT NULL,
+    finished   bool        NOT NULL DEFAULT false --if the final leaderboard was posted
+);
+
+create index if not exists seasons_guild_id_index on seasons (guild_id); --query seasons by guild ID
+create index if not exists games_start_time_index on games (start_time); --query games in a period
+
+-- achievements players unlocked on a guild. Awarded when a game ends
+create table if not exists user_achievements
+(
+    user_id     numeric     NOT NULL,
+    guild_id    numeric     NOT NULL,
+    achievement VARCHAR(32) NOT NULL,
+    game_id     bigint      REFERENCES games ON DELETE SET NULL, --the game it was unlocked in
+    unlock_time integer     NOT NULL,
+    PRIMARY KEY (user_id, guild_id, achievement)
+);
+
+-- achievements guild admins turned off. They aren't awarded or shown on the guild
+create table if not exists disabled_achievements
+(
+    guild_id    numeric     NOT NULL,
+    achievement VARCHAR(32) NOT NULL,
+    PRIMARY KEY (guild_id, achievement)
+);
+
+create index if not exists user_achievements_guild_id_index on user_achievements (guild_id); --delete achievements by guild ID
+
+-- games of players that weren't linked to a Discord user, for guilds that record them. Moved to users_games when a
+-- user's claim on the in-game name is approved
+create table if not exists unlinked_users_games
+(
+    guild_id     numeric REFERENCES guilds ON DELETE CASCADE, --if a guild is deleted, delete all unlinked games
+    game_id      bigint REFERENCES games ON DELETE CASCADE,   --if a game is deleted, delete all unlinked games in it
+    player_name  VARCHAR(10) NOT NULL,
+    player_color smallint    NOT NULL,
+    player_role  smallint    NOT NULL,
+    player_won   bool        NOT NULL,
+    PRIMARY KEY (game_id, player_name)
+);
+
+-- guilds that record the games of unlinked players
+create table if not exists unlinked_stats_guilds
+(
+    guild_id numeric PRIMARY KEY
+);
+
+-- users asking to attach the unlinked games played under an in-game name to their account, until an admin answers
+create table if not exists unlinked_claims
+(
+    claim_id     bigserial PRIMARY KEY,
+    guild_id     numeric     NOT NULL,
+    user_id      numeric     NOT NULL,
+    player_name  VARCHAR(10) NOT NULL,
+    request_time integer     NOT NULL --2038 problem, but I do not care
+);
+
+create index if not exists unlinked_users_games_name_index on unlinked_users_games (guild_id, lower(player_name)); --query unlinked games by name
+
+-- in-game names of users that opted out of data collection, which unlinked games are never recorded under. Kept in
+-- lowercase, without the user they belonged to
+create table if not exists unlinked_ignored_names
+(
+    guild_id    numeric     NOT NULL,
+    player_name VARCHAR(10) NOT NULL,
+    PRIMARY KEY (guild_id, player_name)
+);
+
+-- users that made their stats from every guild public on the bot's profile page
+create table if not exists public_profiles
+(
+    user_id     numeric PRIMARY KEY REFERENCES users ON DELETE CASCADE, --if a user gets deleted, hide their profile
+    enable_time integer NOT NULL                                        --2038 problem, but I do not care
+);
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// The storage tests run their queries against a real Postgres database, given as a connection URL by
+// AUTOMUTEUS_TEST_POSTGRES_URL, and are skipped without one. Every test gets its own schema with the tables from
+// postgres.sql, which is dropped when the test finishes
+
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv("AUTOMUTEUS_TEST_POSTGRES_URL")
+	if url == "" {
+		t.Skip("AUTOMUTEUS_TEST_POSTGRES_URL is not set")
+	}
+	ctx := context.Background()
+
+	conn, err := pgx.Connect(ctx, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := fmt.Sprintf("automuteus_test_%d", time.Now().UnixNano())
+	_, err = conn.Exec(ctx, "CREATE SCHEMA "+schema+";")
+	if err != nil {
+		conn.Close(ctx)
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_, err := conn.Exec(ctx, "DROP SCHEMA "+schema+" CASCADE;")
+		if err != nil {
+			t.Error(err)
+		}
+		conn.Close(ctx)
+	})
+
+	config, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.ConnConfig.RuntimeParams["search_path"] = schema
+	pool, err := pgxpool.ConnectConfig(ctx, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+
+	tables, err := os.ReadFile("postgres.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pool.Exec(ctx, string(tables))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pool
+}
+
+// addTestGame adds a finished game to a guild, and returns its ID
+func addTestGame(t *testing.T, pool *pgxpool.Pool, guildID uint64, startTime, endTime int32) int64 {
+	t.Helper()
+	ctx := context.Background()
+	_, err := pool.Exec(ctx,
+		"INSERT INTO guilds (guild_id, guild_name, premium) VALUES ($1, 'Test', 0) ON CONFLICT DO NOTHING;", guildID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gameID int64
+	err = pool.QueryRow(ctx,
+		"INSERT INTO games (guild_id, connect_code, start_time, win_type, end_time) "+
+			"VALUES ($1, 'ABCDEFGH', $2, 0, $3) RETURNING game_id;",
+		guildID, startTime, endTime).Scan(&gameID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gameID
+}
+
+// addTestPlayer records that a user played in a game
+func addTestPlayer(t *testing.T, pool *pgxpool.Pool, guildID uint64, gameID int64, userID uint64, role int16, won bool) {
+	t.Helper()
+	ctx := context.Background()
+	_, err := pool.Exec(ctx, "INSERT INTO users (user_id, opt) VALUES ($1, true) ON CONFLICT DO NOTHING;", userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pool.Exec(ctx,
+		"INSERT INTO users_games (user_id, guild_id, game_id, player_name, player_color, player_role, player_won) "+
+			"VALUES ($1, $2, $3, 'Player', 0, $4, $5);",
+		userID, guildID, gameID, role, won)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetSeason(t *testing.T) {
+	pool := testPool(t)
+	id, err := AddSeason(pool, &Season{GuildID: 1, Name: "Spring", StartTime: 100, EndTime: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if overlapping, err := AddSeason(pool, &Season{GuildID: 1, Name: "Summer", StartTime: 150, EndTime: 300}); err != nil || overlapping != 0 {
+		t.Errorf("Expected an overlapping season not to be added, got %d %v", overlapping, err)
+	}
+
+	season, err := GetSeason(pool, 1, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if season == nil || season.Name != "Spring" || season.TimeRange() != (TimeRange{Start: 100, End: 200}) {
+		t.Errorf("Expected the Spring season, got %+v", season)
+	}
+	if season, err := GetSeason(pool, 2, id); err != nil || season != nil {
+		t.Errorf("Expected no season for another guild's ID, got %+v %v", season, err)
+	}
+}
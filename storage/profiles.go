@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+func IsProfilePublic(pool *pgxpool.Pool, userID uint64) (bool, error) {
+	var public bool
+	err := pool.QueryRow(context.Background(),
+		"SELECT EXISTS (SELECT 1 FROM public_profiles WHERE user_id = $1);", userID).Scan(&public)
+	return public, err
+}
+
+// SetProfilePublic shows or hides a user's profile page. The user has to exist before it can be made public
+func SetProfilePublic(pool *pgxpool.Pool, userID uint64, public bool, enableTime int32) error {
+	var err error
+	if public {
+		_, err = pool.Exec(context.Background(), "INSERT INTO public_profiles VALUES ($1, $2) ON CONFLICT DO NOTHING;", userID, enableTime)
+	} else {
+		_, err = pool.Exec(context.Background(), "DELETE FROM public_profiles WHERE user_id = $1;", userID)
+	}
+	return err
+}
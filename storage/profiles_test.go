@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSetProfilePublic(t *testing.T) {
+	pool := testPool(t)
+	_, err := pool.Exec(context.Background(), "INSERT INTO users (user_id, opt) VALUES (1, true);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if public, err := IsProfilePublic(pool, 1); err != nil || public {
+		t.Errorf("Expected profiles to start out private, got %t %v", public, err)
+	}
+
+	if err := SetProfilePublic(pool, 1, true, 100); err != nil {
+		t.Fatal(err)
+	}
+	// turning it on twice keeps it on
+	if err := SetProfilePublic(pool, 1, true, 200); err != nil {
+		t.Fatal(err)
+	}
+	if public, err := IsProfilePublic(pool, 1); err != nil || !public {
+		t.Errorf("Expected the profile to be public, got %t %v", public, err)
+	}
+	if public, err := IsProfilePublic(pool, 2); err != nil || public {
+		t.Errorf("Expected another user's profile to stay private, got %t %v", public, err)
+	}
+
+	if err := SetProfilePublic(pool, 1, false, 300); err != nil {
+		t.Fatal(err)
+	}
+	if public, err := IsProfilePublic(pool, 1); err != nil || public {
+		t.Errorf("Expected the profile to be private again, got %t %v", public, err)
+	}
+	if err := SetProfilePublic(pool, 3, true, 100); err == nil {
+		t.Error("Expected a user that doesn't exist not to get a public profile")
+	}
+}
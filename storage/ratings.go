@@ -0,0 +1,283 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/automuteus/automuteus/rating"
+	"github.com/automuteus/utils/pkg/game"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// ratingsLockKey is the Postgres advisory lock that keeps games from being rated while every rating is replayed
+const ratingsLockKey int64 = 0x616d7572 // "amur"
+
+type UserRating struct {
+	UserID         uint64  `db:"user_id"`
+	GuildID        uint64  `db:"guild_id"`
+	CrewmateRating float64 `db:"crewmate_rating"`
+	CrewmateGames  int32   `db:"crewmate_games"`
+	ImposterRating float64 `db:"imposter_rating"`
+	ImposterGames  int32   `db:"imposter_games"`
+}
+
+// ForRole returns the rating and number of rated games for one of the user's roles
+func (r *UserRating) ForRole(role int16) (float64, int32) {
+	if role == int16(game.ImposterRole) {
+		return r.ImposterRating, r.ImposterGames
+	}
+	return r.CrewmateRating, r.CrewmateGames
+}
+
+func (r *UserRating) setForRole(role int16, value float64) {
+	if role == int16(game.ImposterRole) {
+		r.ImposterRating = value
+		r.ImposterGames++
+	} else {
+		r.CrewmateRating = value
+		r.CrewmateGames++
+	}
+}
+
+func newUserRating(userID, guildID uint64) *UserRating {
+	return &UserRating{
+		UserID:         userID,
+		GuildID:        guildID,
+		CrewmateRating: rating.InitialRating,
+		ImposterRating: rating.InitialRating,
+	}
+}
+
+func ratingColumns(role int16) (string, string) {
+	if role == int16(game.ImposterRole) {
+		return "imposter_rating", "imposter_games"
+	}
+	return "crewmate_rating", "crewmate_games"
+}
+
+// GetUserRating returns a user's ratings on a guild, or nil if they haven't played a rated game there
+func GetUserRating(pool *pgxpool.Pool, guildID, userID uint64) (*UserRating, error) {
+	var r UserRating
+	err := pgxscan.Get(context.Background(), pool, &r,
+		"SELECT * FROM user_ratings WHERE guild_id = $1 AND user_id = $2;", guildID, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// GetRatingLeaderboard returns the highest (or lowest, if ascending) rated players on a guild for a role, skipping
+// anyone that has played fewer than minGames rated games in it, or that has opted out of data collection
+func GetRatingLeaderboard(pool *pgxpool.Pool, guildID uint64, role int16, ascending bool, minGames, limit, offset int) ([]*UserRating, error) {
+	ratingCol, gamesCol := ratingColumns(role)
+	direction := "DESC"
+	if ascending {
+		direction = "ASC"
+	}
+	var r []*UserRating
+	err := pgxscan.Select(context.Background(), pool, &r,
+		fmt.Sprintf("SELECT * FROM user_ratings WHERE guild_id = $1 AND %s >= $2 "+
+			"AND NOT EXISTS (SELECT 1 FROM users WHERE users.user_id = user_ratings.user_id AND users.opt = false) "+
+			"ORDER BY %s %s, user_id LIMIT $3 OFFSET $4;", gamesCol, ratingCol, direction),
+		guildID, minGames, limit, offset)
+	return r, err
+}
+
+// GetRatingHistory is a player's rating in a role after each of their last limit rated games, oldest first
+func GetRatingHistory(pool *pgxpool.Pool, guildID, userID uint64, role int16, limit int) ([]float64, error) {
+	var r []float64
+	err := pgxscan.Select(context.Background(), pool, &r,
+		"SELECT rating_after FROM ("+
+			"SELECT rating_history.rating_after, games.end_time FROM rating_history "+
+			"INNER JOIN games ON games.game_id = rating_history.game_id "+
+			"WHERE rating_history.guild_id = $1 AND rating_history.user_id = $2 AND rating_history.player_role = $3 "+
+			"ORDER BY games.end_time DESC LIMIT $4) recent "+
+			"ORDER BY end_time ASC;",
+		guildID, userID, role, limit)
+	return r, err
+}
+
+// RateGame updates the ratings of everyone that played in a game. Games that were already rated are skipped, so it's
+// safe to call more than once for the same game
+func RateGame(pool *pgxpool.Pool, guildID uint64, gameID int64, players []rating.Player) error {
+	ctx := context.Background()
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// wait for (and hold off) a full replay, which would otherwise overwrite this game's rating changes
+	_, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock_shared($1);", ratingsLockKey)
+	if err != nil {
+		return err
+	}
+
+	var rated bool
+	err = tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM rating_history WHERE game_id = $1);", gameID).Scan(&rated)
+	if err != nil || rated {
+		return err
+	}
+
+	for i, p := range players {
+		// create missing rows first, so every row can be locked against games that end at the same time
+		_, err = tx.Exec(ctx, "INSERT INTO user_ratings (user_id, guild_id) VALUES ($1, $2) ON CONFLICT DO NOTHING;", p.ID, guildID)
+		if err != nil {
+			return err
+		}
+		var r UserRating
+		err = pgxscan.Get(ctx, tx, &r,
+			"SELECT * FROM user_ratings WHERE guild_id = $1 AND user_id = $2 FOR UPDATE;", guildID, p.ID)
+		if err != nil {
+			return err
+		}
+		players[i].Rating, players[i].Games = r.ForRole(p.Role)
+	}
+
+	results := rating.Update(players)
+	if results == nil {
+		return nil
+	}
+	for _, res := range results {
+		ratingCol, gamesCol := ratingColumns(res.Role)
+		_, err = tx.Exec(ctx,
+			fmt.Sprintf("UPDATE user_ratings SET %s = $3, %s = %s + 1 WHERE guild_id = $1 AND user_id = $2;", ratingCol, gamesCol, gamesCol),
+			guildID, res.ID, res.After)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, "INSERT INTO rating_history VALUES ($1, $2, $3, $4, $5, $6);",
+			gameID, res.ID, guildID, res.Role, res.Before, res.After)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+type ratedUserGame struct {
+	GuildID    uint64 `db:"guild_id"`
+	GameID     int64  `db:"game_id"`
+	UserID     uint64 `db:"user_id"`
+	PlayerRole int16  `db:"player_role"`
+	PlayerWon  bool   `db:"player_won"`
+}
+
+// ReplayRatings throws away every rating, and rebuilds them by rating every finished game again in the order they
+// ended. It returns how many games were rated.
+// Every users_games row for finished games, across all guilds, is held in memory while the ratings are rebuilt, so
+// expect memory use in proportion to the table. It's safe to run while bots are live: games that end during the
+// replay wait for it to finish (see RateGame), and are then rated on top of the rebuilt ratings
+func ReplayRatings(pool *pgxpool.Pool) (int, error) {
+	ctx := context.Background()
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// waits for games that are being rated right now, so the games read below include them
+	_, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1);", ratingsLockKey)
+	if err != nil {
+		return 0, err
+	}
+
+	var userGames []*ratedUserGame
+	err = pgxscan.Select(ctx, tx, &userGames,
+		"SELECT users_games.guild_id, users_games.game_id, users_games.user_id, users_games.player_role, users_games.player_won "+
+			"FROM users_games INNER JOIN games ON games.game_id = users_games.game_id "+
+			"WHERE games.end_time > 0 "+
+			"ORDER BY games.end_time, users_games.game_id;")
+	if err != nil {
+		return 0, err
+	}
+
+	type guildUser struct {
+		guildID uint64
+		userID  uint64
+	}
+	ratings := map[guildUser]*UserRating{}
+	var history [][]interface{}
+	gamesRated := 0
+
+	rateGame := func(played []*ratedUserGame) {
+		players := make([]rating.Player, len(played))
+		for i, ug := range played {
+			key := guildUser{ug.GuildID, ug.UserID}
+			if ratings[key] == nil {
+				ratings[key] = newUserRating(ug.UserID, ug.GuildID)
+			}
+			players[i] = rating.Player{ID: ug.UserID, Role: ug.PlayerRole, Won: ug.PlayerWon}
+			players[i].Rating, players[i].Games = ratings[key].ForRole(ug.PlayerRole)
+		}
+		results := rating.Update(players)
+		if results == nil {
+			return
+		}
+		for i, res := range results {
+			ratings[guildUser{played[i].GuildID, res.ID}].setForRole(res.Role, res.After)
+			history = append(history, []interface{}{played[i].GameID, res.ID, played[i].GuildID, res.Role, res.Before, res.After})
+		}
+		gamesRated++
+	}
+
+	start := 0
+	for i := 1; i <= len(userGames); i++ {
+		if i == len(userGames) || userGames[i].GameID != userGames[start].GameID {
+			rateGame(userGames[start:i])
+			start = i
+		}
+	}
+
+	_, err = tx.Exec(ctx, "DELETE FROM rating_history;")
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec(ctx, "DELETE FROM user_ratings;")
+	if err != nil {
+		return 0, err
+	}
+
+	rows := make([][]interface{}, 0, len(ratings))
+	for _, r := range ratings {
+		rows = append(rows, []interface{}{r.UserID, r.GuildID, r.CrewmateRating, r.CrewmateGames, r.ImposterRating, r.ImposterGames})
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"user_ratings"},
+		[]string{"user_id", "guild_id", "crewmate_rating", "crewmate_games", "imposter_rating", "imposter_games"},
+		pgx.CopyFromRows(rows))
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{"rating_history"},
+		[]string{"game_id", "user_id", "guild_id", "player_role", "rating_before", "rating_after"},
+		pgx.CopyFromRows(history))
+	if err != nil {
+		return 0, err
+	}
+	return gamesRated, tx.Commit(ctx)
+}
+
+// DeleteRatingsForUser resets a user's ratings on every guild, like clearing their stats does for their games
+func DeleteRatingsForUser(pool *pgxpool.Pool, userID uint64) error {
+	_, err := pool.Exec(context.Background(), "DELETE FROM rating_history WHERE user_id = $1;", userID)
+	if err != nil {
+		return err
+	}
+	_, err = pool.Exec(context.Background(), "DELETE FROM user_ratings WHERE user_id = $1;", userID)
+	return err
+}
+
+func DeleteRatingsForGuild(pool *pgxpool.Pool, guildID uint64) error {
+	_, err := pool.Exec(context.Background(), "DELETE FROM rating_history WHERE guild_id = $1;", guildID)
+	if err != nil {
+		return err
+	}
+	_, err = pool.Exec(context.Background(), "DELETE FROM user_ratings WHERE guild_id = $1;", guildID)
+	return err
+}
@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/automuteus/automuteus/rating"
+	"github.com/automuteus/utils/pkg/game"
+)
+
+func TestGetRatingLeaderboard(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	for _, v := range []struct {
+		userID uint64
+		rating float64
+		games  int32
+	}{{1, 1600, 5}, {2, 1550, 5}, {3, 1700, 5}, {4, 1800, 1}} {
+		_, err := pool.Exec(ctx,
+			"INSERT INTO user_ratings (user_id, guild_id, crewmate_rating, crewmate_games) VALUES ($1, 1, $2, $3);",
+			v.userID, v.rating, v.games)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// user 3 opted out of data collection, but their ratings haven't been deleted yet
+	_, err := pool.Exec(ctx, "INSERT INTO users (user_id, opt) VALUES (1, true), (3, false);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userIDs := func(ratings []*UserRating) []uint64 {
+		ids := make([]uint64, len(ratings))
+		for i, v := range ratings {
+			ids[i] = v.UserID
+		}
+		return ids
+	}
+	crewmate := int16(game.CrewmateRole)
+	ratings, err := GetRatingLeaderboard(pool, 1, crewmate, false, 2, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := userIDs(ratings); !reflect.DeepEqual(ids, []uint64{1, 2}) {
+		t.Errorf("Expected users 1 and 2, got %v", ids)
+	}
+	ratings, err = GetRatingLeaderboard(pool, 1, crewmate, true, 1, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := userIDs(ratings); !reflect.DeepEqual(ids, []uint64{2, 1, 4}) {
+		t.Errorf("Expected users 2, 1 and 4, got %v", ids)
+	}
+	ratings, err = GetRatingLeaderboard(pool, 1, int16(game.ImposterRole), false, 1, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ratings) != 0 {
+		t.Errorf("Expected nobody with imposter games, got %v", userIDs(ratings))
+	}
+}
+
+func TestReplayRatings(t *testing.T) {
+	pool := testPool(t)
+	crewmate, imposter := int16(game.CrewmateRole), int16(game.ImposterRole)
+	games := [][]rating.Player{
+		{{ID: 1, Role: crewmate, Won: true}, {ID: 2, Role: crewmate, Won: true}, {ID: 3, Role: imposter}},
+		{{ID: 1, Role: imposter, Won: true}, {ID: 2, Role: crewmate}, {ID: 3, Role: crewmate}},
+		{{ID: 1, Role: crewmate}, {ID: 2, Role: imposter, Won: true}, {ID: 3, Role: crewmate}},
+		// only one team, so it isn't rated
+		{{ID: 1, Role: crewmate, Won: true}, {ID: 2, Role: crewmate, Won: true}},
+	}
+	for i, players := range games {
+		gameID := addTestGame(t, pool, 1, int32(i*100), int32(i*100+50))
+		for _, p := range players {
+			addTestPlayer(t, pool, 1, gameID, p.ID, p.Role, p.Won)
+		}
+		if err := RateGame(pool, 1, gameID, players); err != nil {
+			t.Fatal(err)
+		}
+		// rating a game again changes nothing
+		if err := RateGame(pool, 1, gameID, players); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	live := map[uint64]*UserRating{}
+	for _, id := range []uint64{1, 2, 3} {
+		r, err := GetUserRating(pool, 1, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r == nil || r.CrewmateGames+r.ImposterGames != 3 {
+			t.Fatalf("Expected user %d to have 3 rated games, got %+v", id, r)
+		}
+		live[id] = r
+	}
+
+	rated, err := ReplayRatings(pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rated != 3 {
+		t.Errorf("Expected 3 games to be rated, got %d", rated)
+	}
+	for id, expected := range live {
+		r, err := GetUserRating(pool, 1, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.CrewmateGames != expected.CrewmateGames || r.ImposterGames != expected.ImposterGames ||
+			math.Abs(r.CrewmateRating-expected.CrewmateRating) > 1e-9 || math.Abs(r.ImposterRating-expected.ImposterRating) > 1e-9 {
+			t.Errorf("Expected replaying user %d's games to give %+v, got %+v", id, expected, r)
+		}
+	}
+
+	history, err := GetRatingHistory(pool, 1, 1, crewmate, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || math.Abs(history[1]-live[1].CrewmateRating) > 1e-9 {
+		t.Errorf("Expected 2 crewmate ratings for user 1 ending at %f, got %v", live[1].CrewmateRating, history)
+	}
+
+	if err := DeleteRatingsForUser(pool, 1); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := GetUserRating(pool, 1, 1); err != nil || r != nil {
+		t.Errorf("Expected user 1's ratings to be deleted, got %+v %v", r, err)
+	}
+	if history, err := GetRatingHistory(pool, 1, 1, crewmate, 10); err != nil || len(history) != 0 {
+		t.Errorf("Expected user 1's rating history to be deleted, got %v %v", history, err)
+	}
+}
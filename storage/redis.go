@@ -4,16 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"github.com/automuteus/automuteus/metrics"
 	"github.com/automuteus/utils/pkg/rediskey"
 	"github.com/automuteus/utils/pkg/settings"
 	"github.com/go-redis/redis/v8"
 	"log"
+	"time"
 )
 
 var ctx = context.Background()
 
 type StorageInterface struct {
 	client *redis.Client
+
+	settingsCache *settingsCache
+	pubsub        *redis.PubSub
 }
 
 type RedisParameters struct {
@@ -31,12 +36,51 @@ func (storageInterface *StorageInterface) Init(params interface{}) error {
 		DB:       0, // use default DB
 	})
 	storageInterface.client = rdb
+	storageInterface.settingsCache = newSettingsCache(settingsCacheSize)
+	storageInterface.pubsub = rdb.Subscribe(ctx, settingsInvalidateChannel)
+	go storageInterface.invalidateSettingsWorker()
 	return nil
 }
 
+// invalidateSettingsWorker evicts settings from the cache when any shard changes them
+func (storageInterface *StorageInterface) invalidateSettingsWorker() {
+	for msg := range storageInterface.pubsub.Channel() {
+		storageInterface.settingsCache.invalidate(msg.Payload)
+	}
+}
+
+// invalidateSettings evicts settings from this shard's cache right away, and tells every other shard to do the same
+func (storageInterface *StorageInterface) invalidateSettings(key string) {
+	storageInterface.settingsCache.invalidate(key)
+	err := storageInterface.client.Publish(ctx, settingsInvalidateChannel, key).Err()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// GetGuildSettings returns the settings for a guild, from the in-memory cache if possible. The returned settings may
+// be shared with other callers, so they must be copied before being modified
 func (storageInterface *StorageInterface) GetGuildSettings(guildID string) *settings.GuildSettings {
 	key := rediskey.GuildSettings(rediskey.HashGuildID(guildID))
 
+	sett, generation := storageInterface.settingsCache.get(key)
+	if sett != nil {
+		metrics.RecordSettingsCache(true)
+		return sett
+	}
+	metrics.RecordSettingsCache(false)
+	sett, ok := storageInterface.fetchGuildSettings(key)
+	// a failed read falls back to the default settings, which have no admins or operators, so it's only used for this
+	// call instead of being cached
+	if ok {
+		storageInterface.settingsCache.put(key, sett, generation)
+	}
+	return sett
+}
+
+// fetchGuildSettings reads a guild's settings from Redis, creating the default settings for guilds that don't have any
+// yet. ok is false if they couldn't be read, and the default settings were returned in their place
+func (storageInterface *StorageInterface) fetchGuildSettings(key string) (sett *settings.GuildSettings, ok bool) {
 	j, err := storageInterface.client.Get(ctx, key).Result()
 	switch {
 	case errors.Is(err, redis.Nil):
@@ -44,24 +88,24 @@ func (storageInterface *StorageInterface) GetGuildSettings(guildID string) *sett
 		jBytes, err := json.MarshalIndent(s, "", "  ")
 		if err != nil {
 			log.Println(err)
-			return settings.MakeGuildSettings()
+			return settings.MakeGuildSettings(), true
 		}
 		err = storageInterface.client.Set(ctx, key, jBytes, 0).Err()
 		if err != nil {
 			log.Println(err)
 		}
-		return s
+		return s, true
 	case err != nil:
 		log.Println(err)
-		return settings.MakeGuildSettings()
+		return settings.MakeGuildSettings(), false
 	default:
 		s := settings.GuildSettings{}
 		err := json.Unmarshal([]byte(j), &s)
 		if err != nil {
 			log.Println(err)
-			return settings.MakeGuildSettings()
+			return settings.MakeGuildSettings(), false
 		}
-		return &s
+		return &s, true
 	}
 }
 
@@ -73,6 +117,7 @@ func (storageInterface *StorageInterface) SetGuildSettings(guildID string, guild
 		return err
 	}
 	err = storageInterface.client.Set(ctx, key, jbytes, 0).Err()
+	storageInterface.invalidateSettings(key)
 	return err
 }
 
@@ -80,9 +125,168 @@ func (storageInterface *StorageInterface) DeleteGuildSettings(guildID string) er
 	key := rediskey.GuildSettings(rediskey.HashGuildID(guildID))
 
 	err := storageInterface.client.Del(ctx, key).Err()
+	storageInterface.invalidateSettings(key)
 	return err
 }
 
 func (storageInterface *StorageInterface) Close() error {
+	if err := storageInterface.pubsub.Close(); err != nil {
+		log.Println(err)
+	}
 	return storageInterface.client.Close()
 }
+
+// pendingSettingsImportTTL is how long an admin has to confirm a settings import before it's discarded
+const pendingSettingsImportTTL = time.Minute * 10
+
+func pendingSettingsImportKey(guildID, userID string) string {
+	return "automuteus:settings:import:" + string(rediskey.HashGuildID(guildID)) + ":" + userID
+}
+
+// SetPendingSettingsImport holds an uploaded settings file until the admin confirms or cancels the import. The file is
+// stored as uploaded, so that confirming applies it on top of the settings at that time
+func (storageInterface *StorageInterface) SetPendingSettingsImport(guildID, userID string, data []byte) error {
+	return storageInterface.client.Set(ctx, pendingSettingsImportKey(guildID, userID), data, pendingSettingsImportTTL).Err()
+}
+
+// GetPendingSettingsImport returns the settings file an admin has uploaded but not yet confirmed, or nil if there is
+// none
+func (storageInterface *StorageInterface) GetPendingSettingsImport(guildID, userID string) ([]byte, error) {
+	data, err := storageInterface.client.Get(ctx, pendingSettingsImportKey(guildID, userID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (storageInterface *StorageInterface) DeletePendingSettingsImport(guildID, userID string) error {
+	return storageInterface.client.Del(ctx, pendingSettingsImportKey(guildID, userID)).Err()
+}
+
+func settingsProfilesKey(guildID string) string {
+	return "automuteus:settings:profiles:" + string(rediskey.HashGuildID(guildID))
+}
+
+// SetSettingsProfile stores a named settings profile for a guild. Profiles are stored in the settings export format,
+// so they're migrated the same way as imported files when the format changes
+func (storageInterface *StorageInterface) SetSettingsProfile(guildID, name string, data []byte) error {
+	return storageInterface.client.HSet(ctx, settingsProfilesKey(guildID), name, data).Err()
+}
+
+// GetSettingsProfile returns the stored profile, or nil if no profile exists with that name
+func (storageInterface *StorageInterface) GetSettingsProfile(guildID, name string) ([]byte, error) {
+	data, err := storageInterface.client.HGet(ctx, settingsProfilesKey(guildID), name).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (storageInterface *StorageInterface) DeleteSettingsProfile(guildID, name string) (bool, error) {
+	deleted, err := storageInterface.client.HDel(ctx, settingsProfilesKey(guildID), name).Result()
+	return deleted > 0, err
+}
+
+func (storageInterface *StorageInterface) ListSettingsProfiles(guildID string) ([]string, error) {
+	return storageInterface.client.HKeys(ctx, settingsProfilesKey(guildID)).Result()
+}
+
+func channelSettingsKey(guildID string) string {
+	return "automuteus:settings:channels:" + string(rediskey.HashGuildID(guildID))
+}
+
+// SetChannelSettings stores the settings overrides for a voice channel. Only the settings that differ from the guild
+// defaults are stored, in the settings export format
+func (storageInterface *StorageInterface) SetChannelSettings(guildID, channelID string, data []byte) error {
+	return storageInterface.client.HSet(ctx, channelSettingsKey(guildID), channelID, data).Err()
+}
+
+// GetChannelSettings returns the overrides for a voice channel, or nil if the channel has none
+func (storageInterface *StorageInterface) GetChannelSettings(guildID, channelID string) ([]byte, error) {
+	data, err := storageInterface.client.HGet(ctx, channelSettingsKey(guildID), channelID).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (storageInterface *StorageInterface) DeleteChannelSettings(guildID, channelID string) (bool, error) {
+	deleted, err := storageInterface.client.HDel(ctx, channelSettingsKey(guildID), channelID).Result()
+	return deleted > 0, err
+}
+
+func (storageInterface *StorageInterface) ListChannelSettings(guildID string) ([]string, error) {
+	return storageInterface.client.HKeys(ctx, channelSettingsKey(guildID)).Result()
+}
+
+func autoStartKey(guildID string) string {
+	return "automuteus:settings:autostart:" + string(rediskey.HashGuildID(guildID))
+}
+
+// SetAutoStart stores the auto-start config for a voice channel, as JSON
+func (storageInterface *StorageInterface) SetAutoStart(guildID, voiceChannelID string, data []byte) error {
+	return storageInterface.client.HSet(ctx, autoStartKey(guildID), voiceChannelID, data).Err()
+}
+
+// GetAutoStart returns the auto-start config for a voice channel, or nil if the channel doesn't start games on its own
+func (storageInterface *StorageInterface) GetAutoStart(guildID, voiceChannelID string) ([]byte, error) {
+	data, err := storageInterface.client.HGet(ctx, autoStartKey(guildID), voiceChannelID).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (storageInterface *StorageInterface) DeleteAutoStart(guildID, voiceChannelID string) (bool, error) {
+	deleted, err := storageInterface.client.HDel(ctx, autoStartKey(guildID), voiceChannelID).Result()
+	return deleted > 0, err
+}
+
+// ListAutoStart returns every auto-start config for a guild, keyed by voice channel ID
+func (storageInterface *StorageInterface) ListAutoStart(guildID string) (map[string]string, error) {
+	return storageInterface.client.HGetAll(ctx, autoStartKey(guildID)).Result()
+}
+
+// PinnedCodeGuildScope is the field used for a connect code pinned to the whole guild, rather than one voice channel
+const PinnedCodeGuildScope = "guild"
+
+func pinnedConnectCodesKey(guildID string) string {
+	return "automuteus:connectcode:pinned:" + string(rediskey.HashGuildID(guildID))
+}
+
+// SetPinnedConnectCode pins a connect code to a voice channel, or to the guild with PinnedCodeGuildScope
+func (storageInterface *StorageInterface) SetPinnedConnectCode(guildID, scope, code string) error {
+	return storageInterface.client.HSet(ctx, pinnedConnectCodesKey(guildID), scope, code).Err()
+}
+
+// GetPinnedConnectCode returns the connect code pinned to a voice channel (or the guild), or "" if there isn't one
+func (storageInterface *StorageInterface) GetPinnedConnectCode(guildID, scope string) (string, error) {
+	code, err := storageInterface.client.HGet(ctx, pinnedConnectCodesKey(guildID), scope).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return code, err
+}
+
+func (storageInterface *StorageInterface) DeletePinnedConnectCode(guildID, scope string) (bool, error) {
+	deleted, err := storageInterface.client.HDel(ctx, pinnedConnectCodesKey(guildID), scope).Result()
+	return deleted > 0, err
+}
+
+func commandPermissionsKey(guildID string) string {
+	return "automuteus:settings:permissions:" + string(rediskey.HashGuildID(guildID))
+}
+
+// SetCommandPermissions stores the per-command grants for a guild, as JSON
+func (storageInterface *StorageInterface) SetCommandPermissions(guildID string, data []byte) error {
+	return storageInterface.client.Set(ctx, commandPermissionsKey(guildID), data, 0).Err()
+}
+
+// GetCommandPermissions returns the per-command grants for a guild, or nil if none have been configured
+func (storageInterface *StorageInterface) GetCommandPermissions(guildID string) ([]byte, error) {
+	data, err := storageInterface.client.Get(ctx, commandPermissionsKey(guildID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return data, err
+}
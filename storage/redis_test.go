@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/automuteus/utils/pkg/rediskey"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestGetGuildSettingsFailedReadIsNotCached(t *testing.T) {
+	// nothing listens on port 1, so every read fails
+	storageInterface := &StorageInterface{
+		client: redis.NewClient(&redis.Options{
+			Addr:        "127.0.0.1:1",
+			DialTimeout: time.Millisecond * 100,
+			MaxRetries:  -1,
+		}),
+		settingsCache: newSettingsCache(settingsCacheSize),
+	}
+	defer storageInterface.client.Close()
+
+	sett := storageInterface.GetGuildSettings("1234")
+	if sett == nil {
+		t.Fatal("Expected the default settings when Redis can't be read")
+	}
+	key := rediskey.GuildSettings(rediskey.HashGuildID("1234"))
+	if cached, _ := storageInterface.settingsCache.get(key); cached != nil {
+		t.Error("Expected the default settings from a failed read not to be cached")
+	}
+}
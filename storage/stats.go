@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/automuteus/utils/pkg/game"
+	"github.com/automuteus/utils/pkg/task"
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// These mirror the all-time stats queries in the utils repo, but only count games that started in a TimeRange
+
+// TimeRange covers games that started at or after Start, and before End
+type TimeRange struct {
+	Start int32
+	End   int32
+}
+
+var AllTime = TimeRange{Start: 0, End: math.MaxInt32}
+
+// AnyRole can be used in place of a role to include games played as either one
+const AnyRole int16 = -1
+
+type UserPeriodStats struct {
+	Games         int64 `db:"games"`
+	Wins          int64 `db:"wins"`
+	CrewmateGames int64 `db:"crewmate_games"`
+	CrewmateWins  int64 `db:"crewmate_wins"`
+	ImposterGames int64 `db:"imposter_games"`
+	ImposterWins  int64 `db:"imposter_wins"`
+}
+
+func GetUserStats(pool *pgxpool.Pool, guildID, userID uint64, period TimeRange) (*UserPeriodStats, error) {
+	var r UserPeriodStats
+	err := pgxscan.Get(context.Background(), pool, &r,
+		"SELECT COUNT(*) AS games, "+
+			"COUNT(*) FILTER ( WHERE users_games.player_won = true ) AS wins, "+
+			"COUNT(*) FILTER ( WHERE users_games.player_role = 0 ) AS crewmate_games, "+
+			"COUNT(*) FILTER ( WHERE users_games.player_role = 0 AND users_games.player_won = true ) AS crewmate_wins, "+
+			"COUNT(*) FILTER ( WHERE users_games.player_role = 1 ) AS imposter_games, "+
+			"COUNT(*) FILTER ( WHERE users_games.player_role = 1 AND users_games.player_won = true ) AS imposter_wins "+
+			"FROM users_games INNER JOIN games ON games.game_id = users_games.game_id "+
+			"WHERE users_games.guild_id = $1 AND users_games.user_id = $2 AND games.start_time >= $3 AND games.start_time < $4;",
+		guildID, userID, period.Start, period.End)
+	return &r, err
+}
+
+// ColorCount is how many games a user played as a color
+type ColorCount struct {
+	Color int16 `db:"player_color"`
+	Count int64 `db:"count"`
+}
+
+// GetColorRankings returns the colors a user played as on a guild, most played first
+func GetColorRankings(pool *pgxpool.Pool, guildID, userID uint64, period TimeRange) ([]*ColorCount, error) {
+	var r []*ColorCount
+	err := pgxscan.Select(context.Background(), pool, &r,
+		"SELECT users_games.player_color, COUNT(*) AS count "+
+			"FROM users_games INNER JOIN games ON games.game_id = users_games.game_id "+
+			"WHERE users_games.guild_id = $1 AND users_games.user_id = $2 AND games.start_time >= $3 AND games.start_time < $4 "+
+			"GROUP BY users_games.player_color ORDER BY count DESC, users_games.player_color;",
+		guildID, userID, period.Start, period.End)
+	return r, err
+}
+
+// GlobalUserStats is a user's stats from every guild they played on
+type GlobalUserStats struct {
+	UserPeriodStats
+	Guilds int64 `db:"guilds"`
+}
+
+func GetGlobalUserStats(pool *pgxpool.Pool, userID uint64, period TimeRange) (*GlobalUserStats, error) {
+	var r GlobalUserStats
+	err := pgxscan.Get(context.Background(), pool, &r,
+		"SELECT COUNT(*) AS games, "+
+			"COUNT(*) FILTER ( WHERE users_games.player_won = true ) AS wins, "+
+			"COUNT(*) FILTER ( WHERE users_games.player_role = 0 ) AS crewmate_games, "+
+			"COUNT(*) FILTER ( WHERE users_games.player_role = 0 AND users_games.player_won = true ) AS crewmate_wins, "+
+			"COUNT(*) FILTER ( WHERE users_games.player_role = 1 ) AS imposter_games, "+
+			"COUNT(*) FILTER ( WHERE users_games.player_role = 1 AND users_games.player_won = true ) AS imposter_wins, "+
+			"COUNT(DISTINCT users_games.guild_id) AS guilds "+
+			"FROM users_games INNER JOIN games ON games.game_id = users_games.game_id "+
+			"WHERE users_games.user_id = $1 AND games.start_time >= $2 AND games.start_time < $3;",
+		userID, period.Start, period.End)
+	return &r, err
+}
+
+type GuildPeriodStats struct {
+	Games        int64 `db:"games"`
+	CrewmateWins int64 `db:"crewmate_wins"`
+	ImposterWins int64 `db:"imposter_wins"`
+}
+
+func GetGuildStats(pool *pgxpool.Pool, guildID uint64, period TimeRange) (*GuildPeriodStats, error) {
+	var r GuildPeriodStats
+	err := pgxscan.Get(context.Background(), pool, &r,
+		"SELECT COUNT(*) AS games, "+
+			"COUNT(*) FILTER ( WHERE win_type = 0 OR win_type = 1 OR win_type = 6 ) AS crewmate_wins, "+
+			"COUNT(*) FILTER ( WHERE win_type = 2 OR win_type = 3 OR win_type = 4 OR win_type = 5 ) AS imposter_wins "+
+			"FROM games WHERE guild_id = $1 AND end_time != -1 AND start_time >= $2 AND start_time < $3;",
+		guildID, period.Start, period.End)
+	return &r, err
+}
+
+type PlayerRanking struct {
+	UserID  uint64  `db:"user_id"`
+	Games   int64   `db:"games"`
+	Wins    int64   `db:"wins"`
+	WinRate float64 `db:"win_rate"`
+}
+
+// RankingOrder is the column player rankings are sorted by
+type RankingOrder string
+
+const (
+	OrderByGames   RankingOrder = "games"
+	OrderByWins    RankingOrder = "wins"
+	OrderByWinRate RankingOrder = "win_rate"
+)
+
+// GetPlayerRankings ranks the players of a guild that played at least minGames games as a role (or AnyRole) in a period
+func GetPlayerRankings(pool *pgxpool.Pool, guildID uint64, role int16, period TimeRange, order RankingOrder, ascending bool, minGames, limit, offset int) ([]*PlayerRanking, error) {
+	switch order {
+	case OrderByGames, OrderByWins, OrderByWinRate:
+	default:
+		return nil, fmt.Errorf("unknown ranking order %s", order)
+	}
+	direction := "DESC"
+	if ascending {
+		direction = "ASC"
+	}
+	var r []*PlayerRanking
+	err := pgxscan.Select(context.Background(), pool, &r,
+		"SELECT users_games.user_id, "+
+			"COUNT(*) AS games, "+
+			"COUNT(*) FILTER ( WHERE users_games.player_won = true ) AS wins, "+
+			"(COUNT(*) FILTER ( WHERE users_games.player_won = true ))::float8 / COUNT(*) * 100 AS win_rate "+
+			"FROM users_games INNER JOIN games ON games.game_id = users_games.game_id "+
+			"WHERE users_games.guild_id = $1 AND ($2::smallint < 0 OR users_games.player_role = $2) "+
+			"AND games.start_time >= $3 AND games.start_time < $4 "+
+			"GROUP BY users_games.user_id "+
+			"HAVING COUNT(*) >= $5 "+
+			fmt.Sprintf("ORDER BY %s %s, users_games.user_id ", order, direction)+
+			"LIMIT $6 OFFSET $7;",
+		guildID, role, period.Start, period.End, minGames, limit, offset)
+	return r, err
+}
+
+// VersusStats compares two players, A and B, over the games they played together. Capture only records who died or was
+// exiled, not who killed or voted for them, so deaths and exiles are counted for games the players were on opposite teams
+type VersusStats struct {
+	Games          int64 `db:"games"`
+	TeammateGames  int64 `db:"teammate_games"`
+	TeammateWins   int64 `db:"teammate_wins"`
+	AImposterGames int64 `db:"a_imposter_games"`
+	AImposterWins  int64 `db:"a_imposter_wins"`
+	BImposterGames int64 `db:"b_imposter_games"`
+	BImposterWins  int64 `db:"b_imposter_wins"`
+	// A died as a crewmate while B was an imposter
+	AKilled int64 `db:"a_killed"`
+	BKilled int64 `db:"b_killed"`
+	// A was exiled while on the opposite team from B
+	AExiled int64 `db:"a_exiled"`
+	BExiled int64 `db:"b_exiled"`
+}
+
+// OpponentGames is how many games the players played on opposite teams
+func (v *VersusStats) OpponentGames() int64 {
+	return v.AImposterGames + v.BImposterGames
+}
+
+func GetVersusStats(pool *pgxpool.Pool, guildID, userA, userB uint64) (*VersusStats, error) {
+	var r VersusStats
+	err := pgxscan.Get(context.Background(), pool, &r,
+		"WITH shared AS ("+
+			"SELECT a.game_id, a.player_role AS a_role, a.player_won AS a_won, b.player_role AS b_role "+
+			"FROM users_games a INNER JOIN users_games b ON b.game_id = a.game_id AND b.user_id = $3 "+
+			"WHERE a.guild_id = $1 AND a.user_id = $2), "+
+			"events AS ("+
+			"SELECT shared.*, game_events.user_id, game_events.payload ->> 'Action' AS action "+
+			"FROM shared INNER JOIN game_events ON game_events.game_id = shared.game_id "+
+			"WHERE game_events.event_type = $4 AND game_events.user_id IN ($2, $3) AND shared.a_role != shared.b_role) "+
+			"SELECT "+
+			"(SELECT COUNT(*) FROM shared) AS games, "+
+			"(SELECT COUNT(*) FROM shared WHERE a_role = b_role) AS teammate_games, "+
+			"(SELECT COUNT(*) FROM shared WHERE a_role = b_role AND a_won) AS teammate_wins, "+
+			"(SELECT COUNT(*) FROM shared WHERE a_role = 1 AND b_role = 0) AS a_imposter_games, "+
+			"(SELECT COUNT(*) FROM shared WHERE a_role = 1 AND b_role = 0 AND a_won) AS a_imposter_wins, "+
+			"(SELECT COUNT(*) FROM shared WHERE a_role = 0 AND b_role = 1) AS b_imposter_games, "+
+			"(SELECT COUNT(*) FROM shared WHERE a_role = 0 AND b_role = 1 AND NOT a_won) AS b_imposter_wins, "+
+			"(SELECT COUNT(*) FROM events WHERE user_id = $2 AND action = $5 AND a_role = 0) AS a_killed, "+
+			"(SELECT COUNT(*) FROM events WHERE user_id = $3 AND action = $5 AND b_role = 0) AS b_killed, "+
+			"(SELECT COUNT(*) FROM events WHERE user_id = $2 AND action = $6) AS a_exiled, "+
+			"(SELECT COUNT(*) FROM events WHERE user_id = $3 AND action = $6) AS b_exiled;",
+		guildID, userA, userB, int16(task.PlayerJob), strconv.Itoa(int(game.DIED)), strconv.Itoa(int(game.EXILED)))
+	return &r, err
+}
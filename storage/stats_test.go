@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/automuteus/utils/pkg/game"
+	"github.com/automuteus/utils/pkg/task"
+)
+
+func TestGetUserStatsPeriod(t *testing.T) {
+	pool := testPool(t)
+	crewmate, imposter := int16(game.CrewmateRole), int16(game.ImposterRole)
+	for i, v := range []struct {
+		role int16
+		won  bool
+	}{{crewmate, true}, {crewmate, false}, {imposter, true}, {imposter, false}} {
+		gameID := addTestGame(t, pool, 1, int32(i*100), int32(i*100+50))
+		addTestPlayer(t, pool, 1, gameID, 1, v.role, v.won)
+	}
+	gameID := addTestGame(t, pool, 2, 0, 50)
+	addTestPlayer(t, pool, 2, gameID, 1, crewmate, true)
+
+	stats, err := GetUserStats(pool, 1, 1, AllTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := UserPeriodStats{Games: 4, Wins: 2, CrewmateGames: 2, CrewmateWins: 1, ImposterGames: 2, ImposterWins: 1}
+	if *stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *stats)
+	}
+
+	// the period includes games that started at its start, but not at its end
+	stats, err = GetUserStats(pool, 1, 1, TimeRange{Start: 100, End: 300})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = UserPeriodStats{Games: 2, Wins: 1, CrewmateGames: 1, ImposterGames: 1, ImposterWins: 1}
+	if *stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *stats)
+	}
+
+	global, err := GetGlobalUserStats(pool, 1, AllTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if global.Games != 5 || global.Wins != 3 || global.Guilds != 2 {
+		t.Errorf("Expected 5 games and 3 wins on 2 guilds, got %+v", global)
+	}
+}
+
+func TestGetColorRankings(t *testing.T) {
+	pool := testPool(t)
+	for i, color := range []int16{3, 1, 3, 1, 3, 5} {
+		gameID := addTestGame(t, pool, 1, int32(i*100), int32(i*100+50))
+		addTestPlayer(t, pool, 1, gameID, 1, int16(game.CrewmateRole), true)
+		_, err := pool.Exec(context.Background(),
+			"UPDATE users_games SET player_color = $1 WHERE game_id = $2;", color, gameID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	colors, err := GetColorRankings(pool, 1, 1, TimeRange{Start: 0, End: 500})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []ColorCount
+	for _, v := range colors {
+		got = append(got, *v)
+	}
+	// the game played as color 5 started after the period
+	expected := []ColorCount{{Color: 3, Count: 3}, {Color: 1, Count: 2}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestGetPlayerRankings(t *testing.T) {
+	pool := testPool(t)
+	crewmate, imposter := int16(game.CrewmateRole), int16(game.ImposterRole)
+	play := func(userID uint64, role int16, results ...bool) {
+		for i, won := range results {
+			gameID := addTestGame(t, pool, 1, int32(i*100), int32(i*100+50))
+			addTestPlayer(t, pool, 1, gameID, userID, role, won)
+		}
+	}
+	// user 1 wins 3 of 4, user 2 wins 2 of 2, user 3 wins 1 of 5
+	play(1, crewmate, true, true, true, false)
+	play(2, imposter, true, true)
+	play(3, crewmate, false, false, true, false, false)
+
+	userIDs := func(rankings []*PlayerRanking) []uint64 {
+		ids := make([]uint64, len(rankings))
+		for i, v := range rankings {
+			ids[i] = v.UserID
+		}
+		return ids
+	}
+	tests := []struct {
+		role      int16
+		order     RankingOrder
+		ascending bool
+		minGames  int
+		expected  []uint64
+	}{
+		{AnyRole, OrderByGames, false, 1, []uint64{3, 1, 2}},
+		{AnyRole, OrderByWins, false, 1, []uint64{1, 2, 3}},
+		{AnyRole, OrderByWinRate, false, 1, []uint64{2, 1, 3}},
+		{AnyRole, OrderByWinRate, true, 1, []uint64{3, 1, 2}},
+		{AnyRole, OrderByWinRate, false, 3, []uint64{1, 3}},
+		{crewmate, OrderByWins, false, 1, []uint64{1, 3}},
+		{imposter, OrderByWins, false, 1, []uint64{2}},
+	}
+	for _, test := range tests {
+		rankings, err := GetPlayerRankings(pool, 1, test.role, AllTime, test.order, test.ascending, test.minGames, 10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := userIDs(rankings); !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("Role %d by %s ascending=%t min=%d: expected %v, got %v",
+				test.role, test.order, test.ascending, test.minGames, test.expected, ids)
+		}
+	}
+
+	rankings, err := GetPlayerRankings(pool, 1, AnyRole, AllTime, OrderByWinRate, false, 1, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rankings[1].WinRate != 75 {
+		t.Errorf("Expected a 75%% win rate for user 1, got %f", rankings[1].WinRate)
+	}
+	if _, err := GetPlayerRankings(pool, 1, AnyRole, AllTime, "games; DROP TABLE games", false, 1, 10, 0); err == nil {
+		t.Error("Expected an unknown order to be rejected")
+	}
+}
+
+func TestGetVersusStats(t *testing.T) {
+	pool := testPool(t)
+	crewmate, imposter := int16(game.CrewmateRole), int16(game.ImposterRole)
+	games := []struct {
+		aRole, bRole int16
+		aWon, bWon   bool
+		// who died in the game, if anyone
+		died uint64
+	}{
+		{crewmate, crewmate, true, true, 0},
+		{crewmate, crewmate, false, false, 0},
+		{imposter, crewmate, true, false, 2},
+		{crewmate, imposter, false, true, 1},
+		{crewmate, imposter, true, false, 0},
+	}
+	for i, v := range games {
+		gameID := addTestGame(t, pool, 1, int32(i*100), int32(i*100+50))
+		addTestPlayer(t, pool, 1, gameID, 1, v.aRole, v.aWon)
+		addTestPlayer(t, pool, 1, gameID, 2, v.bRole, v.bWon)
+		if v.died != 0 {
+			_, err := pool.Exec(context.Background(),
+				"INSERT INTO game_events (user_id, game_id, event_time, event_type, payload) VALUES ($1, $2, $3, $4, $5);",
+				v.died, gameID, int32(i*100+10), int16(task.PlayerJob), `{"Action": "`+strconv.Itoa(int(game.DIED))+`"}`)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	stats, err := GetVersusStats(pool, 1, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := VersusStats{
+		Games:          5,
+		TeammateGames:  2,
+		TeammateWins:   1,
+		AImposterGames: 1,
+		AImposterWins:  1,
+		BImposterGames: 2,
+		BImposterWins:  1,
+		AKilled:        1,
+		BKilled:        1,
+	}
+	if *stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *stats)
+	}
+	if stats.OpponentGames() != 3 {
+		t.Errorf("Expected 3 games on opposite teams, got %d", stats.OpponentGames())
+	}
+}
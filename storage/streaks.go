@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// StreakRanking is a player's win streaks on a guild. Current is 0 if they lost their latest game
+type StreakRanking struct {
+	UserID  uint64 `db:"user_id"`
+	Games   int64  `db:"games"`
+	Current int64  `db:"current_streak"`
+	Best    int64  `db:"best_streak"`
+}
+
+// StreakOrder is the streak player rankings are sorted by
+type StreakOrder string
+
+const (
+	OrderByCurrentStreak StreakOrder = "current_streak"
+	OrderByBestStreak    StreakOrder = "best_streak"
+)
+
+// streaksQuery numbers each player's games by when they ended, overall (n) and among the games they won or lost (m).
+// Consecutive wins share the same n - m, so grouping by it gives each streak, and a streak is current if it includes
+// the player's last game
+const streaksQuery = "WITH played AS (" +
+	"SELECT users_games.user_id, users_games.player_won, " +
+	"ROW_NUMBER() OVER (PARTITION BY users_games.user_id ORDER BY games.end_time, games.game_id) AS n, " +
+	"ROW_NUMBER() OVER (PARTITION BY users_games.user_id, users_games.player_won ORDER BY games.end_time, games.game_id) AS m " +
+	"FROM users_games INNER JOIN games ON games.game_id = users_games.game_id " +
+	"WHERE users_games.guild_id = $1 AND ($2::smallint < 0 OR users_games.player_role = $2) " +
+	"AND ($3::numeric = 0 OR users_games.user_id = $3)), " +
+	"players AS (SELECT user_id, COUNT(*) AS games FROM played GROUP BY user_id HAVING COUNT(*) >= $4), " +
+	"streaks AS (SELECT user_id, COUNT(*) AS length, MAX(n) AS last FROM played WHERE player_won GROUP BY user_id, n - m) " +
+	"SELECT players.user_id, players.games, " +
+	"COALESCE(MAX(streaks.length) FILTER ( WHERE streaks.last = players.games ), 0) AS current_streak, " +
+	"COALESCE(MAX(streaks.length), 0) AS best_streak " +
+	"FROM players LEFT JOIN streaks ON streaks.user_id = players.user_id " +
+	"GROUP BY players.user_id, players.games "
+
+// GetStreakRankings ranks the players of a guild that played at least minGames games as a role (or AnyRole) by their
+// current or best win streak
+func GetStreakRankings(pool *pgxpool.Pool, guildID uint64, role int16, order StreakOrder, ascending bool, minGames, limit, offset int) ([]*StreakRanking, error) {
+	switch order {
+	case OrderByCurrentStreak, OrderByBestStreak:
+	default:
+		return nil, fmt.Errorf("unknown streak order %s", order)
+	}
+	direction := "DESC"
+	if ascending {
+		direction = "ASC"
+	}
+	var r []*StreakRanking
+	err := pgxscan.Select(context.Background(), pool, &r,
+		streaksQuery+fmt.Sprintf("ORDER BY %s %s, players.user_id ", order, direction)+"LIMIT $5 OFFSET $6;",
+		guildID, role, 0, minGames, limit, offset)
+	return r, err
+}
+
+// GetUserStreak returns a player's win streaks as a role (or AnyRole) on a guild
+func GetUserStreak(pool *pgxpool.Pool, guildID, userID uint64, role int16) (*StreakRanking, error) {
+	var r []*StreakRanking
+	err := pgxscan.Select(context.Background(), pool, &r, streaksQuery+";", guildID, role, userID, 0)
+	if err != nil || len(r) == 0 {
+		return &StreakRanking{UserID: userID}, err
+	}
+	return r[0], nil
+}
+
+// GetRecentForm returns whether a player won each of their last limit games on a guild, oldest first
+func GetRecentForm(pool *pgxpool.Pool, guildID, userID uint64, limit int) ([]bool, error) {
+	var r []bool
+	err := pgxscan.Select(context.Background(), pool, &r,
+		"SELECT player_won FROM ("+
+			"SELECT users_games.player_won, games.end_time, games.game_id "+
+			"FROM users_games INNER JOIN games ON games.game_id = users_games.game_id "+
+			"WHERE users_games.guild_id = $1 AND users_games.user_id = $2 "+
+			"ORDER BY games.end_time DESC, games.game_id DESC LIMIT $3) recent "+
+			"ORDER BY end_time, game_id;",
+		guildID, userID, limit)
+	return r, err
+}
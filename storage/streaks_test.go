@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/automuteus/utils/pkg/game"
+)
+
+func TestGetUserStreak(t *testing.T) {
+	pool := testPool(t)
+	crewmate, imposter := int16(game.CrewmateRole), int16(game.ImposterRole)
+	// user 1: W W L W W W L W overall
+	results := []struct {
+		role int16
+		won  bool
+	}{
+		{crewmate, true},
+		{imposter, true},
+		{crewmate, false},
+		{crewmate, true},
+		{imposter, true},
+		{crewmate, true},
+		{imposter, false},
+		{crewmate, true},
+	}
+	// added newest first, so the query has to order by end time rather than by game ID
+	for i := len(results) - 1; i >= 0; i-- {
+		gameID := addTestGame(t, pool, 1, int32(i*100), int32(i*100+50))
+		addTestPlayer(t, pool, 1, gameID, 1, results[i].role, results[i].won)
+	}
+	// a loss on another guild doesn't end any streak on this one
+	gameID := addTestGame(t, pool, 2, 10000, 10050)
+	addTestPlayer(t, pool, 2, gameID, 1, crewmate, false)
+
+	tests := []struct {
+		role          int16
+		current, best int64
+	}{
+		{AnyRole, 1, 3},
+		{crewmate, 3, 3},
+		{imposter, 0, 2},
+	}
+	for _, test := range tests {
+		streak, err := GetUserStreak(pool, 1, 1, test.role)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if streak.Current != test.current || streak.Best != test.best {
+			t.Errorf("Role %d: expected a current streak of %d and best of %d, got %d and %d",
+				test.role, test.current, test.best, streak.Current, streak.Best)
+		}
+	}
+
+	streak, err := GetUserStreak(pool, 1, 4, AnyRole)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if streak.UserID != 4 || streak.Games != 0 || streak.Current != 0 || streak.Best != 0 {
+		t.Errorf("Expected no streaks for a user without games, got %+v", streak)
+	}
+}
+
+func TestGetStreakRankings(t *testing.T) {
+	pool := testPool(t)
+	crewmate := int16(game.CrewmateRole)
+	play := func(userID uint64, results ...bool) {
+		for i, won := range results {
+			gameID := addTestGame(t, pool, 1, int32(i*100), int32(i*100+50))
+			addTestPlayer(t, pool, 1, gameID, userID, crewmate, won)
+		}
+	}
+	play(1, true, true, true, false, true)
+	play(2, true, true)
+	play(3, false)
+
+	userIDs := func(rankings []*StreakRanking) []uint64 {
+		ids := make([]uint64, len(rankings))
+		for i, v := range rankings {
+			ids[i] = v.UserID
+		}
+		return ids
+	}
+	tests := []struct {
+		order     StreakOrder
+		ascending bool
+		minGames  int
+		expected  []uint64
+	}{
+		{OrderByCurrentStreak, false, 1, []uint64{2, 1, 3}},
+		{OrderByBestStreak, false, 1, []uint64{1, 2, 3}},
+		{OrderByBestStreak, true, 1, []uint64{3, 2, 1}},
+		{OrderByCurrentStreak, false, 2, []uint64{2, 1}},
+	}
+	for _, test := range tests {
+		rankings, err := GetStreakRankings(pool, 1, AnyRole, test.order, test.ascending, test.minGames, 10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := userIDs(rankings); !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("%s ascending=%t min=%d: expected %v, got %v", test.order, test.ascending, test.minGames, test.expected, ids)
+		}
+	}
+
+	rankings, err := GetStreakRankings(pool, 1, AnyRole, OrderByBestStreak, false, 1, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := userIDs(rankings); !reflect.DeepEqual(ids, []uint64{2}) {
+		t.Errorf("Expected the second page to have user 2, got %v", ids)
+	}
+	if _, err := GetStreakRankings(pool, 1, AnyRole, "games; DROP TABLE games", false, 1, 10, 0); err == nil {
+		t.Error("Expected an unknown order to be rejected")
+	}
+}
+
+func TestGetRecentForm(t *testing.T) {
+	pool := testPool(t)
+	results := []bool{true, false, false, true, true, false, true}
+	for i := len(results) - 1; i >= 0; i-- {
+		gameID := addTestGame(t, pool, 1, int32(i*100), int32(i*100+50))
+		addTestPlayer(t, pool, 1, gameID, 1, int16(game.CrewmateRole), results[i])
+	}
+
+	form, err := GetRecentForm(pool, 1, 1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := results[2:]; !reflect.DeepEqual(form, expected) {
+		t.Errorf("Expected the last 5 games oldest first, %v, got %v", expected, form)
+	}
+	form, err = GetRecentForm(pool, 2, 1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(form) != 0 {
+		t.Errorf("Expected no games on another guild, got %v", form)
+	}
+}
@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"context"
+	"errors"
+
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// UnlinkedUserGame is a game played by someone that wasn't linked to a Discord user, known only by in-game name
+type UnlinkedUserGame struct {
+	GuildID     uint64 `db:"guild_id"`
+	GameID      int64  `db:"game_id"`
+	PlayerName  string `db:"player_name"`
+	PlayerColor int16  `db:"player_color"`
+	PlayerRole  int16  `db:"player_role"`
+	PlayerWon   bool   `db:"player_won"`
+}
+
+// UnlinkedPlayer is an in-game name that has unlinked games on a guild
+type UnlinkedPlayer struct {
+	PlayerName string `db:"player_name"`
+	Games      int64  `db:"games"`
+	Wins       int64  `db:"wins"`
+}
+
+type UnlinkedClaim struct {
+	ClaimID     int64  `db:"claim_id"`
+	GuildID     uint64 `db:"guild_id"`
+	UserID      uint64 `db:"user_id"`
+	PlayerName  string `db:"player_name"`
+	RequestTime int32  `db:"request_time"`
+}
+
+func IsUnlinkedStatsEnabled(pool *pgxpool.Pool, guildID uint64) (bool, error) {
+	var enabled bool
+	err := pool.QueryRow(context.Background(),
+		"SELECT EXISTS (SELECT 1 FROM unlinked_stats_guilds WHERE guild_id = $1);", guildID).Scan(&enabled)
+	return enabled, err
+}
+
+func SetUnlinkedStatsEnabled(pool *pgxpool.Pool, guildID uint64, enabled bool) error {
+	var err error
+	if enabled {
+		_, err = pool.Exec(context.Background(), "INSERT INTO unlinked_stats_guilds VALUES ($1) ON CONFLICT DO NOTHING;", guildID)
+	} else {
+		_, err = pool.Exec(context.Background(), "DELETE FROM unlinked_stats_guilds WHERE guild_id = $1;", guildID)
+	}
+	return err
+}
+
+// AddUnlinkedUserGames records unlinked games, except for in-game names that were ever linked to a user on the guild
+// (those are someone's games, even if the link was cleared since) or that belonged to a user that opted out
+func AddUnlinkedUserGames(pool *pgxpool.Pool, games []*UnlinkedUserGame) error {
+	for _, v := range games {
+		_, err := pool.Exec(context.Background(),
+			"INSERT INTO unlinked_users_games SELECT $1::numeric, $2::bigint, $3::varchar, $4::smallint, $5::smallint, $6::bool "+
+				"WHERE NOT EXISTS (SELECT 1 FROM users_games WHERE guild_id = $1 AND lower(player_name) = lower($3)) "+
+				"AND NOT EXISTS (SELECT 1 FROM unlinked_ignored_names WHERE guild_id = $1 AND player_name = lower($3)) "+
+				"ON CONFLICT DO NOTHING;",
+			v.GuildID, v.GameID, v.PlayerName, v.PlayerColor, v.PlayerRole, v.PlayerWon)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IgnoreUnlinkedNames deletes the unlinked games played under the in-game names of a user that opted out, and keeps
+// any more from being recorded under them
+func IgnoreUnlinkedNames(pool *pgxpool.Pool, guildID uint64, names []string) error {
+	ctx := context.Background()
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, name := range names {
+		_, err = tx.Exec(ctx, "INSERT INTO unlinked_ignored_names VALUES ($1, lower($2)) ON CONFLICT DO NOTHING;", guildID, name)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, "DELETE FROM unlinked_users_games WHERE guild_id = $1 AND lower(player_name) = lower($2);", guildID, name)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// GetUnlinkedPlayers returns the in-game names with the most unlinked games on a guild
+func GetUnlinkedPlayers(pool *pgxpool.Pool, guildID uint64, limit int) ([]*UnlinkedPlayer, error) {
+	var r []*UnlinkedPlayer
+	err := pgxscan.Select(context.Background(), pool, &r,
+		"SELECT MIN(player_name) AS player_name, COUNT(*) AS games, COUNT(*) FILTER ( WHERE player_won ) AS wins "+
+			"FROM unlinked_users_games WHERE guild_id = $1 GROUP BY lower(player_name) "+
+			"ORDER BY games DESC, player_name LIMIT $2;",
+		guildID, limit)
+	return r, err
+}
+
+// CountUnlinkedGames returns how many unlinked games were played under an in-game name on a guild, ignoring case
+func CountUnlinkedGames(pool *pgxpool.Pool, guildID uint64, playerName string) (int64, error) {
+	var count int64
+	err := pool.QueryRow(context.Background(),
+		"SELECT COUNT(*) FROM unlinked_users_games WHERE guild_id = $1 AND lower(player_name) = lower($2);",
+		guildID, playerName).Scan(&count)
+	return count, err
+}
+
+func AddUnlinkedClaim(pool *pgxpool.Pool, claim *UnlinkedClaim) (int64, error) {
+	var id int64
+	err := pool.QueryRow(context.Background(),
+		"INSERT INTO unlinked_claims VALUES (DEFAULT, $1, $2, $3, $4) RETURNING claim_id;",
+		claim.GuildID, claim.UserID, claim.PlayerName, claim.RequestTime,
+	).Scan(&id)
+	return id, err
+}
+
+// GetUnlinkedClaim returns a pending claim, or nil if the guild has no claim with that ID
+func GetUnlinkedClaim(pool *pgxpool.Pool, guildID uint64, claimID int64) (*UnlinkedClaim, error) {
+	var claim UnlinkedClaim
+	err := pgxscan.Get(context.Background(), pool, &claim,
+		"SELECT * FROM unlinked_claims WHERE guild_id = $1 AND claim_id = $2;", guildID, claimID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// DeleteUnlinkedClaim answers a claim. It returns false if it was already answered, so a claim is only handled once
+func DeleteUnlinkedClaim(pool *pgxpool.Pool, claimID int64) (bool, error) {
+	tag, err := pool.Exec(context.Background(), "DELETE FROM unlinked_claims WHERE claim_id = $1;", claimID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ApproveUnlinkedClaim answers a claim by moving the unlinked games played under the claimed in-game name to the
+// user's games, and returns how many were moved. The claim is deleted in the same transaction, so it stays pending if
+// the games can't be moved; answered is false if it was already answered. Games the user already played linked are
+// left alone, and nothing is moved for users that opted out. Ratings aren't recalculated for claimed games
+func ApproveUnlinkedClaim(pool *pgxpool.Pool, claim *UnlinkedClaim) (moved int64, answered bool, err error) {
+	ctx := context.Background()
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, "DELETE FROM unlinked_claims WHERE claim_id = $1;", claim.ClaimID)
+	if err != nil || tag.RowsAffected() == 0 {
+		return 0, false, err
+	}
+	_, err = tx.Exec(ctx, "INSERT INTO users (user_id, opt) VALUES ($1, true) ON CONFLICT DO NOTHING;", claim.UserID)
+	if err != nil {
+		return 0, false, err
+	}
+	tag, err = tx.Exec(ctx,
+		"WITH moved AS ("+
+			"DELETE FROM unlinked_users_games WHERE guild_id = $1 AND lower(player_name) = lower($3) "+
+			"AND EXISTS (SELECT 1 FROM users WHERE users.user_id = $2 AND users.opt) AND NOT EXISTS ("+
+			"SELECT 1 FROM users_games WHERE users_games.user_id = $2 AND users_games.game_id = unlinked_users_games.game_id) "+
+			"RETURNING *) "+
+			"INSERT INTO users_games (user_id, guild_id, game_id, player_name, player_color, player_role, player_won) "+
+			"SELECT $2, guild_id, game_id, player_name, player_color, player_role, player_won FROM moved;",
+		claim.GuildID, claim.UserID, claim.PlayerName)
+	if err != nil {
+		return 0, false, err
+	}
+	return tag.RowsAffected(), true, tx.Commit(ctx)
+}
+
+func DeleteUnlinkedClaimsForUser(pool *pgxpool.Pool, userID uint64) error {
+	_, err := pool.Exec(context.Background(), "DELETE FROM unlinked_claims WHERE user_id = $1;", userID)
+	return err
+}
+
+// DeleteUnlinkedForGuild deletes a guild's unlinked games, pending claims and ignored names
+func DeleteUnlinkedForGuild(pool *pgxpool.Pool, guildID uint64) error {
+	_, err := pool.Exec(context.Background(), "DELETE FROM unlinked_users_games WHERE guild_id = $1;", guildID)
+	if err != nil {
+		return err
+	}
+	_, err = pool.Exec(context.Background(), "DELETE FROM unlinked_claims WHERE guild_id = $1;", guildID)
+	if err != nil {
+		return err
+	}
+	_, err = pool.Exec(context.Background(), "DELETE FROM unlinked_ignored_names WHERE guild_id = $1;", guildID)
+	return err
+}
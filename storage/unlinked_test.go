@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/automuteus/utils/pkg/game"
+)
+
+func unlinkedGame(gameID int64, name string) *UnlinkedUserGame {
+	return &UnlinkedUserGame{GuildID: 1, GameID: gameID, PlayerName: name, PlayerRole: int16(game.CrewmateRole), PlayerWon: true}
+}
+
+func TestAddUnlinkedUserGames(t *testing.T) {
+	pool := testPool(t)
+	first := addTestGame(t, pool, 1, 0, 50)
+	addTestPlayer(t, pool, 1, first, 1, int16(game.CrewmateRole), true)
+	_, err := pool.Exec(context.Background(), "UPDATE users_games SET player_name = 'Red' WHERE game_id = $1;", first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second := addTestGame(t, pool, 1, 100, 150)
+	if err := IgnoreUnlinkedNames(pool, 1, []string{"Green"}); err != nil {
+		t.Fatal(err)
+	}
+	err = AddUnlinkedUserGames(pool, []*UnlinkedUserGame{
+		unlinkedGame(second, "red"),
+		unlinkedGame(second, "Blue"),
+		unlinkedGame(second, "GREEN"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name     string
+		expected int64
+	}{{"Red", 0}, {"blue", 1}, {"Green", 0}} {
+		count, err := CountUnlinkedGames(pool, 1, test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != test.expected {
+			t.Errorf("Expected %d unlinked games as %s, got %d", test.expected, test.name, count)
+		}
+	}
+
+	// the names of a user that opts out lose the unlinked games already recorded, too
+	if err := IgnoreUnlinkedNames(pool, 1, []string{"BLUE"}); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := CountUnlinkedGames(pool, 1, "Blue"); err != nil || count != 0 {
+		t.Errorf("Expected the unlinked games as Blue to be deleted, got %d %v", count, err)
+	}
+	third := addTestGame(t, pool, 1, 200, 250)
+	if err := AddUnlinkedUserGames(pool, []*UnlinkedUserGame{unlinkedGame(third, "Blue")}); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := CountUnlinkedGames(pool, 1, "Blue"); err != nil || count != 0 {
+		t.Errorf("Expected no more unlinked games to be recorded as Blue, got %d %v", count, err)
+	}
+}
+
+func TestApproveUnlinkedClaim(t *testing.T) {
+	pool := testPool(t)
+	first := addTestGame(t, pool, 1, 0, 50)
+	second := addTestGame(t, pool, 1, 100, 150)
+	err := AddUnlinkedUserGames(pool, []*UnlinkedUserGame{unlinkedGame(first, "Soup"), unlinkedGame(second, "Soup")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// user 1 played the second game linked, under another name
+	addTestPlayer(t, pool, 1, second, 1, int16(game.ImposterRole), false)
+
+	claim := &UnlinkedClaim{GuildID: 1, UserID: 1, PlayerName: "soup", RequestTime: 200}
+	claim.ClaimID, err = AddUnlinkedClaim(pool, claim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved, answered, err := ApproveUnlinkedClaim(pool, claim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != 1 || !answered {
+		t.Errorf("Expected 1 game to be moved, got %d (answered %t)", moved, answered)
+	}
+	if pending, err := GetUnlinkedClaim(pool, 1, claim.ClaimID); err != nil || pending != nil {
+		t.Errorf("Expected the claim to be answered, got %+v %v", pending, err)
+	}
+	stats, err := GetUserStats(pool, 1, 1, AllTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Games != 2 || stats.Wins != 1 {
+		t.Errorf("Expected user 1 to have 2 games and 1 win, got %+v", stats)
+	}
+	if _, answered, err := ApproveUnlinkedClaim(pool, claim); err != nil || answered {
+		t.Errorf("Expected a claim to only be answered once, got %t %v", answered, err)
+	}
+
+	// nothing is moved for a user that opted out
+	_, err = pool.Exec(context.Background(), "INSERT INTO users (user_id, opt) VALUES (2, false);")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claim = &UnlinkedClaim{GuildID: 1, UserID: 2, PlayerName: "Soup", RequestTime: 300}
+	claim.ClaimID, err = AddUnlinkedClaim(pool, claim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	moved, answered, err = ApproveUnlinkedClaim(pool, claim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != 0 || !answered {
+		t.Errorf("Expected the claim to be answered without moving anything, got %d (answered %t)", moved, answered)
+	}
+	if count, err := CountUnlinkedGames(pool, 1, "Soup"); err != nil || count != 1 {
+		t.Errorf("Expected the game user 1 played linked to stay unlinked, got %d %v", count, err)
+	}
+}